@@ -36,10 +36,10 @@ func DefaultParams() FSRSParams {
 //
 // S'(S, G) = S * e^( w17 * ( (G - 3) + w18 ) )
 //
-// w17 => params.W[16], w18 => params.W[17]
+// w17 => params.W[17], w18 => params.W[18]
 func SameDayStability(oldS float64, grade Rating, params FSRSParams) float64 {
-	w17 := params.W[16]
-	w18 := params.W[17]
+	w17 := params.W[17]
+	w18 := params.W[18]
 	exponent := w17 * (float64(grade) - 3 + w18)
 	return oldS * math.Exp(exponent)
 }
@@ -48,19 +48,17 @@ func SameDayStability(oldS float64, grade Rating, params FSRSParams) float64 {
 //
 // D0(G) = w4 - exp( w5*(G - 1) ) + 1
 //
-// w4 => params.W[3], w5 => params.W[4]
+// w4 => params.W[4], w5 => params.W[5]
 func InitialDifficulty(grade Rating, params FSRSParams) float64 {
-	w4 := params.W[3]
-	w5 := params.W[4]
-	val := w4 - math.Exp(w5*(float64(grade)-1)) + 1
-	// clamp [1..10]
-	if val < 1 {
-		val = 1
-	}
-	if val > 10 {
-		val = 10
-	}
-	return val
+	return clampDifficulty(rawInitialDifficulty(grade, params))
+}
+
+// rawInitialDifficulty is D0(G) before clamping. The mean reversion target
+// uses the unclamped value, matching the reference implementations.
+func rawInitialDifficulty(grade Rating, params FSRSParams) float64 {
+	w4 := params.W[4]
+	w5 := params.W[5]
+	return w4 - math.Exp(w5*(float64(grade)-1)) + 1
 }
 
 // 3) Linear damping:
@@ -68,9 +66,9 @@ func InitialDifficulty(grade Rating, params FSRSParams) float64 {
 // ΔD(G) = - w6 * (G - 3)
 // D' = D + ΔD * ((10 - D)/9)
 //
-// w6 => params.W[5]
+// w6 => params.W[6]
 func LinearDampingDifficulty(oldD float64, grade Rating, params FSRSParams) float64 {
-	w6 := params.W[5]
+	w6 := params.W[6]
 	deltaD := -w6 * (float64(grade) - 3)
 	dPrime := oldD + deltaD*((10-oldD)/9)
 	return dPrime
@@ -80,21 +78,77 @@ func LinearDampingDifficulty(oldD float64, grade Rating, params FSRSParams) floa
 //
 // D” = w7 * D0(4) + (1 - w7)*D'
 //
-// w7 => params.W[6]
+// w7 => params.W[7]
 // D0(4) => initialDifficulty(Easy)
 func MeanReversionDifficulty(dPrime float64, params FSRSParams) float64 {
-	w7 := params.W[6]
-	d0Easy := InitialDifficulty(Easy, params)
+	w7 := params.W[7]
+	d0Easy := rawInitialDifficulty(Easy, params)
 	dDoublePrime := w7*d0Easy + (1-w7)*dPrime
+	return clampDifficulty(dDoublePrime)
+}
+
+// 5) Initial stability:
+//
+// S0(G) = w[G-1]
+//
+// Again => params.W[0], Hard => W[1], Good => W[2], Easy => W[3]
+func InitialStability(grade Rating, params FSRSParams) float64 {
+	s := params.W[int(grade)-1]
+	if s < 0.1 {
+		s = 0.1
+	}
+	return s
+}
+
+// 6) Stability after a successful recall:
+//
+// S'r(D, S, R, G) = S * ( e^w8 * (11 - D) * S^-w9 * (e^(w10*(1 - R)) - 1) * w15(if G=2) * w16(if G=4) + 1 )
+//
+// w8..w10 => params.W[8..10], hard penalty w15 => params.W[15], easy bonus w16 => params.W[16]
+func StabilityAfterRecall(oldD, oldS, R float64, grade Rating, params FSRSParams) float64 {
+	hardPenalty := 1.0
+	if grade == Hard {
+		hardPenalty = params.W[15]
+	}
+	easyBonus := 1.0
+	if grade == Easy {
+		easyBonus = params.W[16]
+	}
+	growth := math.Exp(params.W[8]) *
+		(11 - oldD) *
+		math.Pow(oldS, -params.W[9]) *
+		(math.Exp((1-R)*params.W[10]) - 1) *
+		hardPenalty *
+		easyBonus
+	return oldS * (growth + 1)
+}
 
-	// clamp [1..10]
-	if dDoublePrime < 1 {
-		dDoublePrime = 1
+// 7) Stability after forgetting (post-lapse stability):
+//
+// S'f(D, S, R) = w11 * D^-w12 * ((S + 1)^w13 - 1) * e^(w14*(1 - R))
+//
+// FSRS-5 additionally caps the result at S / e^(w17*w18) so that a lapse never
+// leaves the card more stable than a same-day Again would.
+//
+// w11..w14 => params.W[11..14]
+func StabilityAfterForgetting(oldD, oldS, R float64, params FSRSParams) float64 {
+	newS := params.W[11] *
+		math.Pow(oldD, -params.W[12]) *
+		(math.Pow(oldS+1, params.W[13]) - 1) *
+		math.Exp((1-R)*params.W[14])
+	maxS := oldS / math.Exp(params.W[17]*params.W[18])
+	return math.Min(newS, maxS)
+}
+
+// clampDifficulty keeps D within [1..10]
+func clampDifficulty(d float64) float64 {
+	if d < 1 {
+		return 1
 	}
-	if dDoublePrime > 10 {
-		dDoublePrime = 10
+	if d > 10 {
+		return 10
 	}
-	return dDoublePrime
+	return d
 }
 
 // Additional: FSRS-4.5 forgetting curve
@@ -124,7 +178,12 @@ func NextInterval(r float64, S float64) float64 {
 	return val
 }
 
-// Example "reviewCard" function that uses these pieces
+// ReviewCard applies a single review to a card's memory state.
+//
+// A card that has never been reviewed (oldS <= 0) takes its initial stability
+// and difficulty from the grade. Otherwise the new stability comes from the
+// same-day formula, the post-lapse formula (Again) or the recall formula,
+// evaluated with the retrievability at review time.
 func ReviewCard(
 	oldS, oldD float64,
 	daysSince float64,
@@ -134,27 +193,31 @@ func ReviewCard(
 	requestedRetention float64,
 ) (newS, newD, R, interval float64) {
 
-	// 1) Stability
-	if sameDay {
-		newS = SameDayStability(oldS, grade, params)
+	if oldS <= 0 {
+		// 1) First review: S0 and D0
+		newS = InitialStability(grade, params)
+		newD = InitialDifficulty(grade, params)
 	} else {
-		// For demonstration, if rating=Again => half the oldS, else *1.2
-		if grade == Again {
-			newS = oldS * 0.5
-		} else {
-			newS = oldS * 1.2
+		// 1) Retrievability at review time
+		R = ForgettingCurve(daysSince, oldS)
+
+		// 2) Stability
+		switch {
+		case sameDay:
+			newS = SameDayStability(oldS, grade, params)
+		case grade == Again:
+			newS = StabilityAfterForgetting(oldD, oldS, R, params)
+		default:
+			newS = StabilityAfterRecall(oldD, oldS, R, grade, params)
 		}
 		if newS < 0.1 {
 			newS = 0.1
 		}
-	}
-
-	// 2) Difficulty: linear damping + mean reversion
-	dPrime := LinearDampingDifficulty(oldD, grade, params)
-	newD = MeanReversionDifficulty(dPrime, params)
 
-	// 3) Retrievability
-	R = ForgettingCurve(daysSince, oldS)
+		// 3) Difficulty: linear damping + mean reversion
+		dPrime := LinearDampingDifficulty(oldD, grade, params)
+		newD = MeanReversionDifficulty(dPrime, params)
+	}
 
 	// 4) Next interval
 	interval = NextInterval(requestedRetention, newS)
@@ -0,0 +1,119 @@
+package algorithm
+
+import (
+	"math"
+	"testing"
+)
+
+// Golden values below were computed with the FSRS-5 formulas as implemented
+// in the reference fsrs-rs and py-fsrs schedulers, using DefaultParams().
+
+const epsilon = 1e-9
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < epsilon
+}
+
+func TestInitialState(t *testing.T) {
+	params := DefaultParams()
+	tests := []struct {
+		grade Rating
+		wantS float64
+		wantD float64
+	}{
+		{Again, 0.40255, 7.1949},
+		{Hard, 1.18385, 6.488305268471453},
+		{Good, 3.173, 5.282434422319005},
+		{Easy, 15.69105, 3.2245015893713678},
+	}
+	for _, tt := range tests {
+		if got := InitialStability(tt.grade, params); !almostEqual(got, tt.wantS) {
+			t.Errorf("InitialStability(%d) = %v, want %v", tt.grade, got, tt.wantS)
+		}
+		if got := InitialDifficulty(tt.grade, params); !almostEqual(got, tt.wantD) {
+			t.Errorf("InitialDifficulty(%d) = %v, want %v", tt.grade, got, tt.wantD)
+		}
+	}
+}
+
+func TestNextDifficulty(t *testing.T) {
+	params := DefaultParams()
+	tests := []struct {
+		grade Rating
+		want  float64
+	}{
+		{Again, 6.607035107311108},
+		{Good, 4.991832707311108},
+		{Easy, 4.184231507311108},
+	}
+	for _, tt := range tests {
+		got := MeanReversionDifficulty(LinearDampingDifficulty(5, tt.grade, params), params)
+		if !almostEqual(got, tt.want) {
+			t.Errorf("next difficulty(5, %d) = %v, want %v", tt.grade, got, tt.want)
+		}
+	}
+}
+
+func TestStabilityAfterRecall(t *testing.T) {
+	params := DefaultParams()
+	r := ForgettingCurve(10, 10)
+	if !almostEqual(r, 0.9) {
+		t.Fatalf("ForgettingCurve(10, 10) = %v, want 0.9", r)
+	}
+	tests := []struct {
+		grade Rating
+		want  float64
+	}{
+		{Hard, 15.31391211425268},
+		{Good, 32.954263992452184},
+		{Easy, 78.62865848463353},
+	}
+	for _, tt := range tests {
+		if got := StabilityAfterRecall(5, 10, r, tt.grade, params); !almostEqual(got, tt.want) {
+			t.Errorf("StabilityAfterRecall(grade %d) = %v, want %v", tt.grade, got, tt.want)
+		}
+	}
+}
+
+func TestStabilityAfterForgetting(t *testing.T) {
+	params := DefaultParams()
+	got := StabilityAfterForgetting(5, 10, 0.9, params)
+	if want := 2.107696257677866; !almostEqual(got, want) {
+		t.Errorf("StabilityAfterForgetting = %v, want %v", got, want)
+	}
+}
+
+func TestSameDayStability(t *testing.T) {
+	params := DefaultParams()
+	want := []float64{1.5030855725805248, 2.5195241231427956, 4.223313644212624, 7.079264680801638}
+	for i, grade := range []Rating{Again, Hard, Good, Easy} {
+		if got := SameDayStability(3, grade, params); !almostEqual(got, want[i]) {
+			t.Errorf("SameDayStability(3, %d) = %v, want %v", grade, got, want[i])
+		}
+	}
+}
+
+// TestReviewCardSequence replays Good x4, Again, Good x2 with every review
+// landing exactly on its due date.
+func TestReviewCardSequence(t *testing.T) {
+	params := DefaultParams()
+	grades := []Rating{Good, Good, Good, Good, Again, Good, Good}
+	wantIntervals := []float64{3, 11, 35, 101, 6, 16, 41}
+
+	var s, d, elapsed float64
+	for i, grade := range grades {
+		var interval float64
+		s, d, _, interval = ReviewCard(s, d, elapsed, grade, params, false, 0.9)
+		if got := math.Round(interval); got != wantIntervals[i] {
+			t.Fatalf("review %d: interval = %v, want %v", i, got, wantIntervals[i])
+		}
+		elapsed = math.Round(interval)
+	}
+
+	if want := 40.76793751149399; math.Abs(s-want) > 1e-6 {
+		t.Errorf("final stability = %v, want %v", s, want)
+	}
+	if want := 6.745309313900619; math.Abs(d-want) > 1e-6 {
+		t.Errorf("final difficulty = %v, want %v", d, want)
+	}
+}
@@ -0,0 +1,46 @@
+package algorithm
+
+import "time"
+
+// StudyDay says when a learner's day begins: at StartHour o'clock in
+// Location. Reviews before that hour count towards the previous day, as in
+// Anki's "next day starts at". The zero value is a day starting at midnight
+// UTC.
+type StudyDay struct {
+	Location  *time.Location
+	StartHour int
+}
+
+func (d StudyDay) location() *time.Location {
+	if d.Location == nil {
+		return time.UTC
+	}
+	return d.Location
+}
+
+// Start returns the instant, in UTC, at which the study day containing t began.
+func (d StudyDay) Start(t time.Time) time.Time {
+	local := t.In(d.location())
+	start := time.Date(local.Year(), local.Month(), local.Day(), d.StartHour, 0, 0, 0, d.location())
+	if local.Before(start) {
+		start = time.Date(local.Year(), local.Month(), local.Day()-1, d.StartHour, 0, 0, 0, d.location())
+	}
+	return start.UTC()
+}
+
+// AddDays returns t moved n days on at the same local time of day, in UTC, so
+// that it stays the same distance into its study day across DST changes.
+func (d StudyDay) AddDays(t time.Time, n int) time.Time {
+	return t.In(d.location()).AddDate(0, 0, n).UTC()
+}
+
+// Date returns the calendar date of the study day containing t, at midnight UTC.
+func (d StudyDay) Date(t time.Time) time.Time {
+	y, m, day := d.Start(t).In(d.location()).Date()
+	return time.Date(y, m, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Elapsed counts the study days from a to b.
+func (d StudyDay) Elapsed(a, b time.Time) float64 {
+	return float64(d.Date(b).Sub(d.Date(a)) / (24 * time.Hour))
+}
@@ -0,0 +1,39 @@
+package algorithm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStudyDay(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	d := StudyDay{Location: ny, StartHour: 4}
+
+	// 03:00 in New York still belongs to the previous study day
+	late := time.Date(2025, 3, 5, 3, 0, 0, 0, ny)
+	if got, want := d.Start(late), time.Date(2025, 3, 4, 4, 0, 0, 0, ny).UTC(); !got.Equal(want) {
+		t.Errorf("Start(%v) = %v, want %v", late, got, want)
+	}
+	early := time.Date(2025, 3, 5, 5, 0, 0, 0, ny)
+	if got := d.Elapsed(late, early); got != 1 {
+		t.Errorf("Elapsed across the day start = %v, want 1", got)
+	}
+	if got := d.Elapsed(early, early.Add(20*time.Hour)); got != 0 {
+		t.Errorf("Elapsed within a study day = %v, want 0", got)
+	}
+
+	// clocks go forward on 9 March; the day start stays at 04:00 local time
+	next := d.AddDays(d.Start(early), 5)
+	if want := time.Date(2025, 3, 10, 4, 0, 0, 0, ny).UTC(); !next.Equal(want) {
+		t.Errorf("AddDays over DST = %v, want %v", next, want)
+	}
+
+	var utc StudyDay
+	a := time.Date(2025, 1, 1, 23, 0, 0, 0, time.UTC)
+	if got := utc.Elapsed(a, a.Add(2*time.Hour)); got != 1 || got != ElapsedDays(a, a.Add(2*time.Hour)) {
+		t.Errorf("zero StudyDay should count UTC days, got %v", got)
+	}
+}
@@ -0,0 +1,89 @@
+package algorithm
+
+import (
+	"math"
+	"time"
+)
+
+// SpreadDays assigns n cards, in order, to days counted from 0, putting no
+// more than limit cards on a day once the load[i] cards already due on day i
+// are counted. Days past the end of load count as empty. limit must be
+// positive.
+func SpreadDays(n int, limit int64, load []int64) []int64 {
+	loadOn := func(day int64) int64 {
+		if day < int64(len(load)) {
+			return load[day]
+		}
+		return 0
+	}
+
+	days := make([]int64, 0, n)
+	day, used := int64(0), loadOn(0)
+	for len(days) < n {
+		if used >= limit {
+			day++
+			used = loadOn(day)
+			continue
+		}
+		days = append(days, day)
+		used++
+	}
+	return days
+}
+
+// Reset forgets everything the card has learned and returns it to new. Its
+// review and lapse counts are kept.
+func Reset(card CardState) CardState {
+	card.State = StateNew
+	card.Step = 0
+	card.Stability = 0
+	card.Difficulty = 0
+	card.Interval = 0
+	card.Ease = 0
+	card.Due = time.Time{}
+	return card
+}
+
+// Decay keeps only the keep fraction of a card's stability, or under SM-2 of
+// its interval. Review cards are rescheduled at the interval of their decayed
+// stability (or the decayed interval) counted in study days from their last
+// review, and are due at now if that has passed or they have no last review;
+// (re)learning cards keep their step delay.
+func Decay(card CardState, keep float64, now time.Time, cfg Config) CardState {
+	if card.State == StateNew {
+		return card
+	}
+	var days int64
+	if cfg.Scheduler == SchedulerSM2 {
+		days = int64(math.Max(1, math.Round(float64(card.Interval)*keep)))
+		if card.State != StateReview {
+			if card.State == StateRelearning {
+				card.Interval = days
+			}
+			return card
+		}
+	} else {
+		if card.Stability <= 0 {
+			return card
+		}
+		card.Stability = math.Max(card.Stability*keep, 0.01)
+		if card.State != StateReview {
+			return card
+		}
+		days = int64(math.Max(1, math.Round(NextInterval(cfg.DesiredRetention, card.Stability))))
+	}
+
+	if cfg.MaximumInterval > 0 && days > cfg.MaximumInterval {
+		days = cfg.MaximumInterval
+	}
+	card.Interval = days
+	if card.LastReview.IsZero() {
+		card.Due = now
+		return card
+	}
+	card.Due = cfg.Day.AddDays(card.LastReview, int(days))
+	if card.Due.Before(now) {
+		card.Due = now
+	}
+	return card
+}
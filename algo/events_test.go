@@ -0,0 +1,92 @@
+package algorithm
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSpreadDays(t *testing.T) {
+	cases := []struct {
+		n     int
+		limit int64
+		load  []int64
+		want  []int64
+	}{
+		{5, 2, nil, []int64{0, 0, 1, 1, 2}},
+		{4, 3, []int64{3, 1, 2}, []int64{1, 1, 2, 3}},
+		{3, 2, []int64{5, 5}, []int64{2, 2, 3}},
+		{0, 1, nil, []int64{}},
+	}
+	for _, c := range cases {
+		if got := SpreadDays(c.n, c.limit, c.load); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SpreadDays(%d, %d, %v) = %v, want %v", c.n, c.limit, c.load, got, c.want)
+		}
+	}
+}
+
+func TestDecay(t *testing.T) {
+	cfg := testConfig()
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	card := CardState{
+		State:      StateReview,
+		Stability:  40,
+		Difficulty: 5,
+		Interval:   40,
+		LastReview: now.AddDate(0, 0, -10),
+		Due:        now.AddDate(0, 0, 30),
+	}
+
+	decayed := Decay(card, 0.5, now, cfg)
+	if decayed.Stability != 20 || decayed.Difficulty != 5 {
+		t.Fatalf("decayed memory state = %v/%v, want 20/5", decayed.Stability, decayed.Difficulty)
+	}
+	if decayed.Interval != 20 || !decayed.Due.Equal(card.LastReview.AddDate(0, 0, 20)) {
+		t.Errorf("decayed schedule = %d days, due %v", decayed.Interval, decayed.Due)
+	}
+
+	if overdue := Decay(card, 0.1, now, cfg); !overdue.Due.Equal(now) {
+		t.Errorf("heavily decayed card due %v, want now", overdue.Due)
+	}
+	if fresh := Decay(CardState{State: StateNew}, 0.5, now, cfg); fresh.State != StateNew || !fresh.Due.IsZero() {
+		t.Errorf("new card changed by decay: %+v", fresh)
+	}
+}
+
+func TestDecayStudyDays(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	cfg := testConfig()
+	cfg.Day = StudyDay{Location: ny, StartHour: 4}
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	card := CardState{
+		State:      StateReview,
+		Stability:  40,
+		Difficulty: 5,
+		Interval:   40,
+		LastReview: time.Date(2025, 2, 19, 5, 0, 0, 0, ny),
+	}
+
+	// the decayed interval spans the switch to daylight saving time
+	decayed := Decay(card, 0.5, now, cfg)
+	if want := time.Date(2025, 3, 11, 5, 0, 0, 0, ny); !decayed.Due.Equal(want) {
+		t.Errorf("decayed card due %v, want %v", decayed.Due, want.UTC())
+	}
+
+	card.LastReview = time.Time{}
+	if unknown := Decay(card, 0.5, now, cfg); unknown.Interval != 20 || !unknown.Due.Equal(now) {
+		t.Errorf("card without a last review decayed to %d days, due %v", unknown.Interval, unknown.Due)
+	}
+}
+
+func TestReset(t *testing.T) {
+	card := Reset(CardState{State: StateReview, Stability: 12, Difficulty: 6, Interval: 12, Reps: 5, Lapses: 1, Due: time.Now()})
+	if card.State != StateNew || card.Stability != 0 || card.Interval != 0 || !card.Due.IsZero() {
+		t.Errorf("reset card = %+v", card)
+	}
+	if card.Reps != 5 || card.Lapses != 1 {
+		t.Errorf("reset lost history: reps %d lapses %d", card.Reps, card.Lapses)
+	}
+}
@@ -0,0 +1,107 @@
+package algorithm
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// LoadBalancer reports how busy the days an interval may be fuzzed to are.
+type LoadBalancer interface {
+	// DueCounts returns the number of cards already due on each day from
+	// minDays to maxDays (inclusive) after now. A result of any other length
+	// disables balancing for that review.
+	DueCounts(now time.Time, minDays, maxDays int64) []int64
+}
+
+// fuzzRanges widen the fuzz range by factor for each day of the interval
+// that falls between start and end, as in the reference FSRS schedulers.
+var fuzzRanges = []struct {
+	start, end, factor float64
+}{
+	{2.5, 7, 0.15},
+	{7, 20, 0.1},
+	{20, math.Inf(1), 0.05},
+}
+
+// FuzzRange returns the smallest and largest number of days interval may be
+// fuzzed to, never more than maximum (when positive). Intervals under 2.5
+// days are not fuzzed.
+func FuzzRange(interval float64, maximum int64) (int64, int64) {
+	if maximum > 0 {
+		interval = math.Min(interval, float64(maximum))
+	}
+	if interval < 2.5 {
+		days := int64(math.Max(1, math.Round(interval)))
+		return days, days
+	}
+
+	delta := 1.0
+	for _, r := range fuzzRanges {
+		delta += r.factor * math.Max(math.Min(interval, r.end)-r.start, 0)
+	}
+	lo := int64(math.Max(2, math.Round(interval-delta)))
+	hi := int64(math.Round(interval + delta))
+	if maximum > 0 && hi > maximum {
+		hi = maximum
+	}
+	if lo > hi {
+		lo = hi
+	}
+	return lo, hi
+}
+
+// FuzzSeed derives a reproducible fuzz seed from a card id and its review count.
+func FuzzSeed(cardID string, reps int64) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(cardID))
+	return int64(h.Sum64()) ^ reps
+}
+
+// reviewDays turns a review interval into whole days. With fuzzing or a load
+// balancer the result is picked from FuzzRange using seed; the balancer picks
+// the least busy day, breaking ties at random.
+func (cfg Config) reviewDays(interval float64, seed int64, now time.Time) int64 {
+	days := int64(math.Max(1, math.Round(interval)))
+	if cfg.MaximumInterval > 0 && days > cfg.MaximumInterval {
+		days = cfg.MaximumInterval
+	}
+	if !cfg.Fuzz && cfg.LoadBalancer == nil {
+		return days
+	}
+
+	lo, hi := FuzzRange(interval, cfg.MaximumInterval)
+	if lo == hi {
+		return lo
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	if cfg.LoadBalancer != nil {
+		counts := cfg.LoadBalancer.DueCounts(now, lo, hi)
+		if len(counts) == int(hi-lo+1) {
+			return lo + int64(leastLoaded(counts, rng))
+		}
+	}
+	if !cfg.Fuzz {
+		return days
+	}
+	return lo + rng.Int63n(hi-lo+1)
+}
+
+// leastLoaded returns the index of the smallest count, picking uniformly among ties.
+func leastLoaded(counts []int64, rng *rand.Rand) int {
+	best, ties := 0, 0
+	for i, n := range counts {
+		switch {
+		case n < counts[best]:
+			best, ties = i, 1
+		case n == counts[best]:
+			ties++
+			if rng.Intn(ties) == 0 {
+				best = i
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,79 @@
+package algorithm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFuzzRange(t *testing.T) {
+	cases := []struct {
+		interval float64
+		maximum  int64
+		lo, hi   int64
+	}{
+		{1.4, 36500, 1, 1},
+		{2.4, 36500, 2, 2},
+		{3, 36500, 2, 4},
+		{10, 36500, 8, 12},
+		{100, 36500, 93, 107},
+		{100, 100, 93, 100},
+	}
+	for _, c := range cases {
+		lo, hi := FuzzRange(c.interval, c.maximum)
+		if lo != c.lo || hi != c.hi {
+			t.Errorf("FuzzRange(%v, %d) = [%d, %d], want [%d, %d]", c.interval, c.maximum, lo, hi, c.lo, c.hi)
+		}
+	}
+}
+
+func TestReviewDaysFuzzIsSeeded(t *testing.T) {
+	cfg := Config{MaximumInterval: 36500, Fuzz: true}
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	seed := FuzzSeed("abcdef0123", 3)
+
+	first := cfg.reviewDays(50, seed, now)
+	for i := 0; i < 10; i++ {
+		if got := cfg.reviewDays(50, seed, now); got != first {
+			t.Fatalf("reviewDays not reproducible: %d then %d", first, got)
+		}
+	}
+	lo, hi := FuzzRange(50, cfg.MaximumInterval)
+	if first < lo || first > hi {
+		t.Fatalf("reviewDays = %d, outside [%d, %d]", first, lo, hi)
+	}
+
+	seen := map[int64]bool{}
+	for reps := int64(0); reps < 50; reps++ {
+		seen[cfg.reviewDays(50, FuzzSeed("abcdef0123", reps), now)] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("fuzz never varied across seeds: %v", seen)
+	}
+
+	cfg.Fuzz = false
+	if got := cfg.reviewDays(50, seed, now); got != 50 {
+		t.Errorf("reviewDays without fuzz = %d, want 50", got)
+	}
+}
+
+type fixedLoad []int64
+
+func (f fixedLoad) DueCounts(now time.Time, minDays, maxDays int64) []int64 {
+	return f[minDays : maxDays+1]
+}
+
+func TestReviewDaysLoadBalance(t *testing.T) {
+	load := make(fixedLoad, 20)
+	for i := range load {
+		load[i] = 10
+	}
+	load[11] = 2
+
+	cfg := Config{MaximumInterval: 36500, LoadBalancer: load}
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	for reps := int64(0); reps < 10; reps++ {
+		if got := cfg.reviewDays(10, FuzzSeed("abcdef0123", reps), now); got != 11 {
+			t.Fatalf("balanced reviewDays = %d, want least loaded day 11", got)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package algorithm
+
+// IsLeech reports whether a card that has just lapsed for the lapses-th time
+// becomes a leech: at threshold lapses and then every half threshold after,
+// as in Anki, so that a leech that keeps lapsing is flagged again.
+func IsLeech(lapses, threshold int64) bool {
+	if threshold <= 0 || lapses < threshold {
+		return false
+	}
+	return (lapses-threshold)%max(threshold/2, 1) == 0
+}
@@ -0,0 +1,24 @@
+package algorithm
+
+import "testing"
+
+func TestIsLeech(t *testing.T) {
+	tests := []struct {
+		lapses, threshold int64
+		want              bool
+	}{
+		{7, 8, false},
+		{8, 8, true},
+		{9, 8, false},
+		{12, 8, true},
+		{16, 8, true},
+		{1, 1, true},
+		{2, 1, true},
+		{3, 0, false},
+	}
+	for _, tt := range tests {
+		if got := IsLeech(tt.lapses, tt.threshold); got != tt.want {
+			t.Errorf("IsLeech(%d, %d) = %v, want %v", tt.lapses, tt.threshold, got, tt.want)
+		}
+	}
+}
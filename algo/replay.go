@@ -0,0 +1,58 @@
+package algorithm
+
+import (
+	"time"
+)
+
+// LoggedReview is a review as the review log records it.
+type LoggedReview struct {
+	Grade Rating
+	Time  time.Time
+}
+
+// Amnesia is what an amnesia event did to a card at At: a reset returned it to
+// new, and a decay left it with State's stability, difficulty, interval and
+// due date.
+type Amnesia struct {
+	At    time.Time
+	Reset bool
+	State CardState
+}
+
+// Replay runs a card's logged reviews through the scheduler from a new card,
+// seeding the fuzz of each review with FuzzSeed as the review was. When
+// amnesia is not nil the reviews after it start from the card it left rather
+// than from the replayed one, so that replaying does not undo the event.
+func Replay(cardID string, reviews []LoggedReview, amnesia *Amnesia, cfg Config) CardState {
+	scheduler := NewScheduler(cfg)
+	state := CardState{State: StateNew}
+	applied := amnesia == nil
+	for _, review := range reviews {
+		if !applied && review.Time.After(amnesia.At) {
+			state = amnesia.apply(state)
+			applied = true
+		}
+		state.Seed = FuzzSeed(cardID, state.Reps)
+		state, _ = scheduler.Next(state, review.Grade, review.Time)
+	}
+	if !applied {
+		state = amnesia.apply(state)
+	}
+	return state
+}
+
+// apply does to the replayed card what the event did; the review and lapse
+// counts, and under SM-2 the ease, are the replay's.
+func (a *Amnesia) apply(card CardState) CardState {
+	if a.Reset {
+		return Reset(card)
+	}
+	if card.State == StateNew {
+		return card
+	}
+	card.Stability = a.State.Stability
+	card.Difficulty = a.State.Difficulty
+	card.Interval = a.State.Interval
+	card.Due = a.State.Due
+	return card
+}
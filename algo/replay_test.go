@@ -0,0 +1,71 @@
+package algorithm
+
+import (
+	"testing"
+	"time"
+)
+
+func replayLog(start time.Time, grades ...Rating) []LoggedReview {
+	reviews := make([]LoggedReview, len(grades))
+	for i, grade := range grades {
+		reviews[i] = LoggedReview{Grade: grade, Time: start.AddDate(0, 0, 3*i)}
+	}
+	return reviews
+}
+
+func TestReplayMatchesReviews(t *testing.T) {
+	cfg := testConfig()
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	reviews := replayLog(start, Good, Good, Again, Good)
+
+	want := CardState{State: StateNew}
+	for _, review := range reviews {
+		want.Seed = FuzzSeed("abcdef0123", want.Reps)
+		want, _ = Next(want, review.Grade, review.Time, cfg)
+	}
+	if got := Replay("abcdef0123", reviews, nil, cfg); got != want {
+		t.Errorf("Replay = %+v, want %+v", got, want)
+	}
+}
+
+func TestReplayAfterReset(t *testing.T) {
+	cfg := testConfig()
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	reviews := replayLog(start, Good, Good, Good, Good)
+	reset := &Amnesia{At: reviews[2].Time.Add(time.Hour), Reset: true}
+
+	before := Replay("abcdef0123", reviews[:3], nil, cfg)
+	want := Reset(before)
+	want.Seed = FuzzSeed("abcdef0123", want.Reps)
+	want, _ = Next(want, Good, reviews[3].Time, cfg)
+
+	got := Replay("abcdef0123", reviews, reset, cfg)
+	if got != want {
+		t.Fatalf("Replay after reset = %+v, want %+v", got, want)
+	}
+	if got.Stability != InitialStability(Good, cfg.Params) || got.Reps != 4 {
+		t.Errorf("reset card replayed to stability %v after %d reps", got.Stability, got.Reps)
+	}
+
+	// with no review since, the card is left as the reset left it
+	if got := Replay("abcdef0123", reviews[:3], reset, cfg); got.State != StateNew || got.Reps != 3 {
+		t.Errorf("Replay up to reset = %+v", got)
+	}
+}
+
+func TestReplayAfterDecay(t *testing.T) {
+	cfg := testConfig()
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	reviews := replayLog(start, Good, Good, Good, Good)
+	at := reviews[2].Time.Add(time.Hour)
+	decayed := Decay(Replay("abcdef0123", reviews[:3], nil, cfg), 0.5, at, cfg)
+	decay := &Amnesia{At: at, State: decayed}
+
+	want := decayed
+	want.Seed = FuzzSeed("abcdef0123", want.Reps)
+	want, _ = Next(want, Good, reviews[3].Time, cfg)
+
+	if got := Replay("abcdef0123", reviews, decay, cfg); got != want {
+		t.Errorf("Replay after decay = %+v, want %+v", got, want)
+	}
+}
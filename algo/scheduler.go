@@ -0,0 +1,39 @@
+package algorithm
+
+import (
+	"time"
+)
+
+// SchedulerKind names a scheduling algorithm.
+type SchedulerKind string
+
+const (
+	SchedulerFSRS SchedulerKind = "fsrs"
+	SchedulerSM2  SchedulerKind = "sm2"
+)
+
+// Scheduler decides when a card is shown next.
+type Scheduler interface {
+	// Next answers card with grade at now and returns its new state, due at
+	// its Due, together with the card's retrievability at the time of the
+	// review. Schedulers without a memory model report a retrievability of 0.
+	Next(card CardState, grade Rating, now time.Time) (CardState, float64)
+}
+
+// NewScheduler returns the scheduler cfg.Scheduler selects, FSRS by default.
+func NewScheduler(cfg Config) Scheduler {
+	if cfg.Scheduler == SchedulerSM2 {
+		return SM2{Config: cfg}
+	}
+	return FSRS{Config: cfg}
+}
+
+// FSRS schedules cards from their FSRS memory state; see Next.
+type FSRS struct {
+	Config Config
+}
+
+// Next implements Scheduler.
+func (s FSRS) Next(card CardState, grade Rating, now time.Time) (CardState, float64) {
+	return Next(card, grade, now, s.Config)
+}
@@ -0,0 +1,203 @@
+package algorithm
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// maxDailyAnswers bounds how often one card is answered on a simulated day,
+// so that a card stuck on its (re)learning steps cannot stall the simulation.
+const maxDailyAnswers = 20
+
+// SimulationDeck is a deck to simulate: its scheduler options, its cards in
+// the order they are studied and its daily limits.
+type SimulationDeck struct {
+	Config         Config
+	Cards          []CardState
+	NewCardsPerDay int64
+	ReviewsPerDay  int64
+}
+
+// SimulationOptions control Simulate.
+type SimulationOptions struct {
+	Days int
+	// Runs is the number of simulations averaged, at least one.
+	Runs int
+	// Seed makes the simulation reproducible. Run i is seeded with Seed+i.
+	Seed int64
+	// SecondsPerReview converts reviews into study time.
+	SecondsPerReview float64
+	// Day is the learner's study day, which the simulated days follow.
+	Day StudyDay
+}
+
+// SimulationResult is the expected outcome of each simulated day, averaged
+// over the runs.
+type SimulationResult struct {
+	ReviewsPerDay []float64
+	MinutesPerDay []float64
+	// MemorizedPerDay is the expected number of cards recalled at the end of
+	// each day: the sum of the retrievability of every card studied so far.
+	MemorizedPerDay []float64
+}
+
+// simCard is a card being simulated. The card's FSRS memory state is taken as
+// the truth about what the learner remembers, whichever scheduler decides its
+// intervals.
+type simCard struct {
+	state      CardState
+	stability  float64
+	difficulty float64
+	lastReview time.Time
+}
+
+// Simulate runs a Monte-Carlo simulation of studying decks for opts.Days study
+// days from the one containing start. Each day introduces up to the deck's new card limit, and answers
+// its due review cards up to the review limit and every (re)learning card
+// until it leaves the day. A card is recalled with probability equal to its
+// retrievability under the deck's FSRS parameters and then answered Good,
+// otherwise Again; new cards are answered Good the first time. It stops with
+// ctx's error when ctx is done.
+func Simulate(ctx context.Context, decks []SimulationDeck, start time.Time, opts SimulationOptions) (SimulationResult, error) {
+	result := SimulationResult{
+		ReviewsPerDay:   make([]float64, opts.Days),
+		MinutesPerDay:   make([]float64, opts.Days),
+		MemorizedPerDay: make([]float64, opts.Days),
+	}
+	runs := max(opts.Runs, 1)
+	for run := 0; run < runs; run++ {
+		rng := rand.New(rand.NewSource(opts.Seed + int64(run)))
+		reviews, memorized, err := simulateRun(ctx, decks, start, opts.Days, opts.Day, rng)
+		if err != nil {
+			return SimulationResult{}, err
+		}
+		for day := range reviews {
+			result.ReviewsPerDay[day] += float64(reviews[day]) / float64(runs)
+			result.MemorizedPerDay[day] += memorized[day] / float64(runs)
+		}
+	}
+	for day, n := range result.ReviewsPerDay {
+		result.MinutesPerDay[day] = n * opts.SecondsPerReview / 60
+	}
+	return result, nil
+}
+
+// simulateRun simulates the decks once, returning the reviews done and the
+// expected cards memorized on each day. Cards are studied at the start of each
+// study day, and on the first from start.
+func simulateRun(ctx context.Context, decks []SimulationDeck, start time.Time, days int, studyDay StudyDay, rng *rand.Rand) ([]int64, []float64, error) {
+	cards := make([][]simCard, len(decks))
+	for i, deck := range decks {
+		cards[i] = make([]simCard, 0, len(deck.Cards))
+		for _, state := range deck.Cards {
+			cards[i] = append(cards[i], newSimCard(state, start))
+		}
+	}
+
+	reviews := make([]int64, days)
+	memorized := make([]float64, days)
+	today := studyDay.Start(start)
+	for day := 0; day < days; day++ {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		now := studyDay.AddDays(today, day)
+		if now.Before(start) {
+			now = start
+		}
+		end := studyDay.AddDays(today, day+1)
+		for i, deck := range decks {
+			scheduler := NewScheduler(deck.Config)
+			newLeft, reviewLeft := deck.NewCardsPerDay, deck.ReviewsPerDay
+			for j := range cards[i] {
+				card := &cards[i][j]
+				switch {
+				case card.state.State == StateNew:
+					if newLeft <= 0 {
+						continue
+					}
+					newLeft--
+				case !card.state.Due.Before(end):
+					continue
+				case card.state.State == StateReview:
+					if reviewLeft <= 0 {
+						continue
+					}
+					reviewLeft--
+				}
+				reviews[day] += card.study(scheduler, deck.Config, now, end, rng)
+			}
+		}
+
+		for i := range cards {
+			for _, card := range cards[i] {
+				if card.stability > 0 {
+					memorized[day] += ForgettingCurve(decks[i].Config.Day.Elapsed(card.lastReview, end), card.stability)
+				}
+			}
+		}
+	}
+	return reviews, memorized, nil
+}
+
+// newSimCard starts simulating state at start. Cards without an FSRS memory
+// state, such as those scheduled by SM-2, are assumed to be at 90% retention
+// at their interval, and cards without a last review to have been reviewed
+// interval days before they are due.
+func newSimCard(state CardState, start time.Time) simCard {
+	card := simCard{
+		state:      state,
+		stability:  state.Stability,
+		difficulty: state.Difficulty,
+		lastReview: state.LastReview,
+	}
+	if state.State == StateNew {
+		card.stability, card.difficulty = 0, 0
+		return card
+	}
+	if card.stability <= 0 {
+		card.stability = float64(max(state.Interval, 1))
+		card.difficulty = 5
+	}
+	if card.lastReview.IsZero() {
+		card.lastReview = start
+		if state.State == StateReview && !state.Due.IsZero() {
+			card.lastReview = state.Due.AddDate(0, 0, -int(state.Interval))
+		}
+		card.state.LastReview = card.lastReview
+	}
+	return card
+}
+
+// study answers the card from now until it is no longer due before end and
+// returns the number of answers.
+func (c *simCard) study(scheduler Scheduler, cfg Config, now, end time.Time, rng *rand.Rand) int64 {
+	var answers int64
+	for answers < maxDailyAnswers {
+		at := now
+		if c.state.State != StateNew && c.state.Due.After(at) {
+			at = c.state.Due
+		}
+
+		grade := Good
+		elapsed := 0.0
+		if c.stability > 0 {
+			elapsed = cfg.Day.Elapsed(c.lastReview, at)
+			if rng.Float64() >= ForgettingCurve(elapsed, c.stability) {
+				grade = Again
+			}
+		}
+		sameDay := c.stability > 0 && elapsed == 0
+		c.stability, c.difficulty, _, _ = ReviewCard(c.stability, c.difficulty, elapsed, grade, cfg.Params, sameDay, 0.9)
+		c.lastReview = at
+
+		c.state.Seed = rng.Int63()
+		c.state, _ = scheduler.Next(c.state, grade, at)
+		answers++
+		if !c.state.Due.Before(end) {
+			break
+		}
+	}
+	return answers
+}
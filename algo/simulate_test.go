@@ -0,0 +1,101 @@
+package algorithm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testSimulationDecks(retention float64) []SimulationDeck {
+	cfg := testConfig()
+	cfg.DesiredRetention = retention
+	cards := make([]CardState, 200)
+	for i := range cards {
+		cards[i] = CardState{State: StateNew}
+	}
+	return []SimulationDeck{{Config: cfg, Cards: cards, NewCardsPerDay: 20, ReviewsPerDay: 1000}}
+}
+
+func simulate(t *testing.T, decks []SimulationDeck, start time.Time, opts SimulationOptions) SimulationResult {
+	t.Helper()
+	result, err := Simulate(context.Background(), decks, start, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestSimulateSeeded(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	opts := SimulationOptions{Days: 30, Runs: 3, Seed: 42, SecondsPerReview: 6}
+
+	a := simulate(t, testSimulationDecks(0.9), start, opts)
+	b := simulate(t, testSimulationDecks(0.9), start, opts)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("same seed gave different results")
+	}
+	if a.ReviewsPerDay[0] < 20 {
+		t.Errorf("first day reviews = %v, want at least the 20 new cards", a.ReviewsPerDay[0])
+	}
+	if want := a.ReviewsPerDay[5] * 6 / 60; !almostEqual(a.MinutesPerDay[5], want) {
+		t.Errorf("minutes = %v, want %v", a.MinutesPerDay[5], want)
+	}
+	if a.MemorizedPerDay[29] <= a.MemorizedPerDay[0] || a.MemorizedPerDay[29] > 200 {
+		t.Errorf("memorized went from %v to %v", a.MemorizedPerDay[0], a.MemorizedPerDay[29])
+	}
+}
+
+func TestSimulateRetentionCost(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	opts := SimulationOptions{Days: 60, Runs: 5, Seed: 7}
+
+	total := func(days []float64) float64 {
+		var sum float64
+		for _, n := range days {
+			sum += n
+		}
+		return sum
+	}
+	low := simulate(t, testSimulationDecks(0.8), start, opts)
+	high := simulate(t, testSimulationDecks(0.95), start, opts)
+	if total(high.ReviewsPerDay) <= total(low.ReviewsPerDay) {
+		t.Errorf("95%% retention should cost more reviews than 80%%: %v <= %v", total(high.ReviewsPerDay), total(low.ReviewsPerDay))
+	}
+	if high.MemorizedPerDay[59] <= low.MemorizedPerDay[59] {
+		t.Errorf("95%% retention should memorize more than 80%%: %v <= %v", high.MemorizedPerDay[59], low.MemorizedPerDay[59])
+	}
+}
+
+func TestSimulateCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	_, err := Simulate(ctx, testSimulationDecks(0.9), start, SimulationOptions{Days: 30, Runs: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestSimulateStudyDays(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	day := StudyDay{Location: ny, StartHour: 4}
+	cfg := testConfig()
+	cfg.Day = day
+	start := time.Date(2025, 1, 1, 23, 0, 0, 0, ny)
+	cards := []CardState{
+		// due before the study day ends at 04:00, and after it
+		{State: StateReview, Stability: 10, Difficulty: 5, Interval: 10, Due: time.Date(2025, 1, 2, 3, 0, 0, 0, ny)},
+		{State: StateReview, Stability: 10, Difficulty: 5, Interval: 10, Due: time.Date(2025, 1, 2, 10, 0, 0, 0, ny)},
+	}
+	decks := []SimulationDeck{{Config: cfg, Cards: cards, ReviewsPerDay: 100}}
+
+	result := simulate(t, decks, start, SimulationOptions{Days: 2, Seed: 1, Day: day})
+	if result.ReviewsPerDay[0] < 1 || result.ReviewsPerDay[1] < 1 {
+		t.Errorf("reviews per day = %v, want a card on each study day", result.ReviewsPerDay)
+	}
+}
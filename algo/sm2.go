@@ -0,0 +1,135 @@
+package algorithm
+
+import (
+	"math"
+	"time"
+)
+
+// minimumEase is the lowest ease factor SM-2 lets a card fall to.
+const minimumEase = 1.3
+
+// SM2Params are the options of the SM-2 scheduler, named after Anki's.
+type SM2Params struct {
+	// StartingEase is the ease factor a card graduates from learning with.
+	StartingEase float64
+	// EasyBonus multiplies the interval of an Easy review.
+	EasyBonus float64
+	// IntervalModifier multiplies every review interval.
+	IntervalModifier float64
+	// HardInterval multiplies the interval of a Hard review.
+	HardInterval float64
+	// LapseInterval is the fraction of its interval a card keeps after a lapse.
+	LapseInterval float64
+	// GraduatingInterval and EasyInterval are the first review intervals, in
+	// days, of a card that passes its learning steps with Good and with Easy.
+	GraduatingInterval int64
+	EasyInterval       int64
+}
+
+// DefaultSM2Params returns Anki's defaults.
+func DefaultSM2Params() SM2Params {
+	return SM2Params{
+		StartingEase:       2.5,
+		EasyBonus:          1.3,
+		IntervalModifier:   1,
+		HardInterval:       1.2,
+		LapseInterval:      0,
+		GraduatingInterval: 1,
+		EasyInterval:       4,
+	}
+}
+
+// SM2 schedules cards with SuperMemo-2 as Anki implements it: cards walk the
+// same learning and relearning steps as under FSRS, and review intervals grow
+// by a per-card ease factor that Again, Hard and Easy adjust. It keeps no
+// memory model, so the card's stability and difficulty are left as they are.
+type SM2 struct {
+	Config Config
+}
+
+// Next implements Scheduler.
+func (s SM2) Next(card CardState, grade Rating, now time.Time) (CardState, float64) {
+	next := card
+	next.LastReview = now
+	next.Reps++
+
+	switch card.State {
+	case StateReview:
+		return s.review(next, card.LastReview, grade, now), 0
+	case StateLearning:
+		return s.step(next, StateLearning, s.Config.LearningSteps, grade, now), 0
+	case StateRelearning:
+		return s.step(next, StateRelearning, s.Config.RelearningSteps, grade, now), 0
+	default:
+		next.Step = 0
+		next.Interval = 0
+		return s.step(next, StateLearning, s.Config.LearningSteps, grade, now), 0
+	}
+}
+
+// step moves a card through steps. Passing the learning steps graduates it at
+// the graduating (or, on Easy, easy) interval with the starting ease; passing
+// the relearning steps returns it to review at the interval kept after its
+// lapse, which SM-2 holds in Interval while the card relearns.
+func (s SM2) step(card CardState, state State, steps []time.Duration, grade Rating, now time.Time) CardState {
+	next, delay, passed := advanceStep(steps, card.Step, grade)
+	if !passed {
+		card.State = state
+		card.Step = next
+		card.Due = now.Add(delay)
+		return card
+	}
+
+	days := card.Interval
+	if state == StateLearning {
+		card.Ease = s.Config.SM2.StartingEase
+		days = s.Config.SM2.GraduatingInterval
+		if grade == Easy {
+			days = s.Config.SM2.EasyInterval
+		}
+	}
+	return s.Config.scheduleReview(card, float64(max(days, 1)), now)
+}
+
+// review answers a review card last reviewed at last. Intervals grow from the
+// days actually waited: a late card is credited with half the extra days on
+// Good and all of them on Easy, and a card answered early grows from the
+// shorter wait. Hard, Good and Easy each give at least a day more than the
+// answer before them.
+func (s SM2) review(card CardState, last time.Time, grade Rating, now time.Time) CardState {
+	p := s.Config.SM2
+	ease := card.Ease
+	if ease <= 0 {
+		ease = p.StartingEase
+	}
+
+	interval := float64(max(card.Interval, 1))
+	late := 0.0
+	if !last.IsZero() {
+		elapsed := math.Max(s.Config.Day.Elapsed(last, now), 1)
+		late = math.Max(elapsed-interval, 0)
+		interval = math.Min(interval, elapsed)
+	}
+
+	hard := math.Max(interval+1, interval*p.HardInterval*p.IntervalModifier)
+	good := math.Max(hard+1, (interval+late/2)*ease*p.IntervalModifier)
+	easy := math.Max(good+1, (interval+late)*ease*p.EasyBonus*p.IntervalModifier)
+
+	switch grade {
+	case Again:
+		card.Lapses++
+		card.Ease = math.Max(ease-0.2, minimumEase)
+		card.Interval = int64(math.Max(1, math.Round(float64(card.Interval)*p.LapseInterval)))
+		card.Step = 0
+		return s.step(card, StateRelearning, s.Config.RelearningSteps, grade, now)
+	case Hard:
+		card.Ease = math.Max(ease-0.15, minimumEase)
+		return s.Config.scheduleReview(card, hard, now)
+	case Easy:
+		card.Ease = ease + 0.15
+		return s.Config.scheduleReview(card, easy, now)
+	default:
+		card.Ease = ease
+		return s.Config.scheduleReview(card, good, now)
+	}
+}
@@ -0,0 +1,90 @@
+package algorithm
+
+import (
+	"testing"
+	"time"
+)
+
+func testSM2() SM2 {
+	cfg := testConfig()
+	cfg.Scheduler = SchedulerSM2
+	cfg.SM2 = DefaultSM2Params()
+	return SM2{Config: cfg}
+}
+
+func TestNewScheduler(t *testing.T) {
+	cfg := testConfig()
+	if _, ok := NewScheduler(cfg).(FSRS); !ok {
+		t.Errorf("default scheduler should be FSRS")
+	}
+	cfg.Scheduler = SchedulerSM2
+	if _, ok := NewScheduler(cfg).(SM2); !ok {
+		t.Errorf("sm2 scheduler should be SM2")
+	}
+}
+
+func TestSM2Graduation(t *testing.T) {
+	s := testSM2()
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	card, R := s.Next(CardState{State: StateNew}, Good, now)
+	if card.State != StateLearning || card.Step != 1 || !card.Due.Equal(now.Add(10*time.Minute)) || R != 0 {
+		t.Fatalf("good on new card: got %+v, R %v", card, R)
+	}
+	card, _ = s.Next(card, Good, now.Add(10*time.Minute))
+	if card.State != StateReview || card.Interval != 1 || card.Ease != 2.5 {
+		t.Fatalf("good on last step: got %+v", card)
+	}
+
+	card, _ = s.Next(CardState{State: StateNew}, Easy, now)
+	if card.State != StateReview || card.Interval != 4 || !card.Due.Equal(now.AddDate(0, 0, 4)) {
+		t.Fatalf("easy on new card: got %+v", card)
+	}
+}
+
+func TestSM2Review(t *testing.T) {
+	s := testSM2()
+	last := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	card := CardState{State: StateReview, Interval: 10, Ease: 2.5, LastReview: last}
+
+	tests := []struct {
+		name     string
+		grade    Rating
+		days     int
+		interval int64
+		ease     float64
+	}{
+		{"hard on time", Hard, 10, 12, 2.35},
+		{"good on time", Good, 10, 25, 2.5},
+		{"easy on time", Easy, 10, 33, 2.65},
+		{"good late", Good, 14, 30, 2.5},
+		{"good early", Good, 4, 10, 2.5},
+	}
+	for _, tt := range tests {
+		got, _ := s.Next(card, tt.grade, last.AddDate(0, 0, tt.days))
+		if got.State != StateReview || got.Interval != tt.interval || !almostEqual(got.Ease, tt.ease) {
+			t.Errorf("%s: got interval %d ease %v, want %d and %v", tt.name, got.Interval, got.Ease, tt.interval, tt.ease)
+		}
+	}
+}
+
+func TestSM2Lapse(t *testing.T) {
+	s := testSM2()
+	last := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	card := CardState{State: StateReview, Interval: 10, Ease: 2.5, LastReview: last, Stability: 7}
+
+	now := last.AddDate(0, 0, 10)
+	card, _ = s.Next(card, Again, now)
+	if card.State != StateRelearning || card.Lapses != 1 || !almostEqual(card.Ease, 2.3) || card.Interval != 1 {
+		t.Fatalf("again on review card: got %+v", card)
+	}
+	if card.Stability != 7 {
+		t.Errorf("sm2 should leave stability alone, got %v", card.Stability)
+	}
+
+	now = now.Add(10 * time.Minute)
+	card, _ = s.Next(card, Good, now)
+	if card.State != StateReview || card.Interval != 1 || !card.Due.Equal(now.AddDate(0, 0, 1)) {
+		t.Fatalf("good after relearning: got %+v", card)
+	}
+}
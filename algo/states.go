@@ -0,0 +1,183 @@
+package algorithm
+
+import (
+	"time"
+)
+
+// State is the scheduling state of a card.
+type State string
+
+const (
+	StateNew        State = "new"
+	StateLearning   State = "learning"
+	StateReview     State = "review"
+	StateRelearning State = "relearning"
+)
+
+// CardState is everything the scheduler needs to know about a card.
+type CardState struct {
+	State State
+	// Step is the index of the current (re)learning step.
+	Step       int
+	Stability  float64
+	Difficulty float64
+	// Interval is the review interval in days. Under FSRS it is 0 while the
+	// card is in (re)learning, where Due carries the minute-granular step
+	// delay; SM-2 keeps the interval a lapsed card returns to there.
+	Interval int64
+	// Ease is the SM-2 ease factor, 0 until the card graduates under SM-2.
+	Ease float64
+	Due  time.Time
+	// LastReview is the time of the previous review, zero if there is none.
+	LastReview time.Time
+	Reps       int64
+	Lapses     int64
+	// Seed makes interval fuzz reproducible; see FuzzSeed.
+	Seed int64
+}
+
+// Config holds the options that drive the state machine.
+type Config struct {
+	// Scheduler selects the algorithm NewScheduler returns.
+	Scheduler        SchedulerKind
+	Params           FSRSParams
+	SM2              SM2Params
+	DesiredRetention float64
+	MaximumInterval  int64
+	LearningSteps    []time.Duration
+	RelearningSteps  []time.Duration
+	// Fuzz spreads review intervals over a small range so that cards
+	// learned together do not keep coming due on the same day.
+	Fuzz bool
+	// LoadBalancer, when set, moves reviews to the least busy day of the
+	// fuzz range.
+	LoadBalancer LoadBalancer
+	// Day decides which reviews fall on the same day and how days are
+	// counted between reviews.
+	Day StudyDay
+}
+
+// Next answers card with grade at now under FSRS and returns its new state
+// together with the card's retrievability at the time of the review.
+//
+// New cards take their initial memory state from the grade and then walk the
+// learning steps; reviews of a card already seen today use the same-day
+// stability formula. Again on a review card counts a lapse and moves it to the
+// relearning steps. A card graduates to review once it passes its last step,
+// or immediately on Easy or when there are no steps.
+func Next(card CardState, grade Rating, now time.Time, cfg Config) (CardState, float64) {
+	var oldS, oldD, daysSince float64
+	var sameDay bool
+	if card.State != StateNew && card.Stability > 0 {
+		oldS, oldD = card.Stability, card.Difficulty
+		if !card.LastReview.IsZero() {
+			daysSince = cfg.Day.Elapsed(card.LastReview, now)
+			sameDay = daysSince == 0
+		}
+	}
+
+	newS, newD, R, _ := ReviewCard(oldS, oldD, daysSince, grade, cfg.Params, sameDay, cfg.DesiredRetention)
+
+	next := card
+	next.Stability = newS
+	next.Difficulty = newD
+	next.LastReview = now
+	next.Reps++
+
+	switch card.State {
+	case StateReview:
+		if grade == Again {
+			next.Lapses++
+			next.Step = 0
+			return cfg.step(next, StateRelearning, cfg.RelearningSteps, grade, now), R
+		}
+		return cfg.graduate(next, now), R
+	case StateLearning:
+		return cfg.step(next, StateLearning, cfg.LearningSteps, grade, now), R
+	case StateRelearning:
+		return cfg.step(next, StateRelearning, cfg.RelearningSteps, grade, now), R
+	default:
+		next.Step = 0
+		return cfg.step(next, StateLearning, cfg.LearningSteps, grade, now), R
+	}
+}
+
+// step moves a card through steps, starting from card.Step, graduating it
+// once it passes the last one.
+func (cfg Config) step(card CardState, state State, steps []time.Duration, grade Rating, now time.Time) CardState {
+	next, delay, passed := advanceStep(steps, card.Step, grade)
+	if passed {
+		return cfg.graduate(card, now)
+	}
+	card.State = state
+	card.Step = next
+	card.Interval = 0
+	card.Due = now.Add(delay)
+	return card
+}
+
+// CramStep moves a card through steps within a cram session, by the same rules
+// as the learning steps but without touching its memory state. It returns the
+// step reached, when to show the card again and whether it passed the last step.
+func CramStep(steps []time.Duration, step int, grade Rating, now time.Time) (int, time.Time, bool) {
+	next, delay, passed := advanceStep(steps, step, grade)
+	if passed {
+		return 0, now, true
+	}
+	return next, now.Add(delay), false
+}
+
+// advanceStep answers step with grade and returns the next step and its delay:
+//
+//	Again: back to the first step
+//	Hard:  repeat the current step (the average of the first two steps, or
+//	       1.5x a single step, when on the first step)
+//	Good:  advance one step, passing after the last
+//	Easy:  pass
+func advanceStep(steps []time.Duration, step int, grade Rating) (int, time.Duration, bool) {
+	if len(steps) == 0 || grade == Easy {
+		return 0, 0, true
+	}
+
+	switch grade {
+	case Again:
+		return 0, steps[0], false
+	case Hard:
+		step = min(step, len(steps)-1)
+		switch {
+		case step > 0:
+			return step, steps[step], false
+		case len(steps) == 1:
+			return 0, steps[0] * 3 / 2, false
+		default:
+			return 0, (steps[0] + steps[1]) / 2, false
+		}
+	default:
+		step++
+		if step >= len(steps) {
+			return 0, 0, true
+		}
+		return step, steps[step], false
+	}
+}
+
+// graduate schedules card as a review card at its (fuzzed) FSRS interval.
+func (cfg Config) graduate(card CardState, now time.Time) CardState {
+	return cfg.scheduleReview(card, NextInterval(cfg.DesiredRetention, card.Stability), now)
+}
+
+// scheduleReview makes card a review card due in interval days, fuzzed and
+// capped as configured.
+func (cfg Config) scheduleReview(card CardState, interval float64, now time.Time) CardState {
+	days := cfg.reviewDays(interval, card.Seed, now)
+	card.State = StateReview
+	card.Step = 0
+	card.Interval = days
+	card.Due = cfg.Day.AddDays(now, int(days))
+	return card
+}
+
+// ElapsedDays counts the calendar days (UTC) from a to b.
+func ElapsedDays(a, b time.Time) float64 {
+	return StudyDay{}.Elapsed(a, b)
+}
@@ -0,0 +1,126 @@
+package algorithm
+
+import (
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	return Config{
+		Params:           DefaultParams(),
+		DesiredRetention: 0.9,
+		MaximumInterval:  36500,
+		LearningSteps:    []time.Duration{time.Minute, 10 * time.Minute},
+		RelearningSteps:  []time.Duration{10 * time.Minute},
+	}
+}
+
+func TestNextLearningSteps(t *testing.T) {
+	cfg := testConfig()
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	card := CardState{State: StateNew}
+
+	card, _ = Next(card, Again, now, cfg)
+	if card.State != StateLearning || card.Step != 0 || !card.Due.Equal(now.Add(time.Minute)) {
+		t.Fatalf("again on new card: got %+v", card)
+	}
+
+	now = now.Add(time.Minute)
+	card, _ = Next(card, Hard, now, cfg)
+	if card.State != StateLearning || card.Step != 0 || !card.Due.Equal(now.Add(330*time.Second)) {
+		t.Fatalf("hard on first step: got %+v", card)
+	}
+
+	now = now.Add(330 * time.Second)
+	card, _ = Next(card, Good, now, cfg)
+	if card.State != StateLearning || card.Step != 1 || !card.Due.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("good on first step: got %+v", card)
+	}
+
+	now = now.Add(10 * time.Minute)
+	s := card.Stability
+	card, _ = Next(card, Good, now, cfg)
+	if card.State != StateReview || card.Interval < 1 || !card.Due.Equal(now.AddDate(0, 0, int(card.Interval))) {
+		t.Fatalf("good on last step: got %+v", card)
+	}
+	if want := SameDayStability(s, Good, cfg.Params); !almostEqual(card.Stability, want) {
+		t.Errorf("same-day stability = %v, want %v", card.Stability, want)
+	}
+	if card.Reps != 4 || card.Lapses != 0 {
+		t.Errorf("reps/lapses = %d/%d, want 4/0", card.Reps, card.Lapses)
+	}
+}
+
+func TestNextLapse(t *testing.T) {
+	cfg := testConfig()
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	card, _ := Next(CardState{State: StateNew}, Easy, now, cfg)
+	if card.State != StateReview {
+		t.Fatalf("easy on new card should graduate, got %+v", card)
+	}
+
+	now = card.Due
+	card, R := Next(card, Again, now, cfg)
+	if card.State != StateRelearning || card.Lapses != 1 || !card.Due.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("again on review card: got %+v", card)
+	}
+	if R <= 0 || R >= 1 {
+		t.Errorf("retrievability = %v, want in (0, 1)", R)
+	}
+
+	// Failing again while relearning is not another lapse.
+	now = now.Add(10 * time.Minute)
+	card, _ = Next(card, Again, now, cfg)
+	if card.State != StateRelearning || card.Lapses != 1 {
+		t.Fatalf("again while relearning: got %+v", card)
+	}
+
+	now = now.Add(10 * time.Minute)
+	card, _ = Next(card, Good, now, cfg)
+	if card.State != StateReview || card.Interval < 1 {
+		t.Fatalf("good on last relearning step: got %+v", card)
+	}
+}
+
+func TestNextWithoutSteps(t *testing.T) {
+	cfg := testConfig()
+	cfg.LearningSteps = nil
+	cfg.RelearningSteps = nil
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	card, _ := Next(CardState{State: StateNew}, Again, now, cfg)
+	if card.State != StateReview || card.Interval != 1 {
+		t.Fatalf("again without learning steps: got %+v", card)
+	}
+
+	now = card.Due
+	card, _ = Next(card, Again, now, cfg)
+	if card.State != StateReview || card.Lapses != 1 {
+		t.Fatalf("again without relearning steps: got %+v", card)
+	}
+}
+
+func TestCramStep(t *testing.T) {
+	steps := []time.Duration{time.Minute, 10 * time.Minute}
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	step, due, passed := CramStep(steps, 0, Again, now)
+	if step != 0 || passed || !due.Equal(now.Add(time.Minute)) {
+		t.Fatalf("again: got step %d due %v passed %v", step, due, passed)
+	}
+	step, due, passed = CramStep(steps, step, Good, now)
+	if step != 1 || passed || !due.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("good on first step: got step %d due %v passed %v", step, due, passed)
+	}
+	step, due, passed = CramStep(steps, step, Hard, now)
+	if step != 1 || passed || !due.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("hard on last step: got step %d due %v passed %v", step, due, passed)
+	}
+	if _, _, passed = CramStep(steps, step, Good, now); !passed {
+		t.Fatalf("good on last step should pass")
+	}
+	if _, _, passed = CramStep(steps, 0, Easy, now); !passed {
+		t.Fatalf("easy should pass")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/server"
+)
+
+var ErrUnknownCommand = errors.New("unknown command")
+
+// runCommand runs a maintenance command against the database instead of starting the server.
+func runCommand(ctx context.Context, appInstance *app.App, args []string) error {
+	switch args[0] {
+	case "optimize":
+		return runOptimize(ctx, appInstance, args[1:])
+	case "reschedule":
+		return runReschedule(ctx, appInstance, args[1:])
+	case "apply-events":
+		return runApplyEvents(ctx, appInstance)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, args[0])
+	}
+}
+
+// runOptimize fits and stores FSRS weights for each user id given.
+//
+//	voidabyss optimize <user-id>...
+func runOptimize(ctx context.Context, appInstance *app.App, userIDs []string) error {
+	if len(userIDs) == 0 {
+		return errors.New("usage: optimize <user-id>...")
+	}
+	for _, userID := range userIDs {
+		result, err := server.OptimizeUserParams(ctx, appInstance.Queries, userID, "")
+		if err != nil {
+			return fmt.Errorf("user %s: %w", userID, err)
+		}
+		log.Printf("User %s: fitted on %d reviews, log-loss %.4f -> %.4f, weights %v\n",
+			userID, result.Reviews, result.InitialLoss, result.Loss, result.Params.W)
+	}
+	return nil
+}
+
+// runApplyEvents applies the vacation and amnesia events that have started.
+//
+//	voidabyss apply-events
+func runApplyEvents(ctx context.Context, appInstance *app.App) error {
+	applied, err := server.ApplyDueUserEvents(ctx, appInstance, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	log.Printf("Applied %d user events\n", applied)
+	return nil
+}
+
+// runReschedule replays a user's review log to recompute their cards,
+// optionally only in some decks, and prints the due date changes.
+//
+//	voidabyss reschedule [-dry-run] [-batch-size n] <user-id> [deck-id...]
+func runReschedule(ctx context.Context, appInstance *app.App, args []string) error {
+	flags := flag.NewFlagSet("reschedule", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "report the changes without writing them")
+	batchSize := flags.Int("batch-size", 0, "cards replayed per transaction")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("usage: reschedule [-dry-run] [-batch-size n] <user-id> [deck-id...]")
+	}
+
+	result, err := server.RescheduleCards(ctx, appInstance, flags.Arg(0), server.RescheduleOptions{
+		DeckIDs:   flags.Args()[1:],
+		DryRun:    *dryRun,
+		BatchSize: *batchSize,
+	})
+	if err != nil {
+		return err
+	}
+	for _, change := range result.Changes {
+		log.Printf("%s: due %s -> %s, stability %.2f -> %.2f\n", change.New.ID,
+			formatDue(change.Old.DueDate.Time, change.Old.DueDate.Valid),
+			formatDue(change.New.DueDate.Time, change.New.DueDate.Valid),
+			change.Old.Stability.Float64, change.New.Stability.Float64)
+	}
+	verb := "Rescheduled"
+	if *dryRun {
+		verb = "Would reschedule"
+	}
+	log.Printf("%s %d of %d cards\n", verb, len(result.Changes), result.Cards)
+	return nil
+}
+
+// formatDue formats a card due date for command output.
+func formatDue(due time.Time, valid bool) string {
+	if !valid {
+		return "-"
+	}
+	return due.UTC().Format(time.DateTime)
+}
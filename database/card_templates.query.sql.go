@@ -17,19 +17,21 @@ INSERT INTO card_template (
   front_html,
   back_html,
   css,
-  owner_id
+  owner_id,
+  condition_field
 )
-VALUES (?, ?, ?, ?, ?, ?)
-RETURNING id, note_type_id, template_name, front_html, back_html, css, owner_id, created_at, updated_at
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING id, note_type_id, template_name, front_html, back_html, css, owner_id, created_at, updated_at, condition_field
 `
 
 type CreateCardTemplateParams struct {
-	NoteTypeID   string         `json:"note_type_id"`
-	TemplateName string         `json:"template_name"`
-	FrontHtml    string         `json:"front_html"`
-	BackHtml     string         `json:"back_html"`
-	Css          sql.NullString `json:"css"`
-	OwnerID      string         `json:"owner_id"`
+	NoteTypeID     string         `json:"note_type_id"`
+	TemplateName   string         `json:"template_name"`
+	FrontHtml      string         `json:"front_html"`
+	BackHtml       string         `json:"back_html"`
+	Css            sql.NullString `json:"css"`
+	OwnerID        string         `json:"owner_id"`
+	ConditionField sql.NullString `json:"condition_field"`
 }
 
 func (q *Queries) CreateCardTemplate(ctx context.Context, arg CreateCardTemplateParams) (CardTemplate, error) {
@@ -40,6 +42,7 @@ func (q *Queries) CreateCardTemplate(ctx context.Context, arg CreateCardTemplate
 		arg.BackHtml,
 		arg.Css,
 		arg.OwnerID,
+		arg.ConditionField,
 	)
 	var i CardTemplate
 	err := row.Scan(
@@ -52,6 +55,47 @@ func (q *Queries) CreateCardTemplate(ctx context.Context, arg CreateCardTemplate
 		&i.OwnerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ConditionField,
+	)
+	return i, err
+}
+
+const createCardTemplateVersion = `-- name: CreateCardTemplateVersion :one
+INSERT INTO card_template_version (
+  card_template_id,
+  version,
+  template_name,
+  front_html,
+  back_html,
+  css,
+  condition_field
+)
+SELECT
+  t.id,
+  (SELECT COALESCE(MAX(v.version), 0) + 1 FROM card_template_version AS v WHERE v.card_template_id = t.id),
+  t.template_name,
+  t.front_html,
+  t.back_html,
+  t.css,
+  t.condition_field
+FROM card_template AS t
+WHERE t.id = ?
+RETURNING id, card_template_id, version, template_name, front_html, back_html, css, created_at, condition_field
+`
+
+func (q *Queries) CreateCardTemplateVersion(ctx context.Context, id string) (CardTemplateVersion, error) {
+	row := q.db.QueryRowContext(ctx, createCardTemplateVersion, id)
+	var i CardTemplateVersion
+	err := row.Scan(
+		&i.ID,
+		&i.CardTemplateID,
+		&i.Version,
+		&i.TemplateName,
+		&i.FrontHtml,
+		&i.BackHtml,
+		&i.Css,
+		&i.CreatedAt,
+		&i.ConditionField,
 	)
 	return i, err
 }
@@ -67,7 +111,7 @@ func (q *Queries) DeleteCardTemplate(ctx context.Context, id string) error {
 }
 
 const getCardTemplate = `-- name: GetCardTemplate :one
-SELECT id, note_type_id, template_name, front_html, back_html, css, owner_id, created_at, updated_at FROM card_template
+SELECT id, note_type_id, template_name, front_html, back_html, css, owner_id, created_at, updated_at, condition_field FROM card_template
 WHERE id = ?
 LIMIT 1
 `
@@ -85,12 +129,42 @@ func (q *Queries) GetCardTemplate(ctx context.Context, id string) (CardTemplate,
 		&i.OwnerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ConditionField,
+	)
+	return i, err
+}
+
+const getCardTemplateVersion = `-- name: GetCardTemplateVersion :one
+SELECT id, card_template_id, version, template_name, front_html, back_html, css, created_at, condition_field FROM card_template_version
+WHERE card_template_id = ?
+AND version = ?
+LIMIT 1
+`
+
+type GetCardTemplateVersionParams struct {
+	CardTemplateID string `json:"card_template_id"`
+	Version        int64  `json:"version"`
+}
+
+func (q *Queries) GetCardTemplateVersion(ctx context.Context, arg GetCardTemplateVersionParams) (CardTemplateVersion, error) {
+	row := q.db.QueryRowContext(ctx, getCardTemplateVersion, arg.CardTemplateID, arg.Version)
+	var i CardTemplateVersion
+	err := row.Scan(
+		&i.ID,
+		&i.CardTemplateID,
+		&i.Version,
+		&i.TemplateName,
+		&i.FrontHtml,
+		&i.BackHtml,
+		&i.Css,
+		&i.CreatedAt,
+		&i.ConditionField,
 	)
 	return i, err
 }
 
 const listCardTemplatesByNoteType = `-- name: ListCardTemplatesByNoteType :many
-SELECT id, note_type_id, template_name, front_html, back_html, css, owner_id, created_at, updated_at FROM card_template
+SELECT id, note_type_id, template_name, front_html, back_html, css, owner_id, created_at, updated_at, condition_field FROM card_template
 WHERE owner_id = ?
 AND note_type_id = ?
 ORDER BY id
@@ -120,6 +194,7 @@ func (q *Queries) ListCardTemplatesByNoteType(ctx context.Context, arg ListCardT
 			&i.OwnerID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ConditionField,
 		); err != nil {
 			return nil, err
 		}
@@ -135,7 +210,7 @@ func (q *Queries) ListCardTemplatesByNoteType(ctx context.Context, arg ListCardT
 }
 
 const listCardTemplatesByOwner = `-- name: ListCardTemplatesByOwner :many
-SELECT id, note_type_id, template_name, front_html, back_html, css, owner_id, created_at, updated_at FROM card_template
+SELECT id, note_type_id, template_name, front_html, back_html, css, owner_id, created_at, updated_at, condition_field FROM card_template
 WHERE owner_id = ?
 ORDER BY id
 `
@@ -159,6 +234,46 @@ func (q *Queries) ListCardTemplatesByOwner(ctx context.Context, ownerID string)
 			&i.OwnerID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ConditionField,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCardTemplateVersions = `-- name: ListCardTemplateVersions :many
+SELECT id, card_template_id, version, template_name, front_html, back_html, css, created_at, condition_field FROM card_template_version
+WHERE card_template_id = ?
+ORDER BY version DESC
+`
+
+func (q *Queries) ListCardTemplateVersions(ctx context.Context, cardTemplateID string) ([]CardTemplateVersion, error) {
+	rows, err := q.db.QueryContext(ctx, listCardTemplateVersions, cardTemplateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CardTemplateVersion
+	for rows.Next() {
+		var i CardTemplateVersion
+		if err := rows.Scan(
+			&i.ID,
+			&i.CardTemplateID,
+			&i.Version,
+			&i.TemplateName,
+			&i.FrontHtml,
+			&i.BackHtml,
+			&i.Css,
+			&i.CreatedAt,
+			&i.ConditionField,
 		); err != nil {
 			return nil, err
 		}
@@ -180,17 +295,19 @@ SET
   front_html = ?,
   back_html = ?,
   css = ?,
+  condition_field = ?,
   updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, note_type_id, template_name, front_html, back_html, css, owner_id, created_at, updated_at
+RETURNING id, note_type_id, template_name, front_html, back_html, css, owner_id, created_at, updated_at, condition_field
 `
 
 type UpdateCardTemplateParams struct {
-	TemplateName string         `json:"template_name"`
-	FrontHtml    string         `json:"front_html"`
-	BackHtml     string         `json:"back_html"`
-	Css          sql.NullString `json:"css"`
-	ID           string         `json:"id"`
+	TemplateName   string         `json:"template_name"`
+	FrontHtml      string         `json:"front_html"`
+	BackHtml       string         `json:"back_html"`
+	Css            sql.NullString `json:"css"`
+	ConditionField sql.NullString `json:"condition_field"`
+	ID             string         `json:"id"`
 }
 
 func (q *Queries) UpdateCardTemplate(ctx context.Context, arg UpdateCardTemplateParams) (CardTemplate, error) {
@@ -199,6 +316,7 @@ func (q *Queries) UpdateCardTemplate(ctx context.Context, arg UpdateCardTemplate
 		arg.FrontHtml,
 		arg.BackHtml,
 		arg.Css,
+		arg.ConditionField,
 		arg.ID,
 	)
 	var i CardTemplate
@@ -212,6 +330,7 @@ func (q *Queries) UpdateCardTemplate(ctx context.Context, arg UpdateCardTemplate
 		&i.OwnerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ConditionField,
 	)
 	return i, err
 }
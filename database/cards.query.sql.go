@@ -11,6 +11,78 @@ import (
 	"time"
 )
 
+const buryCard = `-- name: BuryCard :one
+UPDATE card
+SET
+  buried_until = ?,
+  bury_kind = 'manual',
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, step, created_at, updated_at, ease, suspended_from, leeched_at, buried_until, bury_kind, ordinal, orphaned_at
+`
+
+type BuryCardParams struct {
+	BuriedUntil sql.NullTime `json:"buried_until"`
+	ID          string       `json:"id"`
+}
+
+func (q *Queries) BuryCard(ctx context.Context, arg BuryCardParams) (Card, error) {
+	row := q.db.QueryRowContext(ctx, buryCard, arg.BuriedUntil, arg.ID)
+	var i Card
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.CardTemplateID,
+		&i.DueDate,
+		&i.Stability,
+		&i.Difficulty,
+		&i.Interval,
+		&i.Status,
+		&i.Reps,
+		&i.Lapses,
+		&i.Step,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Ease,
+		&i.SuspendedFrom,
+		&i.LeechedAt,
+		&i.BuriedUntil,
+		&i.BuryKind,
+		&i.Ordinal,
+		&i.OrphanedAt,
+	)
+	return i, err
+}
+
+const burySiblingCards = `-- name: BurySiblingCards :exec
+UPDATE card
+SET
+  buried_until = ?,
+  bury_kind = 'sibling',
+  updated_at = CURRENT_TIMESTAMP
+WHERE note_id = ?
+  AND id <> ?
+  AND (status IS NULL OR status IN ('new', 'review'))
+  AND (buried_until IS NULL OR buried_until <= ?)
+`
+
+type BurySiblingCardsParams struct {
+	BuriedUntil sql.NullTime `json:"buried_until"`
+	NoteID      string       `json:"note_id"`
+	ID          string       `json:"id"`
+	Now         sql.NullTime `json:"now"`
+}
+
+func (q *Queries) BurySiblingCards(ctx context.Context, arg BurySiblingCardsParams) error {
+	_, err := q.db.ExecContext(ctx, burySiblingCards,
+		arg.BuriedUntil,
+		arg.NoteID,
+		arg.ID,
+		arg.Now,
+	)
+	return err
+}
+
 const cardDetailsByDeck = `-- name: CardDetailsByDeck :many
 SELECT
     n.id AS note_id,
@@ -135,6 +207,60 @@ func (q *Queries) CardDetailsByDeck(ctx context.Context, id string) ([]CardDetai
 	return items, nil
 }
 
+const countDueCardsByDay = `-- name: CountDueCardsByDay :many
+SELECT CAST(DATE(c.due_date, ?) AS TEXT) AS due_day,
+       COUNT(*) AS due_count
+FROM card AS c
+JOIN note AS n ON c.note_id = n.id
+JOIN deck AS d ON n.deck_id = d.id
+WHERE d.owner_id = ?
+  AND c.status = 'review'
+  AND c.due_date >= ?
+  AND c.due_date < ?
+GROUP BY due_day
+ORDER BY due_day
+`
+
+type CountDueCardsByDayParams struct {
+	DayShift  interface{}  `json:"day_shift"`
+	OwnerID   string       `json:"owner_id"`
+	DueDate   sql.NullTime `json:"due_date"`
+	DueDate_2 sql.NullTime `json:"due_date_2"`
+}
+
+type CountDueCardsByDayRow struct {
+	DueDay   string `json:"due_day"`
+	DueCount int64  `json:"due_count"`
+}
+
+func (q *Queries) CountDueCardsByDay(ctx context.Context, arg CountDueCardsByDayParams) ([]CountDueCardsByDayRow, error) {
+	rows, err := q.db.QueryContext(ctx, countDueCardsByDay,
+		arg.DayShift,
+		arg.OwnerID,
+		arg.DueDate,
+		arg.DueDate_2,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountDueCardsByDayRow
+	for rows.Next() {
+		var i CountDueCardsByDayRow
+		if err := rows.Scan(&i.DueDay, &i.DueCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createCard = `-- name: CreateCard :one
 INSERT INTO card (
   note_id,
@@ -143,10 +269,11 @@ INSERT INTO card (
   stability,
   difficulty,
   interval,
-  status
+  status,
+  ordinal
 )
-VALUES (?, ?, ?, ?, ?, ?, ?)
-RETURNING id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, created_at, updated_at
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, step, created_at, updated_at, ease, suspended_from, leeched_at, buried_until, bury_kind, ordinal, orphaned_at
 `
 
 type CreateCardParams struct {
@@ -157,6 +284,7 @@ type CreateCardParams struct {
 	Difficulty     sql.NullFloat64 `json:"difficulty"`
 	Interval       sql.NullInt64   `json:"interval"`
 	Status         sql.NullString  `json:"status"`
+	Ordinal        int64           `json:"ordinal"`
 }
 
 func (q *Queries) CreateCard(ctx context.Context, arg CreateCardParams) (Card, error) {
@@ -168,6 +296,7 @@ func (q *Queries) CreateCard(ctx context.Context, arg CreateCardParams) (Card, e
 		arg.Difficulty,
 		arg.Interval,
 		arg.Status,
+		arg.Ordinal,
 	)
 	var i Card
 	err := row.Scan(
@@ -181,8 +310,16 @@ func (q *Queries) CreateCard(ctx context.Context, arg CreateCardParams) (Card, e
 		&i.Status,
 		&i.Reps,
 		&i.Lapses,
+		&i.Step,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Ease,
+		&i.SuspendedFrom,
+		&i.LeechedAt,
+		&i.BuriedUntil,
+		&i.BuryKind,
+		&i.Ordinal,
+		&i.OrphanedAt,
 	)
 	return i, err
 }
@@ -197,8 +334,18 @@ func (q *Queries) DeleteCard(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteCardsByTemplate = `-- name: DeleteCardsByTemplate :exec
+DELETE FROM card
+WHERE card_template_id = ?
+`
+
+func (q *Queries) DeleteCardsByTemplate(ctx context.Context, cardTemplateID string) error {
+	_, err := q.db.ExecContext(ctx, deleteCardsByTemplate, cardTemplateID)
+	return err
+}
+
 const getCard = `-- name: GetCard :one
-SELECT id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, created_at, updated_at FROM card
+SELECT id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, step, created_at, updated_at, ease, suspended_from, leeched_at, buried_until, bury_kind, ordinal, orphaned_at FROM card
 WHERE id = ?
 LIMIT 1
 `
@@ -217,8 +364,16 @@ func (q *Queries) GetCard(ctx context.Context, id string) (Card, error) {
 		&i.Status,
 		&i.Reps,
 		&i.Lapses,
+		&i.Step,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Ease,
+		&i.SuspendedFrom,
+		&i.LeechedAt,
+		&i.BuriedUntil,
+		&i.BuryKind,
+		&i.Ordinal,
+		&i.OrphanedAt,
 	)
 	return i, err
 }
@@ -234,8 +389,16 @@ SELECT c.id,
        c.status,
        c.reps,
        c.lapses,
+       c.step,
        c.created_at,
-       c.updated_at
+       c.updated_at,
+       c.ease,
+       c.suspended_from,
+       c.leeched_at,
+       c.buried_until,
+       c.bury_kind,
+       c.ordinal,
+       c.orphaned_at
 FROM card AS c
 JOIN note AS n ON c.note_id = n.id
 WHERE n.deck_id = ?
@@ -261,8 +424,16 @@ func (q *Queries) ListCardsByDeck(ctx context.Context, deckID string) ([]Card, e
 			&i.Status,
 			&i.Reps,
 			&i.Lapses,
+			&i.Step,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Ease,
+			&i.SuspendedFrom,
+			&i.LeechedAt,
+			&i.BuriedUntil,
+			&i.BuryKind,
+			&i.Ordinal,
+			&i.OrphanedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -278,7 +449,7 @@ func (q *Queries) ListCardsByDeck(ctx context.Context, deckID string) ([]Card, e
 }
 
 const listCardsByNote = `-- name: ListCardsByNote :many
-SELECT id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, created_at, updated_at FROM card
+SELECT id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, step, created_at, updated_at, ease, suspended_from, leeched_at, buried_until, bury_kind, ordinal, orphaned_at FROM card
 WHERE note_id = ?
 ORDER BY id
 `
@@ -303,8 +474,16 @@ func (q *Queries) ListCardsByNote(ctx context.Context, noteID string) ([]Card, e
 			&i.Status,
 			&i.Reps,
 			&i.Lapses,
+			&i.Step,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Ease,
+			&i.SuspendedFrom,
+			&i.LeechedAt,
+			&i.BuriedUntil,
+			&i.BuryKind,
+			&i.Ordinal,
+			&i.OrphanedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -319,7 +498,378 @@ func (q *Queries) ListCardsByNote(ctx context.Context, noteID string) ([]Card, e
 	return items, nil
 }
 
-const updateCardScheduling = `-- name: UpdateCardScheduling :one
+const listCardsByTag = `-- name: ListCardsByTag :many
+SELECT c.id,
+       c.note_id,
+       c.card_template_id,
+       c.due_date,
+       c.stability,
+       c.difficulty,
+       c.interval,
+       c.status,
+       c.reps,
+       c.lapses,
+       c.step,
+       c.created_at,
+       c.updated_at,
+       c.ease,
+       c.suspended_from,
+       c.leeched_at,
+       c.buried_until,
+       c.bury_kind,
+       c.ordinal,
+       c.orphaned_at
+FROM card AS c
+JOIN note AS n ON c.note_id = n.id
+JOIN note_tag AS nt ON nt.note_id = n.id
+JOIN tag AS t ON nt.tag_id = t.id
+WHERE t.owner_id = ?
+  AND t.name = ?
+ORDER BY n.deck_id, c.created_at
+`
+
+type ListCardsByTagParams struct {
+	OwnerID string `json:"owner_id"`
+	Name    string `json:"name"`
+}
+
+func (q *Queries) ListCardsByTag(ctx context.Context, arg ListCardsByTagParams) ([]Card, error) {
+	rows, err := q.db.QueryContext(ctx, listCardsByTag, arg.OwnerID, arg.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Card
+	for rows.Next() {
+		var i Card
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteID,
+			&i.CardTemplateID,
+			&i.DueDate,
+			&i.Stability,
+			&i.Difficulty,
+			&i.Interval,
+			&i.Status,
+			&i.Reps,
+			&i.Lapses,
+			&i.Step,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Ease,
+			&i.SuspendedFrom,
+			&i.LeechedAt,
+			&i.BuriedUntil,
+			&i.BuryKind,
+			&i.Ordinal,
+			&i.OrphanedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLeechCardsByOwner = `-- name: ListLeechCardsByOwner :many
+SELECT c.id,
+       c.note_id,
+       c.status,
+       c.suspended_from,
+       c.lapses,
+       c.leeched_at,
+       n.deck_id,
+       d.name AS deck_name
+FROM card AS c
+JOIN note AS n ON c.note_id = n.id
+JOIN deck AS d ON n.deck_id = d.id
+WHERE n.owner_id = ?
+  AND c.leeched_at IS NOT NULL
+ORDER BY c.leeched_at DESC, c.id
+`
+
+type ListLeechCardsByOwnerRow struct {
+	ID            string         `json:"id"`
+	NoteID        string         `json:"note_id"`
+	Status        sql.NullString `json:"status"`
+	SuspendedFrom sql.NullString `json:"suspended_from"`
+	Lapses        sql.NullInt64  `json:"lapses"`
+	LeechedAt     sql.NullTime   `json:"leeched_at"`
+	DeckID        string         `json:"deck_id"`
+	DeckName      string         `json:"deck_name"`
+}
+
+func (q *Queries) ListLeechCardsByOwner(ctx context.Context, ownerID string) ([]ListLeechCardsByOwnerRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLeechCardsByOwner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLeechCardsByOwnerRow
+	for rows.Next() {
+		var i ListLeechCardsByOwnerRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteID,
+			&i.Status,
+			&i.SuspendedFrom,
+			&i.Lapses,
+			&i.LeechedAt,
+			&i.DeckID,
+			&i.DeckName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReviewCardsDueBefore = `-- name: ListReviewCardsDueBefore :many
+SELECT c.id,
+       c.note_id,
+       c.card_template_id,
+       c.due_date,
+       c.stability,
+       c.difficulty,
+       c.interval,
+       c.status,
+       c.reps,
+       c.lapses,
+       c.step,
+       c.created_at,
+       c.updated_at,
+       c.ease,
+       c.suspended_from,
+       c.leeched_at,
+       c.buried_until,
+       c.bury_kind,
+       c.ordinal,
+       c.orphaned_at
+FROM card AS c
+JOIN note AS n ON c.note_id = n.id
+JOIN deck AS d ON n.deck_id = d.id
+WHERE d.owner_id = ?
+  AND c.status = 'review'
+  AND c.due_date < ?
+ORDER BY c.due_date, c.id
+`
+
+type ListReviewCardsDueBeforeParams struct {
+	OwnerID string       `json:"owner_id"`
+	DueDate sql.NullTime `json:"due_date"`
+}
+
+func (q *Queries) ListReviewCardsDueBefore(ctx context.Context, arg ListReviewCardsDueBeforeParams) ([]Card, error) {
+	rows, err := q.db.QueryContext(ctx, listReviewCardsDueBefore, arg.OwnerID, arg.DueDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Card
+	for rows.Next() {
+		var i Card
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteID,
+			&i.CardTemplateID,
+			&i.DueDate,
+			&i.Stability,
+			&i.Difficulty,
+			&i.Interval,
+			&i.Status,
+			&i.Reps,
+			&i.Lapses,
+			&i.Step,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Ease,
+			&i.SuspendedFrom,
+			&i.LeechedAt,
+			&i.BuriedUntil,
+			&i.BuryKind,
+			&i.Ordinal,
+			&i.OrphanedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStudyCardsByOwner = `-- name: ListStudyCardsByOwner :many
+SELECT c.id,
+       c.note_id,
+       c.card_template_id,
+       c.due_date,
+       c.stability,
+       c.difficulty,
+       c.interval,
+       c.status,
+       c.reps,
+       c.lapses,
+       c.step,
+       c.created_at,
+       c.updated_at,
+       n.deck_id,
+       d.name AS deck_name
+FROM card AS c
+JOIN note AS n ON c.note_id = n.id
+JOIN deck AS d ON n.deck_id = d.id
+WHERE d.owner_id = ?
+  AND c.status IS NOT 'suspended'
+  AND (c.buried_until IS NULL OR c.buried_until <= ?)
+  AND (c.status IS NULL OR c.status = 'new' OR c.due_date < ?)
+ORDER BY c.due_date, c.created_at
+`
+
+type ListStudyCardsByOwnerParams struct {
+	OwnerID string       `json:"owner_id"`
+	Now     sql.NullTime `json:"now"`
+	DueDate sql.NullTime `json:"due_date"`
+}
+
+type ListStudyCardsByOwnerRow struct {
+	ID             string          `json:"id"`
+	NoteID         string          `json:"note_id"`
+	CardTemplateID string          `json:"card_template_id"`
+	DueDate        sql.NullTime    `json:"due_date"`
+	Stability      sql.NullFloat64 `json:"stability"`
+	Difficulty     sql.NullFloat64 `json:"difficulty"`
+	Interval       sql.NullInt64   `json:"interval"`
+	Status         sql.NullString  `json:"status"`
+	Reps           sql.NullInt64   `json:"reps"`
+	Lapses         sql.NullInt64   `json:"lapses"`
+	Step           int64           `json:"step"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeckID         string          `json:"deck_id"`
+	DeckName       string          `json:"deck_name"`
+}
+
+func (q *Queries) ListStudyCardsByOwner(ctx context.Context, arg ListStudyCardsByOwnerParams) ([]ListStudyCardsByOwnerRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStudyCardsByOwner, arg.OwnerID, arg.Now, arg.DueDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStudyCardsByOwnerRow
+	for rows.Next() {
+		var i ListStudyCardsByOwnerRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteID,
+			&i.CardTemplateID,
+			&i.DueDate,
+			&i.Stability,
+			&i.Difficulty,
+			&i.Interval,
+			&i.Status,
+			&i.Reps,
+			&i.Lapses,
+			&i.Step,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeckID,
+			&i.DeckName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markCardLeech = `-- name: MarkCardLeech :one
+UPDATE card
+SET
+  leeched_at = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, step, created_at, updated_at, ease, suspended_from, leeched_at, buried_until, bury_kind, ordinal, orphaned_at
+`
+
+type MarkCardLeechParams struct {
+	LeechedAt sql.NullTime `json:"leeched_at"`
+	ID        string       `json:"id"`
+}
+
+func (q *Queries) MarkCardLeech(ctx context.Context, arg MarkCardLeechParams) (Card, error) {
+	row := q.db.QueryRowContext(ctx, markCardLeech, arg.LeechedAt, arg.ID)
+	var i Card
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.CardTemplateID,
+		&i.DueDate,
+		&i.Stability,
+		&i.Difficulty,
+		&i.Interval,
+		&i.Status,
+		&i.Reps,
+		&i.Lapses,
+		&i.Step,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Ease,
+		&i.SuspendedFrom,
+		&i.LeechedAt,
+		&i.BuriedUntil,
+		&i.BuryKind,
+		&i.Ordinal,
+		&i.OrphanedAt,
+	)
+	return i, err
+}
+
+const orphanCard = `-- name: OrphanCard :exec
+UPDATE card
+SET
+  orphaned_at = ?,
+  suspended_from = COALESCE(status, 'new'),
+  status = 'suspended',
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND orphaned_at IS NULL
+  AND status IS NOT 'suspended'
+`
+
+type OrphanCardParams struct {
+	OrphanedAt sql.NullTime `json:"orphaned_at"`
+	ID         string       `json:"id"`
+}
+
+func (q *Queries) OrphanCard(ctx context.Context, arg OrphanCardParams) error {
+	_, err := q.db.ExecContext(ctx, orphanCard, arg.OrphanedAt, arg.ID)
+	return err
+}
+
+const restoreCardState = `-- name: RestoreCardState :one
 UPDATE card
 SET
   due_date = ?,
@@ -327,11 +877,265 @@ SET
   difficulty = ?,
   interval = ?,
   status = ?,
+  suspended_from = NULL,
+  reps = ?,
+  lapses = ?,
+  step = ?,
+  ease = ?,
+  leeched_at = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, step, created_at, updated_at, ease, suspended_from, leeched_at, buried_until, bury_kind, ordinal, orphaned_at
+`
+
+type RestoreCardStateParams struct {
+	DueDate    sql.NullTime    `json:"due_date"`
+	Stability  sql.NullFloat64 `json:"stability"`
+	Difficulty sql.NullFloat64 `json:"difficulty"`
+	Interval   sql.NullInt64   `json:"interval"`
+	Status     sql.NullString  `json:"status"`
+	Reps       sql.NullInt64   `json:"reps"`
+	Lapses     sql.NullInt64   `json:"lapses"`
+	Step       int64           `json:"step"`
+	Ease       sql.NullFloat64 `json:"ease"`
+	LeechedAt  sql.NullTime    `json:"leeched_at"`
+	ID         string          `json:"id"`
+}
+
+func (q *Queries) RestoreCardState(ctx context.Context, arg RestoreCardStateParams) (Card, error) {
+	row := q.db.QueryRowContext(ctx, restoreCardState,
+		arg.DueDate,
+		arg.Stability,
+		arg.Difficulty,
+		arg.Interval,
+		arg.Status,
+		arg.Reps,
+		arg.Lapses,
+		arg.Step,
+		arg.Ease,
+		arg.LeechedAt,
+		arg.ID,
+	)
+	var i Card
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.CardTemplateID,
+		&i.DueDate,
+		&i.Stability,
+		&i.Difficulty,
+		&i.Interval,
+		&i.Status,
+		&i.Reps,
+		&i.Lapses,
+		&i.Step,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Ease,
+		&i.SuspendedFrom,
+		&i.LeechedAt,
+		&i.BuriedUntil,
+		&i.BuryKind,
+		&i.Ordinal,
+		&i.OrphanedAt,
+	)
+	return i, err
+}
+
+const restoreOrphanCard = `-- name: RestoreOrphanCard :exec
+UPDATE card
+SET
+  status = CASE WHEN status = 'suspended' THEN suspended_from ELSE status END,
+  suspended_from = CASE WHEN status = 'suspended' THEN NULL ELSE suspended_from END,
+  orphaned_at = NULL,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND orphaned_at IS NOT NULL
+`
+
+func (q *Queries) RestoreOrphanCard(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, restoreOrphanCard, id)
+	return err
+}
+
+const suspendCard = `-- name: SuspendCard :one
+UPDATE card
+SET
+  suspended_from = COALESCE(status, 'new'),
+  status = 'suspended',
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND status IS NOT 'suspended'
+RETURNING id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, step, created_at, updated_at, ease, suspended_from, leeched_at, buried_until, bury_kind, ordinal, orphaned_at
+`
+
+func (q *Queries) SuspendCard(ctx context.Context, id string) (Card, error) {
+	row := q.db.QueryRowContext(ctx, suspendCard, id)
+	var i Card
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.CardTemplateID,
+		&i.DueDate,
+		&i.Stability,
+		&i.Difficulty,
+		&i.Interval,
+		&i.Status,
+		&i.Reps,
+		&i.Lapses,
+		&i.Step,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Ease,
+		&i.SuspendedFrom,
+		&i.LeechedAt,
+		&i.BuriedUntil,
+		&i.BuryKind,
+		&i.Ordinal,
+		&i.OrphanedAt,
+	)
+	return i, err
+}
+
+const unburyCard = `-- name: UnburyCard :one
+UPDATE card
+SET
+  buried_until = NULL,
+  bury_kind = NULL,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, step, created_at, updated_at, ease, suspended_from, leeched_at, buried_until, bury_kind, ordinal, orphaned_at
+`
+
+func (q *Queries) UnburyCard(ctx context.Context, id string) (Card, error) {
+	row := q.db.QueryRowContext(ctx, unburyCard, id)
+	var i Card
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.CardTemplateID,
+		&i.DueDate,
+		&i.Stability,
+		&i.Difficulty,
+		&i.Interval,
+		&i.Status,
+		&i.Reps,
+		&i.Lapses,
+		&i.Step,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Ease,
+		&i.SuspendedFrom,
+		&i.LeechedAt,
+		&i.BuriedUntil,
+		&i.BuryKind,
+		&i.Ordinal,
+		&i.OrphanedAt,
+	)
+	return i, err
+}
+
+const unburySiblingCards = `-- name: UnburySiblingCards :exec
+UPDATE card
+SET
+  buried_until = NULL,
+  bury_kind = NULL,
+  updated_at = CURRENT_TIMESTAMP
+WHERE note_id = ?
+  AND bury_kind = 'sibling'
+  AND NOT EXISTS (
+    SELECT 1
+    FROM review AS r
+    JOIN card AS c ON r.card_id = c.id
+    WHERE c.note_id = ?
+      AND r.scheduled = 1
+      AND r.review_time >= ?
+  )
+`
+
+type UnburySiblingCardsParams struct {
+	NoteID string    `json:"note_id"`
+	Since  time.Time `json:"since"`
+}
+
+func (q *Queries) UnburySiblingCards(ctx context.Context, arg UnburySiblingCardsParams) error {
+	_, err := q.db.ExecContext(ctx, unburySiblingCards, arg.NoteID, arg.NoteID, arg.Since)
+	return err
+}
+
+const unsuspendCard = `-- name: UnsuspendCard :one
+UPDATE card
+SET
+  status = suspended_from,
+  suspended_from = NULL,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND status = 'suspended'
+RETURNING id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, step, created_at, updated_at, ease, suspended_from, leeched_at, buried_until, bury_kind, ordinal, orphaned_at
+`
+
+func (q *Queries) UnsuspendCard(ctx context.Context, id string) (Card, error) {
+	row := q.db.QueryRowContext(ctx, unsuspendCard, id)
+	var i Card
+	err := row.Scan(
+		&i.ID,
+		&i.NoteID,
+		&i.CardTemplateID,
+		&i.DueDate,
+		&i.Stability,
+		&i.Difficulty,
+		&i.Interval,
+		&i.Status,
+		&i.Reps,
+		&i.Lapses,
+		&i.Step,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Ease,
+		&i.SuspendedFrom,
+		&i.LeechedAt,
+		&i.BuriedUntil,
+		&i.BuryKind,
+		&i.Ordinal,
+		&i.OrphanedAt,
+	)
+	return i, err
+}
+
+const updateCardOrdinal = `-- name: UpdateCardOrdinal :exec
+UPDATE card
+SET
+  ordinal = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type UpdateCardOrdinalParams struct {
+	Ordinal int64  `json:"ordinal"`
+	ID      string `json:"id"`
+}
+
+func (q *Queries) UpdateCardOrdinal(ctx context.Context, arg UpdateCardOrdinalParams) error {
+	_, err := q.db.ExecContext(ctx, updateCardOrdinal, arg.Ordinal, arg.ID)
+	return err
+}
+
+const updateCardScheduling = `-- name: UpdateCardScheduling :one
+UPDATE card
+SET
+  due_date = ?,
+  stability = ?,
+  difficulty = ?,
+  interval = ?,
+  status = CASE WHEN status = 'suspended' THEN status ELSE ? END,
+  suspended_from = CASE WHEN status = 'suspended' THEN ? ELSE suspended_from END,
   reps = ?,
   lapses = ?,
+  step = ?,
+  ease = ?,
   updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, created_at, updated_at
+RETURNING id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, step, created_at, updated_at, ease, suspended_from, leeched_at, buried_until, bury_kind, ordinal, orphaned_at
 `
 
 type UpdateCardSchedulingParams struct {
@@ -342,6 +1146,8 @@ type UpdateCardSchedulingParams struct {
 	Status     sql.NullString  `json:"status"`
 	Reps       sql.NullInt64   `json:"reps"`
 	Lapses     sql.NullInt64   `json:"lapses"`
+	Step       int64           `json:"step"`
+	Ease       sql.NullFloat64 `json:"ease"`
 	ID         string          `json:"id"`
 }
 
@@ -352,8 +1158,11 @@ func (q *Queries) UpdateCardScheduling(ctx context.Context, arg UpdateCardSchedu
 		arg.Difficulty,
 		arg.Interval,
 		arg.Status,
+		arg.Status,
 		arg.Reps,
 		arg.Lapses,
+		arg.Step,
+		arg.Ease,
 		arg.ID,
 	)
 	var i Card
@@ -368,8 +1177,16 @@ func (q *Queries) UpdateCardScheduling(ctx context.Context, arg UpdateCardSchedu
 		&i.Status,
 		&i.Reps,
 		&i.Lapses,
+		&i.Step,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Ease,
+		&i.SuspendedFrom,
+		&i.LeechedAt,
+		&i.BuriedUntil,
+		&i.BuryKind,
+		&i.Ordinal,
+		&i.OrphanedAt,
 	)
 	return i, err
 }
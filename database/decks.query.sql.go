@@ -47,7 +47,7 @@ INSERT INTO deck (
   description
 )
 VALUES (?, ?, ?)
-RETURNING id, name, owner_id, description, created_at, updated_at, card_count
+RETURNING id, name, owner_id, description, created_at, updated_at, card_count, preset_id
 `
 
 type CreateDeckParams struct {
@@ -67,6 +67,7 @@ func (q *Queries) CreateDeck(ctx context.Context, arg CreateDeckParams) (Deck, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CardCount,
+		&i.PresetID,
 	)
 	return i, err
 }
@@ -82,7 +83,7 @@ func (q *Queries) DeleteDeck(ctx context.Context, id string) error {
 }
 
 const getDeck = `-- name: GetDeck :one
-SELECT id, name, owner_id, description, created_at, updated_at, card_count FROM deck
+SELECT id, name, owner_id, description, created_at, updated_at, card_count, preset_id FROM deck
 WHERE id = ?
 LIMIT 1
 `
@@ -98,12 +99,13 @@ func (q *Queries) GetDeck(ctx context.Context, id string) (Deck, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CardCount,
+		&i.PresetID,
 	)
 	return i, err
 }
 
 const getDeckById = `-- name: GetDeckById :one
-SELECT id, name, owner_id, description, created_at, updated_at, card_count FROM deck
+SELECT id, name, owner_id, description, created_at, updated_at, card_count, preset_id FROM deck
 WHERE id = ?
 LIMIT 1
 `
@@ -119,6 +121,36 @@ func (q *Queries) GetDeckById(ctx context.Context, id string) (Deck, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CardCount,
+		&i.PresetID,
+	)
+	return i, err
+}
+
+const getDeckByOwnerAndName = `-- name: GetDeckByOwnerAndName :one
+SELECT id, name, owner_id, description, created_at, updated_at, card_count, preset_id FROM deck
+WHERE owner_id = ?
+  AND name = ?
+ORDER BY created_at
+LIMIT 1
+`
+
+type GetDeckByOwnerAndNameParams struct {
+	OwnerID string `json:"owner_id"`
+	Name    string `json:"name"`
+}
+
+func (q *Queries) GetDeckByOwnerAndName(ctx context.Context, arg GetDeckByOwnerAndNameParams) (Deck, error) {
+	row := q.db.QueryRowContext(ctx, getDeckByOwnerAndName, arg.OwnerID, arg.Name)
+	var i Deck
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.OwnerID,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CardCount,
+		&i.PresetID,
 	)
 	return i, err
 }
@@ -159,7 +191,7 @@ func (q *Queries) ListDeckCollaborators(ctx context.Context, deckID string) ([]D
 }
 
 const listDecksByOwnerId = `-- name: ListDecksByOwnerId :many
-SELECT id, name, owner_id, description, created_at, updated_at, card_count FROM deck
+SELECT id, name, owner_id, description, created_at, updated_at, card_count, preset_id FROM deck
 WHERE owner_id = ?
 ORDER BY id
 `
@@ -181,6 +213,7 @@ func (q *Queries) ListDecksByOwnerId(ctx context.Context, ownerID string) ([]Dec
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.CardCount,
+			&i.PresetID,
 		); err != nil {
 			return nil, err
 		}
@@ -196,7 +229,7 @@ func (q *Queries) ListDecksByOwnerId(ctx context.Context, ownerID string) ([]Dec
 }
 
 const listSharedDecks = `-- name: ListSharedDecks :many
-SELECT deck.id, deck.name, deck.owner_id, deck.description, deck.created_at, deck.updated_at, deck.card_count
+SELECT deck.id, deck.name, deck.owner_id, deck.description, deck.created_at, deck.updated_at, deck.card_count, deck.preset_id
 FROM deck
 JOIN deck_collaborator ON deck.id = deck_collaborator.deck_id
 WHERE deck_collaborator.user_id = ?
@@ -220,6 +253,7 @@ func (q *Queries) ListSharedDecks(ctx context.Context, userID string) ([]Deck, e
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.CardCount,
+			&i.PresetID,
 		); err != nil {
 			return nil, err
 		}
@@ -244,6 +278,36 @@ func (q *Queries) RemoveDeckCollaborator(ctx context.Context, id string) error {
 	return err
 }
 
+const setDeckPreset = `-- name: SetDeckPreset :one
+UPDATE deck
+SET
+  preset_id = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, name, owner_id, description, created_at, updated_at, card_count, preset_id
+`
+
+type SetDeckPresetParams struct {
+	PresetID sql.NullString `json:"preset_id"`
+	ID       string         `json:"id"`
+}
+
+func (q *Queries) SetDeckPreset(ctx context.Context, arg SetDeckPresetParams) (Deck, error) {
+	row := q.db.QueryRowContext(ctx, setDeckPreset, arg.PresetID, arg.ID)
+	var i Deck
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.OwnerID,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CardCount,
+		&i.PresetID,
+	)
+	return i, err
+}
+
 const updateDeck = `-- name: UpdateDeck :one
 UPDATE deck
 SET
@@ -251,7 +315,7 @@ SET
   description = ?,
   updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, name, owner_id, description, created_at, updated_at, card_count
+RETURNING id, name, owner_id, description, created_at, updated_at, card_count, preset_id
 `
 
 type UpdateDeckParams struct {
@@ -271,6 +335,7 @@ func (q *Queries) UpdateDeck(ctx context.Context, arg UpdateDeckParams) (Deck, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CardCount,
+		&i.PresetID,
 	)
 	return i, err
 }
@@ -128,36 +128,70 @@ func ApplyMigrations(ctx context.Context, db *sql.DB) error {
 
 		log.Printf("Applying migration %d: %s", m.Count, m.Description)
 
-		// Begin transaction
-		tx, err := db.BeginTx(ctx, &sql.TxOptions{})
-		if err != nil {
-			return fmt.Errorf("failed to begin transaction: %w", err)
+		if err := applyMigration(ctx, db, m); err != nil {
+			return err
 		}
 
-		// Execute migration SQL
-		_, err = tx.ExecContext(ctx, m.SQL)
-		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to execute migration %d (%s): %w", m.Count, m.Filename, err)
-		}
+		log.Printf("Successfully applied migration %d: %s", m.Count, m.Description)
+	}
+
+	return nil
+}
+
+// applyMigration runs a single migration in a transaction on a dedicated
+// connection with foreign key enforcement switched off, so that migrations can
+// rebuild tables (the only way to change a constraint in SQLite) without the
+// DROP TABLE cascading into referencing tables. Foreign keys are checked
+// before committing.
+func applyMigration(ctx context.Context, db *sql.DB, m Migration) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	// foreign_keys is a no-op inside a transaction, so it is set first.
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "PRAGMA foreign_keys = ON")
+
+	// Begin transaction
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-		// Insert migration record
-		_, err = tx.ExecContext(ctx, `
+	// Execute migration SQL
+	_, err = tx.ExecContext(ctx, m.SQL)
+	if err != nil {
+		return fmt.Errorf("failed to execute migration %d (%s): %w", m.Count, m.Filename, err)
+	}
+
+	// Make sure the migration left no dangling references
+	rows, err := tx.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return fmt.Errorf("failed to check foreign keys for migration %d: %w", m.Count, err)
+	}
+	violations := rows.Next()
+	rows.Close()
+	if violations {
+		return fmt.Errorf("migration %d (%s) violates foreign key constraints", m.Count, m.Filename)
+	}
+
+	// Insert migration record
+	_, err = tx.ExecContext(ctx, `
             INSERT INTO migrations (count, description)
             VALUES (?, ?)
         `, m.Count, m.Description)
-		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to record migration %d: %w", m.Count, err)
-		}
-
-		// Commit transaction
-		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("failed to commit migration %d: %w", m.Count, err)
-		}
-
-		log.Printf("Successfully applied migration %d: %s", m.Count, m.Description)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.Count, err)
 	}
 
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", m.Count, err)
+	}
 	return nil
 }
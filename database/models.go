@@ -29,20 +29,41 @@ type Card struct {
 	Status         sql.NullString  `json:"status"`
 	Reps           sql.NullInt64   `json:"reps"`
 	Lapses         sql.NullInt64   `json:"lapses"`
+	Step           int64           `json:"step"`
 	CreatedAt      time.Time       `json:"created_at"`
 	UpdatedAt      time.Time       `json:"updated_at"`
+	Ease           sql.NullFloat64 `json:"ease"`
+	SuspendedFrom  sql.NullString  `json:"suspended_from"`
+	LeechedAt      sql.NullTime    `json:"leeched_at"`
+	BuriedUntil    sql.NullTime    `json:"buried_until"`
+	BuryKind       sql.NullString  `json:"bury_kind"`
+	Ordinal        int64           `json:"ordinal"`
+	OrphanedAt     sql.NullTime    `json:"orphaned_at"`
 }
 
 type CardTemplate struct {
-	ID           string         `json:"id"`
-	NoteTypeID   string         `json:"note_type_id"`
-	TemplateName string         `json:"template_name"`
-	FrontHtml    string         `json:"front_html"`
-	BackHtml     string         `json:"back_html"`
-	Css          sql.NullString `json:"css"`
-	OwnerID      string         `json:"owner_id"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
+	ID             string         `json:"id"`
+	NoteTypeID     string         `json:"note_type_id"`
+	TemplateName   string         `json:"template_name"`
+	FrontHtml      string         `json:"front_html"`
+	BackHtml       string         `json:"back_html"`
+	Css            sql.NullString `json:"css"`
+	OwnerID        string         `json:"owner_id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	ConditionField sql.NullString `json:"condition_field"`
+}
+
+type CardTemplateVersion struct {
+	ID             string         `json:"id"`
+	CardTemplateID string         `json:"card_template_id"`
+	Version        int64          `json:"version"`
+	TemplateName   string         `json:"template_name"`
+	FrontHtml      string         `json:"front_html"`
+	BackHtml       string         `json:"back_html"`
+	Css            sql.NullString `json:"css"`
+	CreatedAt      time.Time      `json:"created_at"`
+	ConditionField sql.NullString `json:"condition_field"`
 }
 
 type Deck struct {
@@ -53,6 +74,7 @@ type Deck struct {
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	CardCount   int64          `json:"card_count"`
+	PresetID    sql.NullString `json:"preset_id"`
 }
 
 type DeckCollaborator struct {
@@ -64,6 +86,30 @@ type DeckCollaborator struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+type DeckPreset struct {
+	ID                 string          `json:"id"`
+	OwnerID            string          `json:"owner_id"`
+	Name               string          `json:"name"`
+	FsrsWeights        sql.NullString  `json:"fsrs_weights"`
+	DesiredRetention   sql.NullFloat64 `json:"desired_retention"`
+	MaximumInterval    sql.NullInt64   `json:"maximum_interval"`
+	NewCardsPerDay     sql.NullInt64   `json:"new_cards_per_day"`
+	ReviewsPerDay      sql.NullInt64   `json:"reviews_per_day"`
+	LearningSteps      sql.NullString  `json:"learning_steps"`
+	RelearningSteps    sql.NullString  `json:"relearning_steps"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+	Scheduler          sql.NullString  `json:"scheduler"`
+	StartingEase       sql.NullFloat64 `json:"starting_ease"`
+	EasyBonus          sql.NullFloat64 `json:"easy_bonus"`
+	IntervalModifier   sql.NullFloat64 `json:"interval_modifier"`
+	GraduatingInterval sql.NullInt64   `json:"graduating_interval"`
+	EasyInterval       sql.NullInt64   `json:"easy_interval"`
+	LeechThreshold     sql.NullInt64   `json:"leech_threshold"`
+	LeechAction        sql.NullString  `json:"leech_action"`
+	BurySiblings       sql.NullBool    `json:"bury_siblings"`
+}
+
 type Note struct {
 	ID         string    `json:"id"`
 	DeckID     string    `json:"deck_id"`
@@ -82,6 +128,13 @@ type NoteField struct {
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+type NoteTag struct {
+	ID        string    `json:"id"`
+	NoteID    string    `json:"note_id"`
+	TagID     string    `json:"tag_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type NoteType struct {
 	ID          string         `json:"id"`
 	Name        string         `json:"name"`
@@ -91,24 +144,52 @@ type NoteType struct {
 	UpdatedAt   time.Time      `json:"updated_at"`
 }
 
+type NoteTypeField struct {
+	ID         string    `json:"id"`
+	NoteTypeID string    `json:"note_type_id"`
+	Name       string    `json:"name"`
+	Position   int64     `json:"position"`
+	Kind       string    `json:"kind"`
+	Required   bool      `json:"required"`
+	SortField  bool      `json:"sort_field"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
 type Rating struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
 }
 
 type Review struct {
-	ID            string          `json:"id"`
-	CardID        string          `json:"card_id"`
-	ReviewTime    time.Time       `json:"review_time"`
-	RatingID      sql.NullString  `json:"rating_id"`
-	ReviewSeconds sql.NullInt64   `json:"review_seconds"`
-	NewInterval   sql.NullInt64   `json:"new_interval"`
-	NewStability  sql.NullFloat64 `json:"new_stability"`
-	NewDifficulty sql.NullFloat64 `json:"new_difficulty"`
-	NewDueDate    sql.NullTime    `json:"new_due_date"`
-	SessionID     sql.NullString  `json:"session_id"`
-	CreatedAt     time.Time       `json:"created_at"`
-	UpdatedAt     time.Time       `json:"updated_at"`
+	ID                     string          `json:"id"`
+	CardID                 string          `json:"card_id"`
+	ReviewTime             time.Time       `json:"review_time"`
+	RatingID               sql.NullString  `json:"rating_id"`
+	ReviewSeconds          sql.NullInt64   `json:"review_seconds"`
+	NewInterval            sql.NullInt64   `json:"new_interval"`
+	NewStability           sql.NullFloat64 `json:"new_stability"`
+	NewDifficulty          sql.NullFloat64 `json:"new_difficulty"`
+	NewDueDate             sql.NullTime    `json:"new_due_date"`
+	SessionID              sql.NullString  `json:"session_id"`
+	CreatedAt              time.Time       `json:"created_at"`
+	UpdatedAt              time.Time       `json:"updated_at"`
+	Scheduled              bool            `json:"scheduled"`
+	Lapse                  bool            `json:"lapse"`
+	PriorStatus            sql.NullString  `json:"prior_status"`
+	PriorDueDate           sql.NullTime    `json:"prior_due_date"`
+	PriorStability         sql.NullFloat64 `json:"prior_stability"`
+	PriorDifficulty        sql.NullFloat64 `json:"prior_difficulty"`
+	PriorInterval          sql.NullInt64   `json:"prior_interval"`
+	PriorReps              sql.NullInt64   `json:"prior_reps"`
+	PriorLapses            sql.NullInt64   `json:"prior_lapses"`
+	PriorStep              sql.NullInt64   `json:"prior_step"`
+	PriorEase              sql.NullFloat64 `json:"prior_ease"`
+	PriorLeechedAt         sql.NullTime    `json:"prior_leeched_at"`
+	PriorSessionCardStatus sql.NullString  `json:"prior_session_card_status"`
+	PriorCramStep          sql.NullInt64   `json:"prior_cram_step"`
+	PriorNextCramDue       sql.NullTime    `json:"prior_next_cram_due"`
+	TypedAnswer            sql.NullString  `json:"typed_answer"`
 }
 
 type Session struct {
@@ -119,9 +200,11 @@ type Session struct {
 	StartTime     time.Time      `json:"start_time"`
 	EndTime       sql.NullTime   `json:"end_time"`
 	IsActive      bool           `json:"is_active"`
-	SessionDeckID string         `json:"session_deck_id"`
+	PausedAt      sql.NullTime   `json:"paused_at"`
+	PausedSeconds int64          `json:"paused_seconds"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
+	Reschedule    bool           `json:"reschedule"`
 }
 
 type SessionCard struct {
@@ -130,8 +213,10 @@ type SessionCard struct {
 	CardID      string         `json:"card_id"`
 	Status      sql.NullString `json:"status"`
 	NextCramDue sql.NullTime   `json:"next_cram_due"`
+	Position    int64          `json:"position"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
+	CramStep    int64          `json:"cram_step"`
 }
 
 type SessionDeck struct {
@@ -142,6 +227,14 @@ type SessionDeck struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+type Tag struct {
+	ID        string    `json:"id"`
+	OwnerID   string    `json:"owner_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 type Team struct {
 	ID        string    `json:"id"`
 	Name      string    `json:"name"`
@@ -170,13 +263,53 @@ type User struct {
 }
 
 type UserEvent struct {
-	ID        string         `json:"id"`
-	UserID    string         `json:"user_id"`
-	EventType sql.NullString `json:"event_type"`
-	StartDate time.Time      `json:"start_date"`
-	EndDate   sql.NullTime   `json:"end_date"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
+	ID          string          `json:"id"`
+	UserID      string          `json:"user_id"`
+	EventType   sql.NullString  `json:"event_type"`
+	StartDate   time.Time       `json:"start_date"`
+	EndDate     sql.NullTime    `json:"end_date"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Status      string          `json:"status"`
+	AppliedAt   sql.NullTime    `json:"applied_at"`
+	DailyCap    sql.NullInt64   `json:"daily_cap"`
+	AmnesiaMode sql.NullString  `json:"amnesia_mode"`
+	Decay       sql.NullFloat64 `json:"decay"`
+}
+
+type UserEventChange struct {
+	ID            string          `json:"id"`
+	UserEventID   string          `json:"user_event_id"`
+	CardID        string          `json:"card_id"`
+	OldStatus     sql.NullString  `json:"old_status"`
+	NewStatus     sql.NullString  `json:"new_status"`
+	OldDueDate    sql.NullTime    `json:"old_due_date"`
+	NewDueDate    sql.NullTime    `json:"new_due_date"`
+	OldInterval   sql.NullInt64   `json:"old_interval"`
+	NewInterval   sql.NullInt64   `json:"new_interval"`
+	OldStability  sql.NullFloat64 `json:"old_stability"`
+	NewStability  sql.NullFloat64 `json:"new_stability"`
+	OldDifficulty sql.NullFloat64 `json:"old_difficulty"`
+	NewDifficulty sql.NullFloat64 `json:"new_difficulty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type UserEventDeck struct {
+	ID          string    `json:"id"`
+	UserEventID string    `json:"user_event_id"`
+	DeckID      string    `json:"deck_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type UserFunction struct {
+	ID         string    `json:"id"`
+	OwnerID    string    `json:"owner_id"`
+	Name       string    `json:"name"`
+	Params     string    `json:"params"`
+	OutputType string    `json:"output_type"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 type UserSession struct {
@@ -199,4 +332,14 @@ type UserSetting struct {
 	TutorialEnabled      bool           `json:"tutorial_enabled"`
 	CreatedAt            time.Time      `json:"created_at"`
 	UpdatedAt            time.Time      `json:"updated_at"`
+	FsrsWeights          sql.NullString `json:"fsrs_weights"`
+	DesiredRetention     float64        `json:"desired_retention"`
+	MaximumInterval      int64          `json:"maximum_interval"`
+	DailyReviewLimit     int64          `json:"daily_review_limit"`
+	LearningSteps        string         `json:"learning_steps"`
+	RelearningSteps      string         `json:"relearning_steps"`
+	IntervalFuzz         bool           `json:"interval_fuzz"`
+	LoadBalance          bool           `json:"load_balance"`
+	Timezone             string         `json:"timezone"`
+	DayStartHour         int64          `json:"day_start_hour"`
 }
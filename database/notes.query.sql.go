@@ -100,6 +100,52 @@ func (q *Queries) CreateNoteType(ctx context.Context, arg CreateNoteTypeParams)
 	return i, err
 }
 
+const createNoteTypeField = `-- name: CreateNoteTypeField :one
+INSERT INTO note_type_field (
+  note_type_id,
+  name,
+  position,
+  kind,
+  required,
+  sort_field
+)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, note_type_id, name, position, kind, required, sort_field, created_at, updated_at
+`
+
+type CreateNoteTypeFieldParams struct {
+	NoteTypeID string `json:"note_type_id"`
+	Name       string `json:"name"`
+	Position   int64  `json:"position"`
+	Kind       string `json:"kind"`
+	Required   bool   `json:"required"`
+	SortField  bool   `json:"sort_field"`
+}
+
+func (q *Queries) CreateNoteTypeField(ctx context.Context, arg CreateNoteTypeFieldParams) (NoteTypeField, error) {
+	row := q.db.QueryRowContext(ctx, createNoteTypeField,
+		arg.NoteTypeID,
+		arg.Name,
+		arg.Position,
+		arg.Kind,
+		arg.Required,
+		arg.SortField,
+	)
+	var i NoteTypeField
+	err := row.Scan(
+		&i.ID,
+		&i.NoteTypeID,
+		&i.Name,
+		&i.Position,
+		&i.Kind,
+		&i.Required,
+		&i.SortField,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const deleteNote = `-- name: DeleteNote :exec
 DELETE FROM note
 WHERE id = ?
@@ -130,6 +176,16 @@ func (q *Queries) DeleteNoteType(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteNoteTypeFields = `-- name: DeleteNoteTypeFields :exec
+DELETE FROM note_type_field
+WHERE note_type_id = ?
+`
+
+func (q *Queries) DeleteNoteTypeFields(ctx context.Context, noteTypeID string) error {
+	_, err := q.db.ExecContext(ctx, deleteNoteTypeFields, noteTypeID)
+	return err
+}
+
 const getNote = `-- name: GetNote :one
 SELECT id, deck_id, note_type_id, owner_id, created_at, updated_at FROM note
 WHERE id = ?
@@ -206,26 +262,27 @@ func (q *Queries) ListFieldsByNote(ctx context.Context, noteID string) ([]NoteFi
 	return items, nil
 }
 
-const listNoteTypesByOwner = `-- name: ListNoteTypesByOwner :many
-SELECT id, name, description, owner_id, created_at, updated_at FROM note_type
-WHERE owner_id = ?
-ORDER BY id
+const listNoteFieldsByOwner = `-- name: ListNoteFieldsByOwner :many
+SELECT nf.id, nf.note_id, nf.field_name, nf.field_content, nf.created_at, nf.updated_at FROM note_field AS nf
+JOIN note AS n ON nf.note_id = n.id
+WHERE n.owner_id = ?
+ORDER BY nf.note_id, nf.id
 `
 
-func (q *Queries) ListNoteTypesByOwner(ctx context.Context, ownerID string) ([]NoteType, error) {
-	rows, err := q.db.QueryContext(ctx, listNoteTypesByOwner, ownerID)
+func (q *Queries) ListNoteFieldsByOwner(ctx context.Context, ownerID string) ([]NoteField, error) {
+	rows, err := q.db.QueryContext(ctx, listNoteFieldsByOwner, ownerID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []NoteType
+	var items []NoteField
 	for rows.Next() {
-		var i NoteType
+		var i NoteField
 		if err := rows.Scan(
 			&i.ID,
-			&i.Name,
-			&i.Description,
-			&i.OwnerID,
+			&i.NoteID,
+			&i.FieldName,
+			&i.FieldContent,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -278,6 +335,153 @@ func (q *Queries) ListNotesByDeck(ctx context.Context, deckID string) ([]Note, e
 	return items, nil
 }
 
+const listNotesByNoteType = `-- name: ListNotesByNoteType :many
+SELECT id, deck_id, note_type_id, owner_id, created_at, updated_at FROM note
+WHERE note_type_id = ?
+ORDER BY id
+`
+
+func (q *Queries) ListNotesByNoteType(ctx context.Context, noteTypeID string) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, listNotesByNoteType, noteTypeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeckID,
+			&i.NoteTypeID,
+			&i.OwnerID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotesByOwner = `-- name: ListNotesByOwner :many
+SELECT id, deck_id, note_type_id, owner_id, created_at, updated_at FROM note
+WHERE owner_id = ?
+ORDER BY id
+`
+
+func (q *Queries) ListNotesByOwner(ctx context.Context, ownerID string) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, listNotesByOwner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeckID,
+			&i.NoteTypeID,
+			&i.OwnerID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNoteTypeFields = `-- name: ListNoteTypeFields :many
+SELECT id, note_type_id, name, position, kind, required, sort_field, created_at, updated_at FROM note_type_field
+WHERE note_type_id = ?
+ORDER BY position
+`
+
+func (q *Queries) ListNoteTypeFields(ctx context.Context, noteTypeID string) ([]NoteTypeField, error) {
+	rows, err := q.db.QueryContext(ctx, listNoteTypeFields, noteTypeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NoteTypeField
+	for rows.Next() {
+		var i NoteTypeField
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoteTypeID,
+			&i.Name,
+			&i.Position,
+			&i.Kind,
+			&i.Required,
+			&i.SortField,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNoteTypesByOwner = `-- name: ListNoteTypesByOwner :many
+SELECT id, name, description, owner_id, created_at, updated_at FROM note_type
+WHERE owner_id = ?
+ORDER BY id
+`
+
+func (q *Queries) ListNoteTypesByOwner(ctx context.Context, ownerID string) ([]NoteType, error) {
+	rows, err := q.db.QueryContext(ctx, listNoteTypesByOwner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NoteType
+	for rows.Next() {
+		var i NoteType
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.OwnerID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateNote = `-- name: UpdateNote :one
 UPDATE note
 SET
@@ -306,6 +510,34 @@ func (q *Queries) UpdateNote(ctx context.Context, arg UpdateNoteParams) (Note, e
 	return i, err
 }
 
+const updateNoteDeck = `-- name: UpdateNoteDeck :one
+UPDATE note
+SET
+  deck_id = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, deck_id, note_type_id, owner_id, created_at, updated_at
+`
+
+type UpdateNoteDeckParams struct {
+	DeckID string `json:"deck_id"`
+	ID     string `json:"id"`
+}
+
+func (q *Queries) UpdateNoteDeck(ctx context.Context, arg UpdateNoteDeckParams) (Note, error) {
+	row := q.db.QueryRowContext(ctx, updateNoteDeck, arg.DeckID, arg.ID)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.DeckID,
+		&i.NoteTypeID,
+		&i.OwnerID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const updateNoteField = `-- name: UpdateNoteField :one
 UPDATE note_field
 SET
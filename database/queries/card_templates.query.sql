@@ -5,9 +5,10 @@ INSERT INTO card_template (
   front_html,
   back_html,
   css,
-  owner_id
+  owner_id,
+  condition_field
 )
-VALUES (?, ?, ?, ?, ?, ?)
+VALUES (?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetCardTemplate :one
@@ -33,6 +34,7 @@ SET
   front_html = ?,
   back_html = ?,
   css = ?,
+  condition_field = ?,
   updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
 RETURNING *;
@@ -40,3 +42,36 @@ RETURNING *;
 -- name: DeleteCardTemplate :exec
 DELETE FROM card_template
 WHERE id = ?;
+
+-- name: CreateCardTemplateVersion :one
+INSERT INTO card_template_version (
+  card_template_id,
+  version,
+  template_name,
+  front_html,
+  back_html,
+  css,
+  condition_field
+)
+SELECT
+  t.id,
+  (SELECT COALESCE(MAX(v.version), 0) + 1 FROM card_template_version AS v WHERE v.card_template_id = t.id),
+  t.template_name,
+  t.front_html,
+  t.back_html,
+  t.css,
+  t.condition_field
+FROM card_template AS t
+WHERE t.id = ?
+RETURNING *;
+
+-- name: GetCardTemplateVersion :one
+SELECT * FROM card_template_version
+WHERE card_template_id = ?
+AND version = ?
+LIMIT 1;
+
+-- name: ListCardTemplateVersions :many
+SELECT * FROM card_template_version
+WHERE card_template_id = ?
+ORDER BY version DESC;
@@ -6,9 +6,10 @@ INSERT INTO card (
   stability,
   difficulty,
   interval,
-  status
+  status,
+  ordinal
 )
-VALUES (?, ?, ?, ?, ?, ?, ?)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetCard :one
@@ -28,9 +29,12 @@ SET
   stability = ?,
   difficulty = ?,
   interval = ?,
-  status = ?,
+  status = CASE WHEN status = 'suspended' THEN status ELSE sqlc.arg(status) END,
+  suspended_from = CASE WHEN status = 'suspended' THEN sqlc.arg(status) ELSE suspended_from END,
   reps = ?,
   lapses = ?,
+  step = ?,
+  ease = ?,
   updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
 RETURNING *;
@@ -39,6 +43,38 @@ RETURNING *;
 DELETE FROM card
 WHERE id = ?;
 
+-- name: DeleteCardsByTemplate :exec
+DELETE FROM card
+WHERE card_template_id = ?;
+
+-- name: UpdateCardOrdinal :exec
+UPDATE card
+SET
+  ordinal = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: OrphanCard :exec
+UPDATE card
+SET
+  orphaned_at = ?,
+  suspended_from = COALESCE(status, 'new'),
+  status = 'suspended',
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND orphaned_at IS NULL
+  AND status IS NOT 'suspended';
+
+-- name: RestoreOrphanCard :exec
+UPDATE card
+SET
+  status = CASE WHEN status = 'suspended' THEN suspended_from ELSE status END,
+  suspended_from = CASE WHEN status = 'suspended' THEN NULL ELSE suspended_from END,
+  orphaned_at = NULL,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND orphaned_at IS NOT NULL;
+
 -- name: CardDetailsByDeck :many
 SELECT
     n.id AS note_id,
@@ -94,8 +130,220 @@ SELECT c.id,
        c.status,
        c.reps,
        c.lapses,
+       c.step,
        c.created_at,
-       c.updated_at
+       c.updated_at,
+       c.ease,
+       c.suspended_from,
+       c.leeched_at,
+       c.buried_until,
+       c.bury_kind,
+       c.ordinal,
+       c.orphaned_at
 FROM card AS c
 JOIN note AS n ON c.note_id = n.id
 WHERE n.deck_id = ?;
+
+-- name: CountDueCardsByDay :many
+SELECT CAST(DATE(c.due_date, sqlc.arg(day_shift)) AS TEXT) AS due_day,
+       COUNT(*) AS due_count
+FROM card AS c
+JOIN note AS n ON c.note_id = n.id
+JOIN deck AS d ON n.deck_id = d.id
+WHERE d.owner_id = ?
+  AND c.status = 'review'
+  AND c.due_date >= ?
+  AND c.due_date < ?
+GROUP BY due_day
+ORDER BY due_day;
+
+-- name: ListStudyCardsByOwner :many
+SELECT c.id,
+       c.note_id,
+       c.card_template_id,
+       c.due_date,
+       c.stability,
+       c.difficulty,
+       c.interval,
+       c.status,
+       c.reps,
+       c.lapses,
+       c.step,
+       c.created_at,
+       c.updated_at,
+       n.deck_id,
+       d.name AS deck_name
+FROM card AS c
+JOIN note AS n ON c.note_id = n.id
+JOIN deck AS d ON n.deck_id = d.id
+WHERE d.owner_id = ?
+  AND c.status IS NOT 'suspended'
+  AND (c.buried_until IS NULL OR c.buried_until <= sqlc.arg(now))
+  AND (c.status IS NULL OR c.status = 'new' OR c.due_date < ?)
+ORDER BY c.due_date, c.created_at;
+
+-- name: ListCardsByTag :many
+SELECT c.id,
+       c.note_id,
+       c.card_template_id,
+       c.due_date,
+       c.stability,
+       c.difficulty,
+       c.interval,
+       c.status,
+       c.reps,
+       c.lapses,
+       c.step,
+       c.created_at,
+       c.updated_at,
+       c.ease,
+       c.suspended_from,
+       c.leeched_at,
+       c.buried_until,
+       c.bury_kind,
+       c.ordinal,
+       c.orphaned_at
+FROM card AS c
+JOIN note AS n ON c.note_id = n.id
+JOIN note_tag AS nt ON nt.note_id = n.id
+JOIN tag AS t ON nt.tag_id = t.id
+WHERE t.owner_id = ?
+  AND t.name = ?
+ORDER BY n.deck_id, c.created_at;
+
+-- name: ListReviewCardsDueBefore :many
+SELECT c.id,
+       c.note_id,
+       c.card_template_id,
+       c.due_date,
+       c.stability,
+       c.difficulty,
+       c.interval,
+       c.status,
+       c.reps,
+       c.lapses,
+       c.step,
+       c.created_at,
+       c.updated_at,
+       c.ease,
+       c.suspended_from,
+       c.leeched_at,
+       c.buried_until,
+       c.bury_kind,
+       c.ordinal,
+       c.orphaned_at
+FROM card AS c
+JOIN note AS n ON c.note_id = n.id
+JOIN deck AS d ON n.deck_id = d.id
+WHERE d.owner_id = ?
+  AND c.status = 'review'
+  AND c.due_date < ?
+ORDER BY c.due_date, c.id;
+
+-- name: SuspendCard :one
+UPDATE card
+SET
+  suspended_from = COALESCE(status, 'new'),
+  status = 'suspended',
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND status IS NOT 'suspended'
+RETURNING *;
+
+-- name: UnsuspendCard :one
+UPDATE card
+SET
+  status = suspended_from,
+  suspended_from = NULL,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND status = 'suspended'
+RETURNING *;
+
+-- name: RestoreCardState :one
+UPDATE card
+SET
+  due_date = ?,
+  stability = ?,
+  difficulty = ?,
+  interval = ?,
+  status = ?,
+  suspended_from = NULL,
+  reps = ?,
+  lapses = ?,
+  step = ?,
+  ease = ?,
+  leeched_at = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: BuryCard :one
+UPDATE card
+SET
+  buried_until = ?,
+  bury_kind = 'manual',
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: UnburyCard :one
+UPDATE card
+SET
+  buried_until = NULL,
+  bury_kind = NULL,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: BurySiblingCards :exec
+UPDATE card
+SET
+  buried_until = sqlc.arg(buried_until),
+  bury_kind = 'sibling',
+  updated_at = CURRENT_TIMESTAMP
+WHERE note_id = sqlc.arg(note_id)
+  AND id <> sqlc.arg(id)
+  AND (status IS NULL OR status IN ('new', 'review'))
+  AND (buried_until IS NULL OR buried_until <= sqlc.arg(now));
+
+-- name: UnburySiblingCards :exec
+UPDATE card
+SET
+  buried_until = NULL,
+  bury_kind = NULL,
+  updated_at = CURRENT_TIMESTAMP
+WHERE note_id = sqlc.arg(note_id)
+  AND bury_kind = 'sibling'
+  AND NOT EXISTS (
+    SELECT 1
+    FROM review AS r
+    JOIN card AS c ON r.card_id = c.id
+    WHERE c.note_id = sqlc.arg(note_id)
+      AND r.scheduled = 1
+      AND r.review_time >= sqlc.arg(since)
+  );
+
+-- name: MarkCardLeech :one
+UPDATE card
+SET
+  leeched_at = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: ListLeechCardsByOwner :many
+SELECT c.id,
+       c.note_id,
+       c.status,
+       c.suspended_from,
+       c.lapses,
+       c.leeched_at,
+       n.deck_id,
+       d.name AS deck_name
+FROM card AS c
+JOIN note AS n ON c.note_id = n.id
+JOIN deck AS d ON n.deck_id = d.id
+WHERE n.owner_id = ?
+  AND c.leeched_at IS NOT NULL
+ORDER BY c.leeched_at DESC, c.id;
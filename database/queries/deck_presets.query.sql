@@ -0,0 +1,68 @@
+-- name: CreateDeckPreset :one
+INSERT INTO deck_preset (
+  owner_id,
+  name,
+  fsrs_weights,
+  desired_retention,
+  maximum_interval,
+  new_cards_per_day,
+  reviews_per_day,
+  learning_steps,
+  relearning_steps,
+  scheduler,
+  starting_ease,
+  easy_bonus,
+  interval_modifier,
+  graduating_interval,
+  easy_interval,
+  leech_threshold,
+  leech_action,
+  bury_siblings
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetDeckPreset :one
+SELECT * FROM deck_preset
+WHERE id = ?
+LIMIT 1;
+
+-- name: ListDeckPresetsByOwner :many
+SELECT * FROM deck_preset
+WHERE owner_id = ?
+ORDER BY name;
+
+-- name: UpdateDeckPreset :one
+UPDATE deck_preset
+SET
+  name = ?,
+  fsrs_weights = ?,
+  desired_retention = ?,
+  maximum_interval = ?,
+  new_cards_per_day = ?,
+  reviews_per_day = ?,
+  learning_steps = ?,
+  relearning_steps = ?,
+  scheduler = ?,
+  starting_ease = ?,
+  easy_bonus = ?,
+  interval_modifier = ?,
+  graduating_interval = ?,
+  easy_interval = ?,
+  leech_threshold = ?,
+  leech_action = ?,
+  bury_siblings = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateDeckPresetFSRSWeights :exec
+UPDATE deck_preset
+SET
+  fsrs_weights = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: DeleteDeckPreset :exec
+DELETE FROM deck_preset
+WHERE id = ?;
@@ -22,6 +22,13 @@ SELECT * FROM deck
 WHERE owner_id = ?
 ORDER BY id;
 
+-- name: GetDeckByOwnerAndName :one
+SELECT * FROM deck
+WHERE owner_id = ?
+  AND name = ?
+ORDER BY created_at
+LIMIT 1;
+
 -- name: UpdateDeck :one
 UPDATE deck
 SET
@@ -66,3 +73,11 @@ FROM deck
 JOIN deck_collaborator ON deck.id = deck_collaborator.deck_id
 WHERE deck_collaborator.user_id = ?
 ORDER BY deck.id;
+
+-- name: SetDeckPreset :one
+UPDATE deck
+SET
+  preset_id = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
@@ -0,0 +1,96 @@
+-- name: CreateUserEvent :one
+INSERT INTO user_event (
+  user_id,
+  event_type,
+  start_date,
+  end_date,
+  daily_cap,
+  amnesia_mode,
+  decay
+)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetUserEvent :one
+SELECT * FROM user_event
+WHERE id = ?
+LIMIT 1;
+
+-- name: ListUserEventsByUser :many
+SELECT * FROM user_event
+WHERE user_id = ?
+ORDER BY start_date DESC;
+
+-- name: ListPendingUserEvents :many
+SELECT * FROM user_event
+WHERE status = 'pending'
+  AND start_date <= ?
+ORDER BY start_date;
+
+-- name: MarkUserEventApplied :one
+UPDATE user_event
+SET
+  status = 'applied',
+  applied_at = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND status = 'pending'
+RETURNING *;
+
+-- name: CancelUserEvent :one
+UPDATE user_event
+SET
+  status = 'cancelled',
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+  AND status = 'pending'
+RETURNING *;
+
+-- name: AddDeckToUserEvent :exec
+INSERT INTO user_event_deck (
+  user_event_id,
+  deck_id
+)
+VALUES (?, ?);
+
+-- name: ListUserEventDecks :many
+SELECT * FROM user_event_deck
+WHERE user_event_id = ?
+ORDER BY created_at, deck_id;
+
+-- name: CreateUserEventChange :exec
+INSERT INTO user_event_change (
+  user_event_id,
+  card_id,
+  old_status,
+  new_status,
+  old_due_date,
+  new_due_date,
+  old_interval,
+  new_interval,
+  old_stability,
+  new_stability,
+  old_difficulty,
+  new_difficulty,
+  created_at
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListUserEventChanges :many
+SELECT * FROM user_event_change
+WHERE user_event_id = ?
+ORDER BY new_due_date, card_id;
+
+-- name: ListAppliedUserEventChangesByCard :many
+SELECT c.created_at,
+       e.event_type,
+       e.amnesia_mode,
+       c.new_due_date,
+       c.new_interval,
+       c.new_stability,
+       c.new_difficulty
+FROM user_event_change AS c
+JOIN user_event AS e ON e.id = c.user_event_id
+WHERE c.card_id = ?
+  AND e.status = 'applied'
+ORDER BY c.created_at;
@@ -30,6 +30,27 @@ RETURNING *;
 DELETE FROM note_type
 WHERE id = ?;
 
+-- name: CreateNoteTypeField :one
+INSERT INTO note_type_field (
+  note_type_id,
+  name,
+  position,
+  kind,
+  required,
+  sort_field
+)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListNoteTypeFields :many
+SELECT * FROM note_type_field
+WHERE note_type_id = ?
+ORDER BY position;
+
+-- name: DeleteNoteTypeFields :exec
+DELETE FROM note_type_field
+WHERE note_type_id = ?;
+
 -- name: CreateNote :one
 INSERT INTO note (
   deck_id,
@@ -49,6 +70,16 @@ SELECT * FROM note
 WHERE deck_id = ?
 ORDER BY id;
 
+-- name: ListNotesByOwner :many
+SELECT * FROM note
+WHERE owner_id = ?
+ORDER BY id;
+
+-- name: ListNotesByNoteType :many
+SELECT * FROM note
+WHERE note_type_id = ?
+ORDER BY id;
+
 -- name: UpdateNote :one
 UPDATE note
 SET
@@ -57,6 +88,14 @@ SET
 WHERE id = ?
 RETURNING *;
 
+-- name: UpdateNoteDeck :one
+UPDATE note
+SET
+  deck_id = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
 -- name: DeleteNote :exec
 DELETE FROM note
 WHERE id = ?;
@@ -75,6 +114,12 @@ SELECT * FROM note_field
 WHERE note_id = ?
 ORDER BY id;
 
+-- name: ListNoteFieldsByOwner :many
+SELECT nf.* FROM note_field AS nf
+JOIN note AS n ON nf.note_id = n.id
+WHERE n.owner_id = ?
+ORDER BY nf.note_id, nf.id;
+
 -- name: UpdateNoteField :one
 UPDATE note_field
 SET
@@ -3,7 +3,7 @@ INSERT INTO session (
   user_id,
   mode,
   name,
-  session_deck_id
+  reschedule
 )
 VALUES (?, ?, ?, ?)
 RETURNING *;
@@ -24,6 +24,39 @@ SET
 WHERE id = ?
 RETURNING *;
 
+-- name: ListSessionsByUser :many
+SELECT * FROM session
+WHERE user_id = ?
+ORDER BY start_time DESC;
+
+-- name: PauseSession :one
+UPDATE session
+SET
+  paused_at = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: ResumeSession :one
+UPDATE session
+SET
+  paused_at = NULL,
+  paused_seconds = paused_seconds + ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: EndSession :one
+UPDATE session
+SET
+  end_time = ?,
+  is_active = 0,
+  paused_at = NULL,
+  paused_seconds = paused_seconds + ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
 -- name: DeleteSession :exec
 DELETE FROM session
 WHERE id = ?;
@@ -49,9 +82,10 @@ WHERE id = ?;
 INSERT INTO session_card (
   session_id,
   card_id,
-  status
+  status,
+  position
 )
-VALUES (?, ?, ?)
+VALUES (?, ?, ?, ?)
 RETURNING *;
 
 -- name: ListSessionCards :many
@@ -59,11 +93,34 @@ SELECT * FROM session_card
 WHERE session_id = ?
 ORDER BY id;
 
+-- name: GetSessionCard :one
+SELECT * FROM session_card
+WHERE session_id = ?
+  AND card_id = ?
+LIMIT 1;
+
+-- name: ListSessionQueue :many
+SELECT sc.card_id,
+       sc.status,
+       sc.position,
+       sc.next_cram_due,
+       sc.cram_step,
+       c.status AS card_status,
+       c.due_date
+FROM session_card AS sc
+JOIN card AS c ON sc.card_id = c.id
+WHERE sc.session_id = ?
+  AND sc.status <> 'done'
+  AND c.status IS NOT 'suspended'
+  AND (c.buried_until IS NULL OR c.buried_until <= sqlc.arg(now))
+ORDER BY sc.position;
+
 -- name: UpdateSessionCard :one
 UPDATE session_card
 SET
   status = ?,
   next_cram_due = ?,
+  cram_step = ?,
   updated_at = CURRENT_TIMESTAMP
 WHERE session_id = ?
   AND card_id = ?
@@ -76,15 +133,32 @@ WHERE id = ?;
 -- name: CreateReview :one
 INSERT INTO review (
   card_id,
+  review_time,
   rating_id,
   review_seconds,
   new_interval,
   new_stability,
   new_difficulty,
   new_due_date,
-  session_id
+  session_id,
+  scheduled,
+  lapse,
+  prior_status,
+  prior_due_date,
+  prior_stability,
+  prior_difficulty,
+  prior_interval,
+  prior_reps,
+  prior_lapses,
+  prior_step,
+  prior_ease,
+  prior_leeched_at,
+  prior_session_card_status,
+  prior_cram_step,
+  prior_next_cram_due,
+  typed_answer
 )
-VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetReview :one
@@ -97,6 +171,34 @@ SELECT * FROM review
 WHERE card_id = ?
 ORDER BY id;
 
+-- name: ListScheduledReviewsByCard :many
+SELECT * FROM review
+WHERE card_id = ?
+  AND scheduled = 1
+ORDER BY review_time, id;
+
+-- name: GetLatestReviewByCard :one
+SELECT * FROM review
+WHERE card_id = ?
+  AND scheduled = 1
+ORDER BY review_time DESC
+LIMIT 1;
+
+-- name: GetLatestReviewBySession :one
+SELECT * FROM review
+WHERE session_id = ?
+ORDER BY review_time DESC, created_at DESC
+LIMIT 1;
+
+-- name: SummarizeSessionReviews :many
+SELECT rating_id,
+       COUNT(*) AS review_count,
+       CAST(COALESCE(SUM(review_seconds), 0) AS INTEGER) AS review_seconds
+FROM review
+WHERE session_id = ?
+GROUP BY rating_id
+ORDER BY rating_id;
+
 -- name: DeleteReview :exec
 DELETE FROM review
 WHERE id = ?;
@@ -109,3 +211,90 @@ RETURNING *;
 -- name: ListRatings :many
 SELECT * FROM rating
 ORDER BY id;
+
+-- name: ListReviewLogByOwner :many
+SELECT r.card_id,
+       r.review_time,
+       r.rating_id
+FROM review AS r
+JOIN card AS c ON r.card_id = c.id
+JOIN note AS n ON c.note_id = n.id
+WHERE n.owner_id = ?
+  AND r.scheduled = 1
+ORDER BY r.card_id, r.review_time;
+
+-- name: ListReviewLogByDeck :many
+SELECT r.card_id,
+       r.review_time,
+       r.rating_id
+FROM review AS r
+JOIN card AS c ON r.card_id = c.id
+JOIN note AS n ON c.note_id = n.id
+WHERE n.deck_id = ?
+  AND r.scheduled = 1
+ORDER BY r.card_id, r.review_time;
+
+-- name: ListReviewLogByPreset :many
+SELECT r.card_id,
+       r.review_time,
+       r.rating_id
+FROM review AS r
+JOIN card AS c ON r.card_id = c.id
+JOIN note AS n ON c.note_id = n.id
+JOIN deck AS d ON n.deck_id = d.id
+WHERE d.preset_id = ?
+  AND r.scheduled = 1
+ORDER BY r.card_id, r.review_time;
+
+-- name: CountNewCardsStudiedSince :many
+SELECT n.deck_id,
+       COUNT(DISTINCT r.card_id) AS new_count
+FROM review AS r
+JOIN card AS c ON r.card_id = c.id
+JOIN note AS n ON c.note_id = n.id
+WHERE n.owner_id = sqlc.arg(owner_id)
+  AND r.review_time >= sqlc.arg(since)
+  AND r.scheduled = 1
+  AND NOT EXISTS (
+    SELECT 1 FROM review AS p
+    WHERE p.card_id = r.card_id
+      AND p.review_time < sqlc.arg(since)
+      AND p.scheduled = 1
+  )
+GROUP BY n.deck_id;
+
+-- name: CountReviewCardsStudiedSince :many
+SELECT n.deck_id,
+       COUNT(DISTINCT r.card_id) AS review_count
+FROM review AS r
+JOIN card AS c ON r.card_id = c.id
+JOIN note AS n ON c.note_id = n.id
+WHERE n.owner_id = sqlc.arg(owner_id)
+  AND r.review_time >= sqlc.arg(since)
+  AND r.scheduled = 1
+  AND EXISTS (
+    SELECT 1 FROM review AS p
+    WHERE p.card_id = r.card_id
+      AND p.review_time < sqlc.arg(since)
+      AND p.scheduled = 1
+  )
+GROUP BY n.deck_id;
+
+-- name: AverageReviewSecondsByOwner :one
+SELECT CAST(COALESCE(AVG(r.review_seconds), 0) AS REAL) AS average_seconds
+FROM review AS r
+JOIN card AS c ON r.card_id = c.id
+JOIN note AS n ON c.note_id = n.id
+WHERE n.owner_id = ?
+  AND r.review_seconds > 0;
+
+-- name: ListLeechLapsesByOwner :many
+SELECT r.card_id,
+       r.review_time
+FROM review AS r
+JOIN card AS c ON r.card_id = c.id
+JOIN note AS n ON c.note_id = n.id
+WHERE n.owner_id = ?
+  AND c.leeched_at IS NOT NULL
+  AND r.lapse = 1
+ORDER BY r.card_id, r.review_time;
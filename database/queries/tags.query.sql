@@ -0,0 +1,31 @@
+-- name: UpsertTag :one
+INSERT INTO tag (
+  owner_id,
+  name
+)
+VALUES (?, ?)
+ON CONFLICT (owner_id, name) DO UPDATE SET name = excluded.name
+RETURNING *;
+
+-- name: ListTagsByOwner :many
+SELECT * FROM tag
+WHERE owner_id = ?
+ORDER BY name;
+
+-- name: ListTagsByNote :many
+SELECT t.* FROM tag AS t
+JOIN note_tag AS nt ON nt.tag_id = t.id
+WHERE nt.note_id = ?
+ORDER BY t.name;
+
+-- name: AddTagToNote :exec
+INSERT INTO note_tag (
+  note_id,
+  tag_id
+)
+VALUES (?, ?)
+ON CONFLICT (note_id, tag_id) DO NOTHING;
+
+-- name: ClearNoteTags :exec
+DELETE FROM note_tag
+WHERE note_id = ?;
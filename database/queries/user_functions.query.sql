@@ -0,0 +1,35 @@
+-- name: CreateUserFunction :one
+INSERT INTO user_function (
+  owner_id,
+  name,
+  params,
+  output_type,
+  body
+)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetUserFunction :one
+SELECT * FROM user_function
+WHERE id = ?
+LIMIT 1;
+
+-- name: ListUserFunctionsByOwner :many
+SELECT * FROM user_function
+WHERE owner_id = ?
+ORDER BY name;
+
+-- name: UpdateUserFunction :one
+UPDATE user_function
+SET
+  name = ?,
+  params = ?,
+  output_type = ?,
+  body = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteUserFunction :exec
+DELETE FROM user_function
+WHERE id = ?;
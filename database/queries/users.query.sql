@@ -85,3 +85,27 @@ SET
   updated_at = CURRENT_TIMESTAMP
 WHERE user_id = ?
 RETURNING *;
+
+-- name: UpdateUserFSRSWeights :exec
+UPDATE user_setting
+SET
+  fsrs_weights = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE user_id = ?;
+
+-- name: UpdateUserSchedulerSetting :one
+UPDATE user_setting
+SET
+  desired_retention = ?,
+  maximum_interval = ?,
+  daily_new_cards_limit = ?,
+  daily_review_limit = ?,
+  learning_steps = ?,
+  relearning_steps = ?,
+  interval_fuzz = ?,
+  load_balance = ?,
+  timezone = ?,
+  day_start_hour = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE user_id = ?
+RETURNING *;
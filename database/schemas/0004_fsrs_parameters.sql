@@ -0,0 +1,16 @@
+-- 0004_fsrs_parameters.sql
+
+-- Ratings use their FSRS grade as id so review.rating_id maps directly to algorithm.Rating
+INSERT OR IGNORE INTO rating (id, name) VALUES
+    ('1', 'again'),
+    ('2', 'hard'),
+    ('3', 'good'),
+    ('4', 'easy');
+
+-- Fitted FSRS weights per user, stored as a JSON array of 19 floats.
+-- NULL means the user is scheduled with algorithm.DefaultParams().
+ALTER TABLE user_setting
+ADD COLUMN fsrs_weights TEXT;
+
+-- Review log lookups by card in review order
+CREATE INDEX IF NOT EXISTS idx_review_card_id ON review(card_id, review_time);
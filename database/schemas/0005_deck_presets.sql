@@ -0,0 +1,50 @@
+-- 0005_deck_presets.sql
+
+-- Scheduler options shared by one or more decks. NULL columns inherit the
+-- owner's defaults from user_setting.
+CREATE TABLE IF NOT EXISTS deck_preset (
+    id                TEXT PRIMARY KEY DEFAULT (SUBSTR(LOWER(HEX(RANDOMBLOB(10))), 1, 10)),
+    owner_id          TEXT NOT NULL,
+    name              TEXT NOT NULL,
+    fsrs_weights      TEXT,
+    desired_retention REAL CHECK (desired_retention > 0 AND desired_retention < 1),
+    maximum_interval  INTEGER CHECK (maximum_interval >= 1),
+    new_cards_per_day INTEGER CHECK (new_cards_per_day >= 0),
+    reviews_per_day   INTEGER CHECK (reviews_per_day >= 0),
+    learning_steps    TEXT,
+    relearning_steps  TEXT,
+    created_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(owner_id) REFERENCES user(id) ON DELETE CASCADE ON UPDATE CASCADE
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS idx_deck_preset_owner_id ON deck_preset(owner_id);
+
+CREATE TRIGGER update_deck_preset_updated_at
+AFTER UPDATE ON deck_preset
+WHEN old.updated_at <> current_timestamp
+BEGIN
+    UPDATE deck_preset
+    SET updated_at = CURRENT_TIMESTAMP
+    WHERE id = OLD.id;
+END;
+
+-- A deck without a preset uses the owner's defaults
+ALTER TABLE deck
+ADD COLUMN preset_id TEXT REFERENCES deck_preset(id) ON DELETE SET NULL ON UPDATE CASCADE;
+
+-- User-wide scheduler defaults. Steps are space separated durations, e.g. '1m 10m'.
+ALTER TABLE user_setting
+ADD COLUMN desired_retention REAL NOT NULL DEFAULT 0.9;
+
+ALTER TABLE user_setting
+ADD COLUMN maximum_interval INTEGER NOT NULL DEFAULT 36500;
+
+ALTER TABLE user_setting
+ADD COLUMN daily_review_limit INTEGER NOT NULL DEFAULT 200;
+
+ALTER TABLE user_setting
+ADD COLUMN learning_steps TEXT NOT NULL DEFAULT '1m 10m';
+
+ALTER TABLE user_setting
+ADD COLUMN relearning_steps TEXT NOT NULL DEFAULT '10m';
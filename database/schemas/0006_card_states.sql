@@ -0,0 +1,71 @@
+-- 0006_card_states.sql
+
+-- Rebuild card to allow the 'relearning' status and to track the current
+-- (re)learning step. SQLite cannot alter a CHECK constraint in place.
+CREATE TABLE card_new (
+    id               TEXT PRIMARY KEY DEFAULT (SUBSTR(LOWER(HEX(RANDOMBLOB(10))), 1, 10)),
+    note_id          TEXT NOT NULL,
+    card_template_id TEXT NOT NULL,
+    due_date         DATETIME,
+    stability        REAL,
+    difficulty       REAL,
+    interval         INTEGER,
+    status           TEXT CHECK (status IN ('new', 'learning', 'review', 'relearning')),
+    reps             INTEGER DEFAULT 0,
+    lapses           INTEGER DEFAULT 0,
+    step             INTEGER NOT NULL DEFAULT 0,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(note_id)          REFERENCES note(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY(card_template_id) REFERENCES card_template(id) ON DELETE SET NULL ON UPDATE CASCADE
+) WITHOUT ROWID;
+
+INSERT INTO card_new (id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, created_at, updated_at)
+SELECT id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, created_at, updated_at
+FROM card;
+
+DROP TABLE card;
+ALTER TABLE card_new RENAME TO card;
+
+-- Recreate the index and triggers dropped with the old table
+CREATE INDEX IF NOT EXISTS idx_card_note_id ON card(note_id);
+
+CREATE TRIGGER IF NOT EXISTS trg_card_insert
+AFTER INSERT ON card
+FOR EACH ROW
+BEGIN
+    UPDATE deck
+    SET card_count = card_count + 1
+    WHERE id = (SELECT deck_id FROM note WHERE id = NEW.note_id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_card_delete
+AFTER DELETE ON card
+FOR EACH ROW
+BEGIN
+    UPDATE deck
+    SET card_count = card_count - 1
+    WHERE id = (SELECT deck_id FROM note WHERE id = OLD.note_id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_card_update_note
+AFTER UPDATE OF note_id ON card
+FOR EACH ROW
+BEGIN
+    UPDATE deck
+    SET card_count = card_count - 1
+    WHERE id = (SELECT deck_id FROM note WHERE id = OLD.note_id);
+
+    UPDATE deck
+    SET card_count = card_count + 1
+    WHERE id = (SELECT deck_id FROM note WHERE id = NEW.note_id);
+END;
+
+CREATE TRIGGER update_card_updated_at
+AFTER UPDATE ON card
+WHEN old.updated_at <> current_timestamp
+BEGIN
+    UPDATE card
+    SET updated_at = CURRENT_TIMESTAMP
+    WHERE id = OLD.id;
+END;
@@ -0,0 +1,8 @@
+-- 0007_load_balance.sql
+
+-- Interval fuzz and review load balancing, per user
+ALTER TABLE user_setting ADD COLUMN interval_fuzz BOOLEAN NOT NULL DEFAULT 1;
+ALTER TABLE user_setting ADD COLUMN load_balance BOOLEAN NOT NULL DEFAULT 0;
+
+-- Due counts per day are looked up on every graduating review
+CREATE INDEX IF NOT EXISTS idx_card_due_date ON card(due_date);
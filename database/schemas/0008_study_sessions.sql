@@ -0,0 +1,73 @@
+-- 0008_study_sessions.sql
+
+-- Rebuild session without session_deck_id: a session spans many decks through
+-- session_deck, and the column made session and session_deck reference each
+-- other with NOT NULL keys. Track pauses so time spent excludes them.
+CREATE TABLE session_new (
+    id               TEXT PRIMARY KEY DEFAULT (SUBSTR(LOWER(HEX(RANDOMBLOB(10))), 1, 10)),
+    user_id          TEXT NOT NULL,
+    mode             TEXT CHECK (mode IN ('normal', 'cram')),
+    name             TEXT,
+    start_time       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    end_time         DATETIME,
+    is_active        BOOLEAN NOT NULL DEFAULT 1,
+    paused_at        DATETIME,
+    paused_seconds   INTEGER NOT NULL DEFAULT 0,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES user(id) ON DELETE CASCADE ON UPDATE CASCADE
+) WITHOUT ROWID;
+
+INSERT INTO session_new (id, user_id, mode, name, start_time, end_time, is_active, created_at, updated_at)
+SELECT id, user_id, mode, name, start_time, end_time, is_active, created_at, updated_at
+FROM session;
+
+DROP TABLE session;
+ALTER TABLE session_new RENAME TO session;
+
+CREATE INDEX IF NOT EXISTS idx_session_user_id ON session(user_id);
+
+CREATE TRIGGER update_session_updated_at
+AFTER UPDATE ON session
+WHEN old.updated_at <> current_timestamp
+BEGIN
+    UPDATE session
+    SET updated_at = CURRENT_TIMESTAMP
+    WHERE id = OLD.id;
+END;
+
+-- Rebuild session_card: the status CHECK compared against the single string
+-- 'pending, in-progress, done', and cards need a position in the session.
+CREATE TABLE session_card_new (
+    id            TEXT PRIMARY KEY DEFAULT (SUBSTR(LOWER(HEX(RANDOMBLOB(10))), 1, 10)),
+    session_id    TEXT NOT NULL,
+    card_id       TEXT NOT NULL,
+    status        TEXT CHECK (status IN ('pending', 'in-progress', 'done')),
+    next_cram_due DATETIME,
+    position      INTEGER NOT NULL DEFAULT 0,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(session_id) REFERENCES session(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY(card_id)    REFERENCES card(id) ON DELETE CASCADE ON UPDATE CASCADE
+) WITHOUT ROWID;
+
+INSERT INTO session_card_new (id, session_id, card_id, status, next_cram_due, created_at, updated_at)
+SELECT id, session_id, card_id, status, next_cram_due, created_at, updated_at
+FROM session_card;
+
+DROP TABLE session_card;
+ALTER TABLE session_card_new RENAME TO session_card;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_session_card_session_card ON session_card(session_id, card_id);
+
+CREATE TRIGGER update_session_card_updated_at
+AFTER UPDATE ON session_card
+WHEN old.updated_at <> current_timestamp
+BEGIN
+    UPDATE session_card
+    SET updated_at = CURRENT_TIMESTAMP
+    WHERE id = OLD.id;
+END;
+
+CREATE INDEX IF NOT EXISTS idx_session_deck_session_id ON session_deck(session_id);
+CREATE INDEX IF NOT EXISTS idx_review_session_id ON review(session_id);
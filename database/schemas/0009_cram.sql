@@ -0,0 +1,44 @@
+-- 0009_cram.sql
+
+-- Tags group notes across decks, e.g. to cram a single topic.
+CREATE TABLE IF NOT EXISTS tag (
+    id         TEXT PRIMARY KEY DEFAULT (SUBSTR(LOWER(HEX(RANDOMBLOB(10))), 1, 10)),
+    owner_id   TEXT NOT NULL,
+    name       TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(owner_id) REFERENCES user(id) ON DELETE CASCADE ON UPDATE CASCADE
+) WITHOUT ROWID;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tag_owner_id_name ON tag(owner_id, name);
+
+CREATE TRIGGER update_tag_updated_at
+AFTER UPDATE ON tag
+WHEN old.updated_at <> current_timestamp
+BEGIN
+    UPDATE tag
+    SET updated_at = CURRENT_TIMESTAMP
+    WHERE id = OLD.id;
+END;
+
+CREATE TABLE IF NOT EXISTS note_tag (
+    id         TEXT PRIMARY KEY DEFAULT (SUBSTR(LOWER(HEX(RANDOMBLOB(10))), 1, 10)),
+    note_id    TEXT NOT NULL,
+    tag_id     TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(note_id) REFERENCES note(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY(tag_id)  REFERENCES tag(id) ON DELETE CASCADE ON UPDATE CASCADE
+) WITHOUT ROWID;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_note_tag_note_id_tag_id ON note_tag(note_id, tag_id);
+CREATE INDEX IF NOT EXISTS idx_note_tag_tag_id ON note_tag(tag_id);
+
+-- Cram sessions leave card scheduling alone unless the user opts in.
+ALTER TABLE session ADD COLUMN reschedule BOOLEAN NOT NULL DEFAULT 0;
+
+-- A card's progress through the cram steps of its session.
+ALTER TABLE session_card ADD COLUMN cram_step INTEGER NOT NULL DEFAULT 0;
+
+-- Reviews that did not change the card's scheduling (cram reviews without
+-- rescheduling) are kept for history but ignored by the scheduler and optimizer.
+ALTER TABLE review ADD COLUMN scheduled BOOLEAN NOT NULL DEFAULT 1;
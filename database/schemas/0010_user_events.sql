@@ -0,0 +1,47 @@
+-- 0010_user_events.sql
+
+-- Events are applied once, by a job, when they start. Vacations spread the
+-- cards that fell due during the vacation over the days after it, at most
+-- daily_cap a day. Amnesia either resets the cards of the event's decks or
+-- keeps only a decay fraction of their stability.
+ALTER TABLE user_event ADD COLUMN status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'applied', 'cancelled'));
+ALTER TABLE user_event ADD COLUMN applied_at DATETIME;
+ALTER TABLE user_event ADD COLUMN daily_cap INTEGER;
+ALTER TABLE user_event ADD COLUMN amnesia_mode TEXT CHECK (amnesia_mode IN ('reset', 'decay'));
+ALTER TABLE user_event ADD COLUMN decay REAL;
+
+CREATE INDEX IF NOT EXISTS idx_user_event_user_id ON user_event(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_event_status_start_date ON user_event(status, start_date);
+
+CREATE TABLE IF NOT EXISTS user_event_deck (
+    id            TEXT PRIMARY KEY DEFAULT (SUBSTR(LOWER(HEX(RANDOMBLOB(10))), 1, 10)),
+    user_event_id TEXT NOT NULL,
+    deck_id       TEXT NOT NULL,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_event_id) REFERENCES user_event(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY(deck_id)       REFERENCES deck(id) ON DELETE CASCADE ON UPDATE CASCADE
+) WITHOUT ROWID;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_event_deck_event_id_deck_id ON user_event_deck(user_event_id, deck_id);
+
+-- What applying an event changed, card by card.
+CREATE TABLE IF NOT EXISTS user_event_change (
+    id             TEXT PRIMARY KEY DEFAULT (SUBSTR(LOWER(HEX(RANDOMBLOB(10))), 1, 10)),
+    user_event_id  TEXT NOT NULL,
+    card_id        TEXT NOT NULL,
+    old_status     TEXT,
+    new_status     TEXT,
+    old_due_date   DATETIME,
+    new_due_date   DATETIME,
+    old_interval   INTEGER,
+    new_interval   INTEGER,
+    old_stability  REAL,
+    new_stability  REAL,
+    old_difficulty REAL,
+    new_difficulty REAL,
+    created_at     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_event_id) REFERENCES user_event(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY(card_id)       REFERENCES card(id) ON DELETE CASCADE ON UPDATE CASCADE
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS idx_user_event_change_event_id ON user_event_change(user_event_id);
@@ -0,0 +1,13 @@
+-- 0011_sm2_scheduler.sql
+
+-- Presets choose the scheduling algorithm of their decks. NULL means FSRS,
+-- and NULL SM-2 options fall back to Anki's defaults.
+ALTER TABLE deck_preset ADD COLUMN scheduler TEXT CHECK (scheduler IN ('fsrs', 'sm2'));
+ALTER TABLE deck_preset ADD COLUMN starting_ease REAL CHECK (starting_ease >= 1.3);
+ALTER TABLE deck_preset ADD COLUMN easy_bonus REAL CHECK (easy_bonus >= 1);
+ALTER TABLE deck_preset ADD COLUMN interval_modifier REAL CHECK (interval_modifier > 0);
+ALTER TABLE deck_preset ADD COLUMN graduating_interval INTEGER CHECK (graduating_interval >= 1);
+ALTER TABLE deck_preset ADD COLUMN easy_interval INTEGER CHECK (easy_interval >= 1);
+
+-- SM-2 ease factor, NULL until the card graduates under SM-2
+ALTER TABLE card ADD COLUMN ease REAL;
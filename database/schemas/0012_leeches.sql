@@ -0,0 +1,114 @@
+-- 0012_leeches.sql
+
+-- Rebuild card to allow the 'suspended' status. A suspended card keeps the
+-- status it was suspended from in suspended_from, so that unsuspending it
+-- restores its scheduling. leeched_at is when the card last became a leech.
+CREATE TABLE card_new (
+    id               TEXT PRIMARY KEY DEFAULT (SUBSTR(LOWER(HEX(RANDOMBLOB(10))), 1, 10)),
+    note_id          TEXT NOT NULL,
+    card_template_id TEXT NOT NULL,
+    due_date         DATETIME,
+    stability        REAL,
+    difficulty       REAL,
+    interval         INTEGER,
+    status           TEXT CHECK (status IN ('new', 'learning', 'review', 'relearning', 'suspended')),
+    reps             INTEGER DEFAULT 0,
+    lapses           INTEGER DEFAULT 0,
+    step             INTEGER NOT NULL DEFAULT 0,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ease             REAL,
+    suspended_from   TEXT CHECK (suspended_from IN ('new', 'learning', 'review', 'relearning')),
+    leeched_at       DATETIME,
+    FOREIGN KEY(note_id)          REFERENCES note(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY(card_template_id) REFERENCES card_template(id) ON DELETE SET NULL ON UPDATE CASCADE
+) WITHOUT ROWID;
+
+INSERT INTO card_new (id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, step, created_at, updated_at, ease)
+SELECT id, note_id, card_template_id, due_date, stability, difficulty, interval, status, reps, lapses, step, created_at, updated_at, ease
+FROM card;
+
+DROP TABLE card;
+ALTER TABLE card_new RENAME TO card;
+
+-- Recreate the indexes and triggers dropped with the old table
+CREATE INDEX IF NOT EXISTS idx_card_note_id ON card(note_id);
+CREATE INDEX IF NOT EXISTS idx_card_due_date ON card(due_date);
+CREATE INDEX IF NOT EXISTS idx_card_leeched_at ON card(leeched_at);
+
+CREATE TRIGGER IF NOT EXISTS trg_card_insert
+AFTER INSERT ON card
+FOR EACH ROW
+BEGIN
+    UPDATE deck
+    SET card_count = card_count + 1
+    WHERE id = (SELECT deck_id FROM note WHERE id = NEW.note_id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_card_delete
+AFTER DELETE ON card
+FOR EACH ROW
+BEGIN
+    UPDATE deck
+    SET card_count = card_count - 1
+    WHERE id = (SELECT deck_id FROM note WHERE id = OLD.note_id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_card_update_note
+AFTER UPDATE OF note_id ON card
+FOR EACH ROW
+BEGIN
+    UPDATE deck
+    SET card_count = card_count - 1
+    WHERE id = (SELECT deck_id FROM note WHERE id = OLD.note_id);
+
+    UPDATE deck
+    SET card_count = card_count + 1
+    WHERE id = (SELECT deck_id FROM note WHERE id = NEW.note_id);
+END;
+
+CREATE TRIGGER update_card_updated_at
+AFTER UPDATE ON card
+WHEN old.updated_at <> current_timestamp
+BEGIN
+    UPDATE card
+    SET updated_at = CURRENT_TIMESTAMP
+    WHERE id = OLD.id;
+END;
+
+-- Moving a note to another deck moves its cards with it
+CREATE TRIGGER IF NOT EXISTS trg_note_update_deck
+AFTER UPDATE OF deck_id ON note
+FOR EACH ROW
+BEGIN
+    UPDATE deck
+    SET card_count = card_count - (SELECT COUNT(*) FROM card WHERE note_id = NEW.id)
+    WHERE id = OLD.deck_id;
+
+    UPDATE deck
+    SET card_count = card_count + (SELECT COUNT(*) FROM card WHERE note_id = NEW.id)
+    WHERE id = NEW.deck_id;
+END;
+
+-- Lapses at which a card becomes a leech, and what happens to it then
+ALTER TABLE deck_preset ADD COLUMN leech_threshold INTEGER CHECK (leech_threshold >= 1);
+ALTER TABLE deck_preset ADD COLUMN leech_action TEXT CHECK (leech_action IN ('tag', 'suspend', 'move'));
+
+-- Reviews that lapsed a review card. Earlier reviews are marked where Again
+-- followed a review that scheduled the card in days.
+ALTER TABLE review ADD COLUMN lapse BOOLEAN NOT NULL DEFAULT 0;
+
+UPDATE review
+SET lapse = 1
+WHERE id IN (
+    SELECT id
+    FROM (
+        SELECT id,
+               rating_id,
+               LAG(new_interval) OVER (PARTITION BY card_id ORDER BY review_time, id) AS previous_interval
+        FROM review
+        WHERE scheduled = 1
+    )
+    WHERE rating_id = '1'
+      AND previous_interval > 0
+);
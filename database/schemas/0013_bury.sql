@@ -0,0 +1,12 @@
+-- 0013_bury.sql
+
+-- A buried card is held out of study until buried_until, the start of the
+-- next day, without touching its scheduling. bury_kind says whether the user
+-- buried it or it was buried because a sibling card of its note was studied.
+ALTER TABLE card ADD COLUMN buried_until DATETIME;
+ALTER TABLE card ADD COLUMN bury_kind TEXT CHECK (bury_kind IN ('manual', 'sibling'));
+
+CREATE INDEX IF NOT EXISTS idx_card_buried_until ON card(buried_until);
+
+-- Presets choose whether studying a card buries its siblings. NULL buries them.
+ALTER TABLE deck_preset ADD COLUMN bury_siblings BOOLEAN;
@@ -0,0 +1,19 @@
+-- 0014_review_undo.sql
+
+-- A review keeps the state it replaced so that it can be undone: the card's
+-- scheduling before a scheduled review, and for reviews in a session the
+-- session card's progress before the answer. Reviews stored before this
+-- migration have no prior state and cannot be undone.
+ALTER TABLE review ADD COLUMN prior_status TEXT;
+ALTER TABLE review ADD COLUMN prior_due_date DATETIME;
+ALTER TABLE review ADD COLUMN prior_stability REAL;
+ALTER TABLE review ADD COLUMN prior_difficulty REAL;
+ALTER TABLE review ADD COLUMN prior_interval INTEGER;
+ALTER TABLE review ADD COLUMN prior_reps INTEGER;
+ALTER TABLE review ADD COLUMN prior_lapses INTEGER;
+ALTER TABLE review ADD COLUMN prior_step INTEGER;
+ALTER TABLE review ADD COLUMN prior_ease REAL;
+ALTER TABLE review ADD COLUMN prior_leeched_at DATETIME;
+ALTER TABLE review ADD COLUMN prior_session_card_status TEXT;
+ALTER TABLE review ADD COLUMN prior_cram_step INTEGER;
+ALTER TABLE review ADD COLUMN prior_next_cram_due DATETIME;
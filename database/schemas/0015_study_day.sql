@@ -0,0 +1,7 @@
+-- 0015_study_day.sql
+
+-- A user's study day starts at day_start_hour o'clock in their timezone, an
+-- IANA name such as 'Europe/Berlin'. The defaults keep the day starting at
+-- midnight UTC.
+ALTER TABLE user_setting ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+ALTER TABLE user_setting ADD COLUMN day_start_hour INTEGER NOT NULL DEFAULT 0 CHECK (day_start_hour BETWEEN 0 AND 23);
@@ -0,0 +1,7 @@
+-- 0016_card_ordinal.sql
+
+-- ordinal tells apart the cards one template makes for a note: the cloze
+-- number a cloze card tests, 0 for templates that make a single card.
+ALTER TABLE card ADD COLUMN ordinal INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_card_note_template ON card(note_id, card_template_id, ordinal);
@@ -0,0 +1,41 @@
+-- 0017_note_type_fields.sql
+
+-- The fields a note type's notes have, in the order they are edited. kind
+-- says what a field holds: plain text, rich HTML, or an image or audio file.
+-- The sort field is the one notes are listed and sorted by; a note type has
+-- at most one.
+CREATE TABLE IF NOT EXISTS note_type_field (
+    id           TEXT PRIMARY KEY DEFAULT (SUBSTR(LOWER(HEX(RANDOMBLOB(10))), 1, 10)),
+    note_type_id TEXT NOT NULL,
+    name         TEXT NOT NULL,
+    position     INTEGER NOT NULL,
+    kind         TEXT NOT NULL DEFAULT 'text' CHECK (kind IN ('text', 'html', 'image', 'audio')),
+    required     BOOLEAN NOT NULL DEFAULT FALSE,
+    sort_field   BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(note_type_id) REFERENCES note_type(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    UNIQUE (note_type_id, name),
+    UNIQUE (note_type_id, position)
+) WITHOUT ROWID;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_note_type_field_sort ON note_type_field(note_type_id) WHERE sort_field;
+
+-- Existing note types get the fields their notes use. The first field, which
+-- is required and the sort field, is Front or Text when there is one.
+INSERT INTO note_type_field (note_type_id, name, position, required, sort_field)
+SELECT note_type_id,
+       field_name,
+       ROW_NUMBER() OVER w - 1,
+       ROW_NUMBER() OVER w = 1,
+       ROW_NUMBER() OVER w = 1
+FROM (
+    SELECT DISTINCT n.note_type_id, nf.field_name
+    FROM note_field AS nf
+    JOIN note AS n ON nf.note_id = n.id
+    WHERE n.note_type_id IS NOT NULL
+)
+WINDOW w AS (
+    PARTITION BY note_type_id
+    ORDER BY CASE field_name WHEN 'Front' THEN 0 WHEN 'Text' THEN 0 WHEN 'Back' THEN 1 ELSE 2 END, field_name
+);
@@ -0,0 +1,22 @@
+-- 0018_card_template_versions.sql
+
+-- Every saved state of a card template, numbered from 1. A version is written
+-- whenever a template is created or changed, so a template can be rolled
+-- back to any earlier version.
+CREATE TABLE IF NOT EXISTS card_template_version (
+    id               TEXT PRIMARY KEY DEFAULT (SUBSTR(LOWER(HEX(RANDOMBLOB(10))), 1, 10)),
+    card_template_id TEXT NOT NULL,
+    version          INTEGER NOT NULL,
+    template_name    TEXT NOT NULL,
+    front_html       TEXT NOT NULL,
+    back_html        TEXT NOT NULL,
+    css              TEXT,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(card_template_id) REFERENCES card_template(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    UNIQUE (card_template_id, version)
+) WITHOUT ROWID;
+
+-- Existing templates start at version 1.
+INSERT INTO card_template_version (card_template_id, version, template_name, front_html, back_html, css)
+SELECT id, 1, template_name, front_html, back_html, css
+FROM card_template;
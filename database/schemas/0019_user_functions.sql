@@ -0,0 +1,17 @@
+-- 0019_user_functions.sql
+
+-- Template functions users write for their own card templates. params is
+-- the comma-separated list of parameter names; body is an expression in the
+-- script language, checked when the function is saved.
+CREATE TABLE IF NOT EXISTS user_function (
+    id           TEXT PRIMARY KEY DEFAULT (SUBSTR(LOWER(HEX(RANDOMBLOB(10))), 1, 10)),
+    owner_id     TEXT NOT NULL,
+    name         TEXT NOT NULL,
+    params       TEXT NOT NULL DEFAULT '',
+    output_type  TEXT NOT NULL DEFAULT 'string' CHECK (output_type IN ('string', 'html', 'boolean', 'number')),
+    body         TEXT NOT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(owner_id) REFERENCES user(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    UNIQUE (owner_id, name)
+) WITHOUT ROWID;
@@ -0,0 +1,57 @@
+-- 0020_template_conditions.sql
+
+-- condition_field names a field that must be filled in for a note to get a
+-- card from the template, as with an optional reverse card. Templates without
+-- one make cards for every note.
+ALTER TABLE card_template ADD COLUMN condition_field TEXT;
+ALTER TABLE card_template_version ADD COLUMN condition_field TEXT;
+
+-- Reverse notes were given a single front-to-back template, so they never got
+-- the reverse card they promise. Where that template is still the only one,
+-- add a back-to-front template for notes whose Back is filled in, rename the
+-- original to match, and make the reverse cards of existing notes.
+INSERT INTO card_template (note_type_id, template_name, front_html, back_html, css, owner_id, condition_field)
+SELECT t.note_type_id, 'Reverse Template', '{{.Back}}', '{{.Front}}', t.css, t.owner_id, 'Back'
+FROM card_template AS t
+JOIN note_type AS nt ON nt.id = t.note_type_id
+WHERE nt.name = 'Reverse Note'
+AND t.template_name = 'Reverse Template'
+AND t.front_html = '{{.Front}}'
+AND t.back_html = '{{.Back}}'
+AND (SELECT COUNT(*) FROM card_template AS o WHERE o.note_type_id = t.note_type_id) = 1;
+
+UPDATE card_template
+SET template_name = 'Forward Template'
+WHERE template_name = 'Reverse Template'
+AND condition_field IS NULL
+AND front_html = '{{.Front}}'
+AND note_type_id IN (
+    SELECT note_type_id FROM card_template
+    WHERE template_name = 'Reverse Template' AND condition_field = 'Back'
+);
+
+INSERT INTO card_template_version (card_template_id, version, template_name, front_html, back_html, css, condition_field)
+SELECT
+    t.id,
+    (SELECT COALESCE(MAX(v.version), 0) + 1 FROM card_template_version AS v WHERE v.card_template_id = t.id),
+    t.template_name,
+    t.front_html,
+    t.back_html,
+    t.css,
+    t.condition_field
+FROM card_template AS t
+WHERE (t.template_name = 'Reverse Template' AND t.condition_field = 'Back'
+       AND NOT EXISTS (SELECT 1 FROM card_template_version AS v WHERE v.card_template_id = t.id))
+OR (t.template_name = 'Forward Template'
+    AND NOT EXISTS (SELECT 1 FROM card_template_version AS v
+                    WHERE v.card_template_id = t.id AND v.template_name = 'Forward Template'));
+
+INSERT INTO card (note_id, card_template_id, due_date, status)
+SELECT n.id, t.id, CURRENT_TIMESTAMP, 'new'
+FROM card_template AS t
+JOIN note AS n ON n.note_type_id = t.note_type_id
+JOIN note_field AS f ON f.note_id = n.id AND f.field_name = 'Back'
+WHERE t.template_name = 'Reverse Template'
+AND t.condition_field = 'Back'
+AND TRIM(f.field_content) != ''
+AND NOT EXISTS (SELECT 1 FROM card AS c WHERE c.card_template_id = t.id AND c.note_id = n.id);
@@ -0,0 +1,5 @@
+-- 0021_typed_answers.sql
+
+-- typed_answer is what was typed for a card with a {{type:Field}} answer, or
+-- NULL when the card was answered without typing.
+ALTER TABLE review ADD COLUMN typed_answer TEXT;
@@ -0,0 +1,6 @@
+-- 0022_orphan_cards.sql
+
+-- orphaned_at is set when a card's template stops making it for its note, as
+-- when a cloze is removed or the template's condition field is emptied. The
+-- card is suspended rather than deleted so that its reviews are kept.
+ALTER TABLE card ADD COLUMN orphaned_at DATETIME;
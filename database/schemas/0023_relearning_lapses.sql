@@ -0,0 +1,23 @@
+-- 0023_relearning_lapses.sql
+
+-- 0012 marked every Again after a review with an interval as a lapse, which
+-- under SM-2 includes Again on a relearning step. Clear the marks on reviews
+-- that did not follow a review leaving the card in review state. Reviews since
+-- 0014 record their prior state and were marked when they were stored.
+UPDATE review
+SET lapse = 0
+WHERE lapse = 1
+  AND prior_status IS NULL
+  AND id IN (
+    SELECT id
+    FROM (
+        SELECT id,
+               LAG(new_interval) OVER w AS previous_interval,
+               LAG(JULIANDAY(new_due_date) - JULIANDAY(review_time)) OVER w AS previous_days
+        FROM review
+        WHERE scheduled = 1
+        WINDOW w AS (PARTITION BY card_id ORDER BY review_time, id)
+    )
+    WHERE previous_days IS NULL
+       OR previous_days <= previous_interval - 0.5
+);
@@ -0,0 +1,4 @@
+-- 0024_event_change_card_index.sql
+
+-- Reschedules look up the event changes of each card they replay.
+CREATE INDEX IF NOT EXISTS idx_user_event_change_card_id ON user_event_change(card_id, created_at);
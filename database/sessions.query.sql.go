@@ -8,26 +8,34 @@ package database
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 const addCardToSession = `-- name: AddCardToSession :one
 INSERT INTO session_card (
   session_id,
   card_id,
-  status
+  status,
+  position
 )
-VALUES (?, ?, ?)
-RETURNING id, session_id, card_id, status, next_cram_due, created_at, updated_at
+VALUES (?, ?, ?, ?)
+RETURNING id, session_id, card_id, status, next_cram_due, position, created_at, updated_at, cram_step
 `
 
 type AddCardToSessionParams struct {
 	SessionID string         `json:"session_id"`
 	CardID    string         `json:"card_id"`
 	Status    sql.NullString `json:"status"`
+	Position  int64          `json:"position"`
 }
 
 func (q *Queries) AddCardToSession(ctx context.Context, arg AddCardToSessionParams) (SessionCard, error) {
-	row := q.db.QueryRowContext(ctx, addCardToSession, arg.SessionID, arg.CardID, arg.Status)
+	row := q.db.QueryRowContext(ctx, addCardToSession,
+		arg.SessionID,
+		arg.CardID,
+		arg.Status,
+		arg.Position,
+	)
 	var i SessionCard
 	err := row.Scan(
 		&i.ID,
@@ -35,8 +43,10 @@ func (q *Queries) AddCardToSession(ctx context.Context, arg AddCardToSessionPara
 		&i.CardID,
 		&i.Status,
 		&i.NextCramDue,
+		&i.Position,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CramStep,
 	)
 	return i, err
 }
@@ -68,6 +78,124 @@ func (q *Queries) AddDeckToSession(ctx context.Context, arg AddDeckToSessionPara
 	return i, err
 }
 
+const averageReviewSecondsByOwner = `-- name: AverageReviewSecondsByOwner :one
+SELECT CAST(COALESCE(AVG(r.review_seconds), 0) AS REAL) AS average_seconds
+FROM review AS r
+JOIN card AS c ON r.card_id = c.id
+JOIN note AS n ON c.note_id = n.id
+WHERE n.owner_id = ?
+  AND r.review_seconds > 0
+`
+
+func (q *Queries) AverageReviewSecondsByOwner(ctx context.Context, ownerID string) (float64, error) {
+	row := q.db.QueryRowContext(ctx, averageReviewSecondsByOwner, ownerID)
+	var average_seconds float64
+	err := row.Scan(&average_seconds)
+	return average_seconds, err
+}
+
+const countNewCardsStudiedSince = `-- name: CountNewCardsStudiedSince :many
+SELECT n.deck_id,
+       COUNT(DISTINCT r.card_id) AS new_count
+FROM review AS r
+JOIN card AS c ON r.card_id = c.id
+JOIN note AS n ON c.note_id = n.id
+WHERE n.owner_id = ?
+  AND r.review_time >= ?
+  AND r.scheduled = 1
+  AND NOT EXISTS (
+    SELECT 1 FROM review AS p
+    WHERE p.card_id = r.card_id
+      AND p.review_time < ?
+      AND p.scheduled = 1
+  )
+GROUP BY n.deck_id
+`
+
+type CountNewCardsStudiedSinceParams struct {
+	OwnerID string    `json:"owner_id"`
+	Since   time.Time `json:"since"`
+}
+
+type CountNewCardsStudiedSinceRow struct {
+	DeckID   string `json:"deck_id"`
+	NewCount int64  `json:"new_count"`
+}
+
+func (q *Queries) CountNewCardsStudiedSince(ctx context.Context, arg CountNewCardsStudiedSinceParams) ([]CountNewCardsStudiedSinceRow, error) {
+	rows, err := q.db.QueryContext(ctx, countNewCardsStudiedSince, arg.OwnerID, arg.Since, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountNewCardsStudiedSinceRow
+	for rows.Next() {
+		var i CountNewCardsStudiedSinceRow
+		if err := rows.Scan(&i.DeckID, &i.NewCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countReviewCardsStudiedSince = `-- name: CountReviewCardsStudiedSince :many
+SELECT n.deck_id,
+       COUNT(DISTINCT r.card_id) AS review_count
+FROM review AS r
+JOIN card AS c ON r.card_id = c.id
+JOIN note AS n ON c.note_id = n.id
+WHERE n.owner_id = ?
+  AND r.review_time >= ?
+  AND r.scheduled = 1
+  AND EXISTS (
+    SELECT 1 FROM review AS p
+    WHERE p.card_id = r.card_id
+      AND p.review_time < ?
+      AND p.scheduled = 1
+  )
+GROUP BY n.deck_id
+`
+
+type CountReviewCardsStudiedSinceParams struct {
+	OwnerID string    `json:"owner_id"`
+	Since   time.Time `json:"since"`
+}
+
+type CountReviewCardsStudiedSinceRow struct {
+	DeckID      string `json:"deck_id"`
+	ReviewCount int64  `json:"review_count"`
+}
+
+func (q *Queries) CountReviewCardsStudiedSince(ctx context.Context, arg CountReviewCardsStudiedSinceParams) ([]CountReviewCardsStudiedSinceRow, error) {
+	rows, err := q.db.QueryContext(ctx, countReviewCardsStudiedSince, arg.OwnerID, arg.Since, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountReviewCardsStudiedSinceRow
+	for rows.Next() {
+		var i CountReviewCardsStudiedSinceRow
+		if err := rows.Scan(&i.DeckID, &i.ReviewCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createRatingEntry = `-- name: CreateRatingEntry :one
 INSERT INTO rating (name)
 VALUES (?)
@@ -84,32 +212,67 @@ func (q *Queries) CreateRatingEntry(ctx context.Context, name string) (Rating, e
 const createReview = `-- name: CreateReview :one
 INSERT INTO review (
   card_id,
+  review_time,
   rating_id,
   review_seconds,
   new_interval,
   new_stability,
   new_difficulty,
   new_due_date,
-  session_id
+  session_id,
+  scheduled,
+  lapse,
+  prior_status,
+  prior_due_date,
+  prior_stability,
+  prior_difficulty,
+  prior_interval,
+  prior_reps,
+  prior_lapses,
+  prior_step,
+  prior_ease,
+  prior_leeched_at,
+  prior_session_card_status,
+  prior_cram_step,
+  prior_next_cram_due,
+  typed_answer
 )
-VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-RETURNING id, card_id, review_time, rating_id, review_seconds, new_interval, new_stability, new_difficulty, new_due_date, session_id, created_at, updated_at
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, card_id, review_time, rating_id, review_seconds, new_interval, new_stability, new_difficulty, new_due_date, session_id, created_at, updated_at, scheduled, lapse, prior_status, prior_due_date, prior_stability, prior_difficulty, prior_interval, prior_reps, prior_lapses, prior_step, prior_ease, prior_leeched_at, prior_session_card_status, prior_cram_step, prior_next_cram_due, typed_answer
 `
 
 type CreateReviewParams struct {
-	CardID        string          `json:"card_id"`
-	RatingID      sql.NullString  `json:"rating_id"`
-	ReviewSeconds sql.NullInt64   `json:"review_seconds"`
-	NewInterval   sql.NullInt64   `json:"new_interval"`
-	NewStability  sql.NullFloat64 `json:"new_stability"`
-	NewDifficulty sql.NullFloat64 `json:"new_difficulty"`
-	NewDueDate    sql.NullTime    `json:"new_due_date"`
-	SessionID     sql.NullString  `json:"session_id"`
+	CardID                 string          `json:"card_id"`
+	ReviewTime             time.Time       `json:"review_time"`
+	RatingID               sql.NullString  `json:"rating_id"`
+	ReviewSeconds          sql.NullInt64   `json:"review_seconds"`
+	NewInterval            sql.NullInt64   `json:"new_interval"`
+	NewStability           sql.NullFloat64 `json:"new_stability"`
+	NewDifficulty          sql.NullFloat64 `json:"new_difficulty"`
+	NewDueDate             sql.NullTime    `json:"new_due_date"`
+	SessionID              sql.NullString  `json:"session_id"`
+	Scheduled              bool            `json:"scheduled"`
+	Lapse                  bool            `json:"lapse"`
+	PriorStatus            sql.NullString  `json:"prior_status"`
+	PriorDueDate           sql.NullTime    `json:"prior_due_date"`
+	PriorStability         sql.NullFloat64 `json:"prior_stability"`
+	PriorDifficulty        sql.NullFloat64 `json:"prior_difficulty"`
+	PriorInterval          sql.NullInt64   `json:"prior_interval"`
+	PriorReps              sql.NullInt64   `json:"prior_reps"`
+	PriorLapses            sql.NullInt64   `json:"prior_lapses"`
+	PriorStep              sql.NullInt64   `json:"prior_step"`
+	PriorEase              sql.NullFloat64 `json:"prior_ease"`
+	PriorLeechedAt         sql.NullTime    `json:"prior_leeched_at"`
+	PriorSessionCardStatus sql.NullString  `json:"prior_session_card_status"`
+	PriorCramStep          sql.NullInt64   `json:"prior_cram_step"`
+	PriorNextCramDue       sql.NullTime    `json:"prior_next_cram_due"`
+	TypedAnswer            sql.NullString  `json:"typed_answer"`
 }
 
 func (q *Queries) CreateReview(ctx context.Context, arg CreateReviewParams) (Review, error) {
 	row := q.db.QueryRowContext(ctx, createReview,
 		arg.CardID,
+		arg.ReviewTime,
 		arg.RatingID,
 		arg.ReviewSeconds,
 		arg.NewInterval,
@@ -117,6 +280,22 @@ func (q *Queries) CreateReview(ctx context.Context, arg CreateReviewParams) (Rev
 		arg.NewDifficulty,
 		arg.NewDueDate,
 		arg.SessionID,
+		arg.Scheduled,
+		arg.Lapse,
+		arg.PriorStatus,
+		arg.PriorDueDate,
+		arg.PriorStability,
+		arg.PriorDifficulty,
+		arg.PriorInterval,
+		arg.PriorReps,
+		arg.PriorLapses,
+		arg.PriorStep,
+		arg.PriorEase,
+		arg.PriorLeechedAt,
+		arg.PriorSessionCardStatus,
+		arg.PriorCramStep,
+		arg.PriorNextCramDue,
+		arg.TypedAnswer,
 	)
 	var i Review
 	err := row.Scan(
@@ -132,6 +311,22 @@ func (q *Queries) CreateReview(ctx context.Context, arg CreateReviewParams) (Rev
 		&i.SessionID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Scheduled,
+		&i.Lapse,
+		&i.PriorStatus,
+		&i.PriorDueDate,
+		&i.PriorStability,
+		&i.PriorDifficulty,
+		&i.PriorInterval,
+		&i.PriorReps,
+		&i.PriorLapses,
+		&i.PriorStep,
+		&i.PriorEase,
+		&i.PriorLeechedAt,
+		&i.PriorSessionCardStatus,
+		&i.PriorCramStep,
+		&i.PriorNextCramDue,
+		&i.TypedAnswer,
 	)
 	return i, err
 }
@@ -141,17 +336,17 @@ INSERT INTO session (
   user_id,
   mode,
   name,
-  session_deck_id
+  reschedule
 )
 VALUES (?, ?, ?, ?)
-RETURNING id, user_id, mode, name, start_time, end_time, is_active, session_deck_id, created_at, updated_at
+RETURNING id, user_id, mode, name, start_time, end_time, is_active, paused_at, paused_seconds, created_at, updated_at, reschedule
 `
 
 type CreateSessionParams struct {
-	UserID        string         `json:"user_id"`
-	Mode          sql.NullString `json:"mode"`
-	Name          sql.NullString `json:"name"`
-	SessionDeckID string         `json:"session_deck_id"`
+	UserID     string         `json:"user_id"`
+	Mode       sql.NullString `json:"mode"`
+	Name       sql.NullString `json:"name"`
+	Reschedule bool           `json:"reschedule"`
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
@@ -159,7 +354,7 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		arg.UserID,
 		arg.Mode,
 		arg.Name,
-		arg.SessionDeckID,
+		arg.Reschedule,
 	)
 	var i Session
 	err := row.Scan(
@@ -170,9 +365,11 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.StartTime,
 		&i.EndTime,
 		&i.IsActive,
-		&i.SessionDeckID,
+		&i.PausedAt,
+		&i.PausedSeconds,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Reschedule,
 	)
 	return i, err
 }
@@ -197,8 +394,133 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 	return err
 }
 
+const endSession = `-- name: EndSession :one
+UPDATE session
+SET
+  end_time = ?,
+  is_active = 0,
+  paused_at = NULL,
+  paused_seconds = paused_seconds + ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, user_id, mode, name, start_time, end_time, is_active, paused_at, paused_seconds, created_at, updated_at, reschedule
+`
+
+type EndSessionParams struct {
+	EndTime       sql.NullTime `json:"end_time"`
+	PausedSeconds int64        `json:"paused_seconds"`
+	ID            string       `json:"id"`
+}
+
+func (q *Queries) EndSession(ctx context.Context, arg EndSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, endSession, arg.EndTime, arg.PausedSeconds, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Mode,
+		&i.Name,
+		&i.StartTime,
+		&i.EndTime,
+		&i.IsActive,
+		&i.PausedAt,
+		&i.PausedSeconds,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Reschedule,
+	)
+	return i, err
+}
+
+const getLatestReviewByCard = `-- name: GetLatestReviewByCard :one
+SELECT id, card_id, review_time, rating_id, review_seconds, new_interval, new_stability, new_difficulty, new_due_date, session_id, created_at, updated_at, scheduled, lapse, prior_status, prior_due_date, prior_stability, prior_difficulty, prior_interval, prior_reps, prior_lapses, prior_step, prior_ease, prior_leeched_at, prior_session_card_status, prior_cram_step, prior_next_cram_due, typed_answer FROM review
+WHERE card_id = ?
+  AND scheduled = 1
+ORDER BY review_time DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestReviewByCard(ctx context.Context, cardID string) (Review, error) {
+	row := q.db.QueryRowContext(ctx, getLatestReviewByCard, cardID)
+	var i Review
+	err := row.Scan(
+		&i.ID,
+		&i.CardID,
+		&i.ReviewTime,
+		&i.RatingID,
+		&i.ReviewSeconds,
+		&i.NewInterval,
+		&i.NewStability,
+		&i.NewDifficulty,
+		&i.NewDueDate,
+		&i.SessionID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Scheduled,
+		&i.Lapse,
+		&i.PriorStatus,
+		&i.PriorDueDate,
+		&i.PriorStability,
+		&i.PriorDifficulty,
+		&i.PriorInterval,
+		&i.PriorReps,
+		&i.PriorLapses,
+		&i.PriorStep,
+		&i.PriorEase,
+		&i.PriorLeechedAt,
+		&i.PriorSessionCardStatus,
+		&i.PriorCramStep,
+		&i.PriorNextCramDue,
+		&i.TypedAnswer,
+	)
+	return i, err
+}
+
+const getLatestReviewBySession = `-- name: GetLatestReviewBySession :one
+SELECT id, card_id, review_time, rating_id, review_seconds, new_interval, new_stability, new_difficulty, new_due_date, session_id, created_at, updated_at, scheduled, lapse, prior_status, prior_due_date, prior_stability, prior_difficulty, prior_interval, prior_reps, prior_lapses, prior_step, prior_ease, prior_leeched_at, prior_session_card_status, prior_cram_step, prior_next_cram_due, typed_answer FROM review
+WHERE session_id = ?
+ORDER BY review_time DESC, created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestReviewBySession(ctx context.Context, sessionID sql.NullString) (Review, error) {
+	row := q.db.QueryRowContext(ctx, getLatestReviewBySession, sessionID)
+	var i Review
+	err := row.Scan(
+		&i.ID,
+		&i.CardID,
+		&i.ReviewTime,
+		&i.RatingID,
+		&i.ReviewSeconds,
+		&i.NewInterval,
+		&i.NewStability,
+		&i.NewDifficulty,
+		&i.NewDueDate,
+		&i.SessionID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Scheduled,
+		&i.Lapse,
+		&i.PriorStatus,
+		&i.PriorDueDate,
+		&i.PriorStability,
+		&i.PriorDifficulty,
+		&i.PriorInterval,
+		&i.PriorReps,
+		&i.PriorLapses,
+		&i.PriorStep,
+		&i.PriorEase,
+		&i.PriorLeechedAt,
+		&i.PriorSessionCardStatus,
+		&i.PriorCramStep,
+		&i.PriorNextCramDue,
+		&i.TypedAnswer,
+	)
+	return i, err
+}
+
 const getReview = `-- name: GetReview :one
-SELECT id, card_id, review_time, rating_id, review_seconds, new_interval, new_stability, new_difficulty, new_due_date, session_id, created_at, updated_at FROM review
+SELECT id, card_id, review_time, rating_id, review_seconds, new_interval, new_stability, new_difficulty, new_due_date, session_id, created_at, updated_at, scheduled, lapse, prior_status, prior_due_date, prior_stability, prior_difficulty, prior_interval, prior_reps, prior_lapses, prior_step, prior_ease, prior_leeched_at, prior_session_card_status, prior_cram_step, prior_next_cram_due, typed_answer FROM review
 WHERE id = ?
 LIMIT 1
 `
@@ -219,12 +541,28 @@ func (q *Queries) GetReview(ctx context.Context, id string) (Review, error) {
 		&i.SessionID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Scheduled,
+		&i.Lapse,
+		&i.PriorStatus,
+		&i.PriorDueDate,
+		&i.PriorStability,
+		&i.PriorDifficulty,
+		&i.PriorInterval,
+		&i.PriorReps,
+		&i.PriorLapses,
+		&i.PriorStep,
+		&i.PriorEase,
+		&i.PriorLeechedAt,
+		&i.PriorSessionCardStatus,
+		&i.PriorCramStep,
+		&i.PriorNextCramDue,
+		&i.TypedAnswer,
 	)
 	return i, err
 }
 
 const getSession = `-- name: GetSession :one
-SELECT id, user_id, mode, name, start_time, end_time, is_active, session_deck_id, created_at, updated_at FROM session
+SELECT id, user_id, mode, name, start_time, end_time, is_active, paused_at, paused_seconds, created_at, updated_at, reschedule FROM session
 WHERE id = ?
 LIMIT 1
 `
@@ -240,13 +578,84 @@ func (q *Queries) GetSession(ctx context.Context, id string) (Session, error) {
 		&i.StartTime,
 		&i.EndTime,
 		&i.IsActive,
-		&i.SessionDeckID,
+		&i.PausedAt,
+		&i.PausedSeconds,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Reschedule,
 	)
 	return i, err
 }
 
+const getSessionCard = `-- name: GetSessionCard :one
+SELECT id, session_id, card_id, status, next_cram_due, position, created_at, updated_at, cram_step FROM session_card
+WHERE session_id = ?
+  AND card_id = ?
+LIMIT 1
+`
+
+type GetSessionCardParams struct {
+	SessionID string `json:"session_id"`
+	CardID    string `json:"card_id"`
+}
+
+func (q *Queries) GetSessionCard(ctx context.Context, arg GetSessionCardParams) (SessionCard, error) {
+	row := q.db.QueryRowContext(ctx, getSessionCard, arg.SessionID, arg.CardID)
+	var i SessionCard
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.CardID,
+		&i.Status,
+		&i.NextCramDue,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CramStep,
+	)
+	return i, err
+}
+
+const listLeechLapsesByOwner = `-- name: ListLeechLapsesByOwner :many
+SELECT r.card_id,
+       r.review_time
+FROM review AS r
+JOIN card AS c ON r.card_id = c.id
+JOIN note AS n ON c.note_id = n.id
+WHERE n.owner_id = ?
+  AND c.leeched_at IS NOT NULL
+  AND r.lapse = 1
+ORDER BY r.card_id, r.review_time
+`
+
+type ListLeechLapsesByOwnerRow struct {
+	CardID     string    `json:"card_id"`
+	ReviewTime time.Time `json:"review_time"`
+}
+
+func (q *Queries) ListLeechLapsesByOwner(ctx context.Context, ownerID string) ([]ListLeechLapsesByOwnerRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLeechLapsesByOwner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLeechLapsesByOwnerRow
+	for rows.Next() {
+		var i ListLeechLapsesByOwnerRow
+		if err := rows.Scan(&i.CardID, &i.ReviewTime); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listRatings = `-- name: ListRatings :many
 SELECT id, name FROM rating
 ORDER BY id
@@ -275,8 +684,132 @@ func (q *Queries) ListRatings(ctx context.Context) ([]Rating, error) {
 	return items, nil
 }
 
+const listReviewLogByDeck = `-- name: ListReviewLogByDeck :many
+SELECT r.card_id,
+       r.review_time,
+       r.rating_id
+FROM review AS r
+JOIN card AS c ON r.card_id = c.id
+JOIN note AS n ON c.note_id = n.id
+WHERE n.deck_id = ?
+  AND r.scheduled = 1
+ORDER BY r.card_id, r.review_time
+`
+
+type ListReviewLogByDeckRow struct {
+	CardID     string         `json:"card_id"`
+	ReviewTime time.Time      `json:"review_time"`
+	RatingID   sql.NullString `json:"rating_id"`
+}
+
+func (q *Queries) ListReviewLogByDeck(ctx context.Context, deckID string) ([]ListReviewLogByDeckRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReviewLogByDeck, deckID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReviewLogByDeckRow
+	for rows.Next() {
+		var i ListReviewLogByDeckRow
+		if err := rows.Scan(&i.CardID, &i.ReviewTime, &i.RatingID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReviewLogByOwner = `-- name: ListReviewLogByOwner :many
+SELECT r.card_id,
+       r.review_time,
+       r.rating_id
+FROM review AS r
+JOIN card AS c ON r.card_id = c.id
+JOIN note AS n ON c.note_id = n.id
+WHERE n.owner_id = ?
+  AND r.scheduled = 1
+ORDER BY r.card_id, r.review_time
+`
+
+type ListReviewLogByOwnerRow struct {
+	CardID     string         `json:"card_id"`
+	ReviewTime time.Time      `json:"review_time"`
+	RatingID   sql.NullString `json:"rating_id"`
+}
+
+func (q *Queries) ListReviewLogByOwner(ctx context.Context, ownerID string) ([]ListReviewLogByOwnerRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReviewLogByOwner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReviewLogByOwnerRow
+	for rows.Next() {
+		var i ListReviewLogByOwnerRow
+		if err := rows.Scan(&i.CardID, &i.ReviewTime, &i.RatingID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReviewLogByPreset = `-- name: ListReviewLogByPreset :many
+SELECT r.card_id,
+       r.review_time,
+       r.rating_id
+FROM review AS r
+JOIN card AS c ON r.card_id = c.id
+JOIN note AS n ON c.note_id = n.id
+JOIN deck AS d ON n.deck_id = d.id
+WHERE d.preset_id = ?
+  AND r.scheduled = 1
+ORDER BY r.card_id, r.review_time
+`
+
+type ListReviewLogByPresetRow struct {
+	CardID     string         `json:"card_id"`
+	ReviewTime time.Time      `json:"review_time"`
+	RatingID   sql.NullString `json:"rating_id"`
+}
+
+func (q *Queries) ListReviewLogByPreset(ctx context.Context, presetID sql.NullString) ([]ListReviewLogByPresetRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReviewLogByPreset, presetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReviewLogByPresetRow
+	for rows.Next() {
+		var i ListReviewLogByPresetRow
+		if err := rows.Scan(&i.CardID, &i.ReviewTime, &i.RatingID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listReviewsByCard = `-- name: ListReviewsByCard :many
-SELECT id, card_id, review_time, rating_id, review_seconds, new_interval, new_stability, new_difficulty, new_due_date, session_id, created_at, updated_at FROM review
+SELECT id, card_id, review_time, rating_id, review_seconds, new_interval, new_stability, new_difficulty, new_due_date, session_id, created_at, updated_at, scheduled, lapse, prior_status, prior_due_date, prior_stability, prior_difficulty, prior_interval, prior_reps, prior_lapses, prior_step, prior_ease, prior_leeched_at, prior_session_card_status, prior_cram_step, prior_next_cram_due, typed_answer FROM review
 WHERE card_id = ?
 ORDER BY id
 `
@@ -303,6 +836,81 @@ func (q *Queries) ListReviewsByCard(ctx context.Context, cardID string) ([]Revie
 			&i.SessionID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Scheduled,
+			&i.Lapse,
+			&i.PriorStatus,
+			&i.PriorDueDate,
+			&i.PriorStability,
+			&i.PriorDifficulty,
+			&i.PriorInterval,
+			&i.PriorReps,
+			&i.PriorLapses,
+			&i.PriorStep,
+			&i.PriorEase,
+			&i.PriorLeechedAt,
+			&i.PriorSessionCardStatus,
+			&i.PriorCramStep,
+			&i.PriorNextCramDue,
+			&i.TypedAnswer,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScheduledReviewsByCard = `-- name: ListScheduledReviewsByCard :many
+SELECT id, card_id, review_time, rating_id, review_seconds, new_interval, new_stability, new_difficulty, new_due_date, session_id, created_at, updated_at, scheduled, lapse, prior_status, prior_due_date, prior_stability, prior_difficulty, prior_interval, prior_reps, prior_lapses, prior_step, prior_ease, prior_leeched_at, prior_session_card_status, prior_cram_step, prior_next_cram_due, typed_answer FROM review
+WHERE card_id = ?
+  AND scheduled = 1
+ORDER BY review_time, id
+`
+
+func (q *Queries) ListScheduledReviewsByCard(ctx context.Context, cardID string) ([]Review, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduledReviewsByCard, cardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Review
+	for rows.Next() {
+		var i Review
+		if err := rows.Scan(
+			&i.ID,
+			&i.CardID,
+			&i.ReviewTime,
+			&i.RatingID,
+			&i.ReviewSeconds,
+			&i.NewInterval,
+			&i.NewStability,
+			&i.NewDifficulty,
+			&i.NewDueDate,
+			&i.SessionID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Scheduled,
+			&i.Lapse,
+			&i.PriorStatus,
+			&i.PriorDueDate,
+			&i.PriorStability,
+			&i.PriorDifficulty,
+			&i.PriorInterval,
+			&i.PriorReps,
+			&i.PriorLapses,
+			&i.PriorStep,
+			&i.PriorEase,
+			&i.PriorLeechedAt,
+			&i.PriorSessionCardStatus,
+			&i.PriorCramStep,
+			&i.PriorNextCramDue,
+			&i.TypedAnswer,
 		); err != nil {
 			return nil, err
 		}
@@ -318,7 +926,7 @@ func (q *Queries) ListReviewsByCard(ctx context.Context, cardID string) ([]Revie
 }
 
 const listSessionCards = `-- name: ListSessionCards :many
-SELECT id, session_id, card_id, status, next_cram_due, created_at, updated_at FROM session_card
+SELECT id, session_id, card_id, status, next_cram_due, position, created_at, updated_at, cram_step FROM session_card
 WHERE session_id = ?
 ORDER BY id
 `
@@ -338,8 +946,10 @@ func (q *Queries) ListSessionCards(ctx context.Context, sessionID string) ([]Ses
 			&i.CardID,
 			&i.Status,
 			&i.NextCramDue,
+			&i.Position,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CramStep,
 		); err != nil {
 			return nil, err
 		}
@@ -389,6 +999,145 @@ func (q *Queries) ListSessionDecks(ctx context.Context, sessionID string) ([]Ses
 	return items, nil
 }
 
+const listSessionQueue = `-- name: ListSessionQueue :many
+SELECT sc.card_id,
+       sc.status,
+       sc.position,
+       sc.next_cram_due,
+       sc.cram_step,
+       c.status AS card_status,
+       c.due_date
+FROM session_card AS sc
+JOIN card AS c ON sc.card_id = c.id
+WHERE sc.session_id = ?
+  AND sc.status <> 'done'
+  AND c.status IS NOT 'suspended'
+  AND (c.buried_until IS NULL OR c.buried_until <= ?)
+ORDER BY sc.position
+`
+
+type ListSessionQueueParams struct {
+	SessionID string       `json:"session_id"`
+	Now       sql.NullTime `json:"now"`
+}
+
+type ListSessionQueueRow struct {
+	CardID      string         `json:"card_id"`
+	Status      sql.NullString `json:"status"`
+	Position    int64          `json:"position"`
+	NextCramDue sql.NullTime   `json:"next_cram_due"`
+	CramStep    int64          `json:"cram_step"`
+	CardStatus  sql.NullString `json:"card_status"`
+	DueDate     sql.NullTime   `json:"due_date"`
+}
+
+func (q *Queries) ListSessionQueue(ctx context.Context, arg ListSessionQueueParams) ([]ListSessionQueueRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSessionQueue, arg.SessionID, arg.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSessionQueueRow
+	for rows.Next() {
+		var i ListSessionQueueRow
+		if err := rows.Scan(
+			&i.CardID,
+			&i.Status,
+			&i.Position,
+			&i.NextCramDue,
+			&i.CramStep,
+			&i.CardStatus,
+			&i.DueDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSessionsByUser = `-- name: ListSessionsByUser :many
+SELECT id, user_id, mode, name, start_time, end_time, is_active, paused_at, paused_seconds, created_at, updated_at, reschedule FROM session
+WHERE user_id = ?
+ORDER BY start_time DESC
+`
+
+func (q *Queries) ListSessionsByUser(ctx context.Context, userID string) ([]Session, error) {
+	rows, err := q.db.QueryContext(ctx, listSessionsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Mode,
+			&i.Name,
+			&i.StartTime,
+			&i.EndTime,
+			&i.IsActive,
+			&i.PausedAt,
+			&i.PausedSeconds,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Reschedule,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const pauseSession = `-- name: PauseSession :one
+UPDATE session
+SET
+  paused_at = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, user_id, mode, name, start_time, end_time, is_active, paused_at, paused_seconds, created_at, updated_at, reschedule
+`
+
+type PauseSessionParams struct {
+	PausedAt sql.NullTime `json:"paused_at"`
+	ID       string       `json:"id"`
+}
+
+func (q *Queries) PauseSession(ctx context.Context, arg PauseSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, pauseSession, arg.PausedAt, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Mode,
+		&i.Name,
+		&i.StartTime,
+		&i.EndTime,
+		&i.IsActive,
+		&i.PausedAt,
+		&i.PausedSeconds,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Reschedule,
+	)
+	return i, err
+}
+
 const removeSessionCard = `-- name: RemoveSessionCard :exec
 DELETE FROM session_card
 WHERE id = ?
@@ -409,6 +1158,80 @@ func (q *Queries) RemoveSessionDeck(ctx context.Context, id string) error {
 	return err
 }
 
+const resumeSession = `-- name: ResumeSession :one
+UPDATE session
+SET
+  paused_at = NULL,
+  paused_seconds = paused_seconds + ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, user_id, mode, name, start_time, end_time, is_active, paused_at, paused_seconds, created_at, updated_at, reschedule
+`
+
+type ResumeSessionParams struct {
+	PausedSeconds int64  `json:"paused_seconds"`
+	ID            string `json:"id"`
+}
+
+func (q *Queries) ResumeSession(ctx context.Context, arg ResumeSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, resumeSession, arg.PausedSeconds, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Mode,
+		&i.Name,
+		&i.StartTime,
+		&i.EndTime,
+		&i.IsActive,
+		&i.PausedAt,
+		&i.PausedSeconds,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Reschedule,
+	)
+	return i, err
+}
+
+const summarizeSessionReviews = `-- name: SummarizeSessionReviews :many
+SELECT rating_id,
+       COUNT(*) AS review_count,
+       CAST(COALESCE(SUM(review_seconds), 0) AS INTEGER) AS review_seconds
+FROM review
+WHERE session_id = ?
+GROUP BY rating_id
+ORDER BY rating_id
+`
+
+type SummarizeSessionReviewsRow struct {
+	RatingID      sql.NullString `json:"rating_id"`
+	ReviewCount   int64          `json:"review_count"`
+	ReviewSeconds int64          `json:"review_seconds"`
+}
+
+func (q *Queries) SummarizeSessionReviews(ctx context.Context, sessionID sql.NullString) ([]SummarizeSessionReviewsRow, error) {
+	rows, err := q.db.QueryContext(ctx, summarizeSessionReviews, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SummarizeSessionReviewsRow
+	for rows.Next() {
+		var i SummarizeSessionReviewsRow
+		if err := rows.Scan(&i.RatingID, &i.ReviewCount, &i.ReviewSeconds); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateSession = `-- name: UpdateSession :one
 UPDATE session
 SET
@@ -418,7 +1241,7 @@ SET
   is_active = ?,
   updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, user_id, mode, name, start_time, end_time, is_active, session_deck_id, created_at, updated_at
+RETURNING id, user_id, mode, name, start_time, end_time, is_active, paused_at, paused_seconds, created_at, updated_at, reschedule
 `
 
 type UpdateSessionParams struct {
@@ -446,9 +1269,11 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.StartTime,
 		&i.EndTime,
 		&i.IsActive,
-		&i.SessionDeckID,
+		&i.PausedAt,
+		&i.PausedSeconds,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Reschedule,
 	)
 	return i, err
 }
@@ -458,15 +1283,17 @@ UPDATE session_card
 SET
   status = ?,
   next_cram_due = ?,
+  cram_step = ?,
   updated_at = CURRENT_TIMESTAMP
 WHERE session_id = ?
   AND card_id = ?
-RETURNING id, session_id, card_id, status, next_cram_due, created_at, updated_at
+RETURNING id, session_id, card_id, status, next_cram_due, position, created_at, updated_at, cram_step
 `
 
 type UpdateSessionCardParams struct {
 	Status      sql.NullString `json:"status"`
 	NextCramDue sql.NullTime   `json:"next_cram_due"`
+	CramStep    int64          `json:"cram_step"`
 	SessionID   string         `json:"session_id"`
 	CardID      string         `json:"card_id"`
 }
@@ -475,6 +1302,7 @@ func (q *Queries) UpdateSessionCard(ctx context.Context, arg UpdateSessionCardPa
 	row := q.db.QueryRowContext(ctx, updateSessionCard,
 		arg.Status,
 		arg.NextCramDue,
+		arg.CramStep,
 		arg.SessionID,
 		arg.CardID,
 	)
@@ -485,8 +1313,10 @@ func (q *Queries) UpdateSessionCard(ctx context.Context, arg UpdateSessionCardPa
 		&i.CardID,
 		&i.Status,
 		&i.NextCramDue,
+		&i.Position,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CramStep,
 	)
 	return i, err
 }
@@ -84,7 +84,7 @@ INSERT INTO user_setting (
   tutorial_enabled
 )
 VALUES (?, ?, ?, ?, ?)
-RETURNING id, user_id, theme, daily_new_cards_limit, notifications_enabled, tutorial_enabled, created_at, updated_at
+RETURNING id, user_id, theme, daily_new_cards_limit, notifications_enabled, tutorial_enabled, created_at, updated_at, fsrs_weights, desired_retention, maximum_interval, daily_review_limit, learning_steps, relearning_steps, interval_fuzz, load_balance, timezone, day_start_hour
 `
 
 type CreateUserSettingParams struct {
@@ -113,6 +113,16 @@ func (q *Queries) CreateUserSetting(ctx context.Context, arg CreateUserSettingPa
 		&i.TutorialEnabled,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.FsrsWeights,
+		&i.DesiredRetention,
+		&i.MaximumInterval,
+		&i.DailyReviewLimit,
+		&i.LearningSteps,
+		&i.RelearningSteps,
+		&i.IntervalFuzz,
+		&i.LoadBalance,
+		&i.Timezone,
+		&i.DayStartHour,
 	)
 	return i, err
 }
@@ -206,7 +216,7 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 }
 
 const getUserSetting = `-- name: GetUserSetting :one
-SELECT id, user_id, theme, daily_new_cards_limit, notifications_enabled, tutorial_enabled, created_at, updated_at FROM user_setting
+SELECT id, user_id, theme, daily_new_cards_limit, notifications_enabled, tutorial_enabled, created_at, updated_at, fsrs_weights, desired_retention, maximum_interval, daily_review_limit, learning_steps, relearning_steps, interval_fuzz, load_balance, timezone, day_start_hour FROM user_setting
 WHERE user_id = ?
 LIMIT 1
 `
@@ -223,6 +233,16 @@ func (q *Queries) GetUserSetting(ctx context.Context, userID string) (UserSettin
 		&i.TutorialEnabled,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.FsrsWeights,
+		&i.DesiredRetention,
+		&i.MaximumInterval,
+		&i.DailyReviewLimit,
+		&i.LearningSteps,
+		&i.RelearningSteps,
+		&i.IntervalFuzz,
+		&i.LoadBalance,
+		&i.Timezone,
+		&i.DayStartHour,
 	)
 	return i, err
 }
@@ -304,6 +324,94 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 	return i, err
 }
 
+const updateUserFSRSWeights = `-- name: UpdateUserFSRSWeights :exec
+UPDATE user_setting
+SET
+  fsrs_weights = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE user_id = ?
+`
+
+type UpdateUserFSRSWeightsParams struct {
+	FsrsWeights sql.NullString `json:"fsrs_weights"`
+	UserID      string         `json:"user_id"`
+}
+
+func (q *Queries) UpdateUserFSRSWeights(ctx context.Context, arg UpdateUserFSRSWeightsParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserFSRSWeights, arg.FsrsWeights, arg.UserID)
+	return err
+}
+
+const updateUserSchedulerSetting = `-- name: UpdateUserSchedulerSetting :one
+UPDATE user_setting
+SET
+  desired_retention = ?,
+  maximum_interval = ?,
+  daily_new_cards_limit = ?,
+  daily_review_limit = ?,
+  learning_steps = ?,
+  relearning_steps = ?,
+  interval_fuzz = ?,
+  load_balance = ?,
+  timezone = ?,
+  day_start_hour = ?,
+  updated_at = CURRENT_TIMESTAMP
+WHERE user_id = ?
+RETURNING id, user_id, theme, daily_new_cards_limit, notifications_enabled, tutorial_enabled, created_at, updated_at, fsrs_weights, desired_retention, maximum_interval, daily_review_limit, learning_steps, relearning_steps, interval_fuzz, load_balance, timezone, day_start_hour
+`
+
+type UpdateUserSchedulerSettingParams struct {
+	DesiredRetention   float64 `json:"desired_retention"`
+	MaximumInterval    int64   `json:"maximum_interval"`
+	DailyNewCardsLimit int64   `json:"daily_new_cards_limit"`
+	DailyReviewLimit   int64   `json:"daily_review_limit"`
+	LearningSteps      string  `json:"learning_steps"`
+	RelearningSteps    string  `json:"relearning_steps"`
+	IntervalFuzz       bool    `json:"interval_fuzz"`
+	LoadBalance        bool    `json:"load_balance"`
+	Timezone           string  `json:"timezone"`
+	DayStartHour       int64   `json:"day_start_hour"`
+	UserID             string  `json:"user_id"`
+}
+
+func (q *Queries) UpdateUserSchedulerSetting(ctx context.Context, arg UpdateUserSchedulerSettingParams) (UserSetting, error) {
+	row := q.db.QueryRowContext(ctx, updateUserSchedulerSetting,
+		arg.DesiredRetention,
+		arg.MaximumInterval,
+		arg.DailyNewCardsLimit,
+		arg.DailyReviewLimit,
+		arg.LearningSteps,
+		arg.RelearningSteps,
+		arg.IntervalFuzz,
+		arg.LoadBalance,
+		arg.Timezone,
+		arg.DayStartHour,
+		arg.UserID,
+	)
+	var i UserSetting
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Theme,
+		&i.DailyNewCardsLimit,
+		&i.NotificationsEnabled,
+		&i.TutorialEnabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FsrsWeights,
+		&i.DesiredRetention,
+		&i.MaximumInterval,
+		&i.DailyReviewLimit,
+		&i.LearningSteps,
+		&i.RelearningSteps,
+		&i.IntervalFuzz,
+		&i.LoadBalance,
+		&i.Timezone,
+		&i.DayStartHour,
+	)
+	return i, err
+}
+
 const updateUserSetting = `-- name: UpdateUserSetting :one
 UPDATE user_setting
 SET
@@ -313,7 +421,7 @@ SET
   tutorial_enabled = ?,
   updated_at = CURRENT_TIMESTAMP
 WHERE user_id = ?
-RETURNING id, user_id, theme, daily_new_cards_limit, notifications_enabled, tutorial_enabled, created_at, updated_at
+RETURNING id, user_id, theme, daily_new_cards_limit, notifications_enabled, tutorial_enabled, created_at, updated_at, fsrs_weights, desired_retention, maximum_interval, daily_review_limit, learning_steps, relearning_steps, interval_fuzz, load_balance, timezone, day_start_hour
 `
 
 type UpdateUserSettingParams struct {
@@ -342,6 +450,16 @@ func (q *Queries) UpdateUserSetting(ctx context.Context, arg UpdateUserSettingPa
 		&i.TutorialEnabled,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.FsrsWeights,
+		&i.DesiredRetention,
+		&i.MaximumInterval,
+		&i.DailyReviewLimit,
+		&i.LearningSteps,
+		&i.RelearningSteps,
+		&i.IntervalFuzz,
+		&i.LoadBalance,
+		&i.Timezone,
+		&i.DayStartHour,
 	)
 	return i, err
 }
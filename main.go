@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"embed"
 	"log"
+	"os"
+	_ "time/tzdata"
 
 	"github.com/threeroundsoftware/voidabyss/app"
 	"github.com/threeroundsoftware/voidabyss/database"
@@ -20,11 +22,6 @@ const DSN = "vb.db?_foreign_keys=on"
 var staticFiles embed.FS
 
 func main() {
-	config, err := config.Load()
-	if err != nil {
-		log.Fatalf("Failed to load config: %s", err.Error())
-	}
-
 	db, err := sql.Open("sqlite3", DSN)
 	if err != nil {
 		log.Fatalf("Failed to connect to SQLite: %v", err)
@@ -37,5 +34,19 @@ func main() {
 	}
 
 	appInstance := app.NewApp(db)
+
+	// maintenance commands, e.g. `voidabyss optimize <user-id>`
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), appInstance, os.Args[1:]); err != nil {
+			log.Fatalf("Command %s failed: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	config, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %s", err.Error())
+	}
+
 	server.StartServer(appInstance, staticFiles, config)
 }
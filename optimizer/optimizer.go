@@ -0,0 +1,228 @@
+package optimizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	algorithm "github.com/threeroundsoftware/voidabyss/algo"
+)
+
+// MinReviews is the smallest number of scored reviews we are willing to fit on.
+// Below this the fitted weights are mostly noise.
+const MinReviews = 8
+
+var ErrNotEnoughReviews = errors.New("not enough reviews to optimize parameters")
+
+// ErrInvalidWeights is returned by CheckParams for a weight outside its bounds.
+var ErrInvalidWeights = errors.New("fsrs weight out of bounds")
+
+// Review is a single entry of a card's review log.
+type Review struct {
+	Rating algorithm.Rating
+	// ElapsedDays is the number of whole days since the previous review of the
+	// same card. It is 0 for the first review and for same-day reviews.
+	ElapsedDays float64
+}
+
+// History is the review log of one card, in review order.
+type History []Review
+
+// Config controls the gradient descent.
+type Config struct {
+	Iterations   int
+	LearningRate float64
+	// Regularization pulls the weights towards the starting weights,
+	// which keeps small review logs from over-fitting.
+	Regularization float64
+	// MaxReviews bounds the reviews fitted on, and so the time a fit takes:
+	// every iteration replays them all 38 times. Larger logs are sampled a
+	// whole card at a time. Zero means no bound.
+	MaxReviews int
+}
+
+// DefaultConfig returns settings that converge for typical review logs.
+func DefaultConfig() Config {
+	return Config{
+		Iterations:     200,
+		LearningRate:   0.04,
+		Regularization: 0.01,
+		MaxReviews:     2000,
+	}
+}
+
+// Result is the outcome of an optimization run.
+type Result struct {
+	Params      algorithm.FSRSParams
+	Loss        float64 // log-loss of Params
+	InitialLoss float64 // log-loss of the starting parameters
+	Reviews     int     // number of reviews that contributed to the loss
+}
+
+// bounds for each weight, as used by the reference FSRS-5 optimizer.
+var bounds = [19][2]float64{
+	{0.01, 100}, {0.01, 100}, {0.01, 100}, {0.01, 100},
+	{1, 10}, {0.001, 4}, {0.001, 4}, {0.001, 0.75},
+	{0, 4.5}, {0, 0.8}, {0.001, 3.5},
+	{0.001, 5}, {0.001, 0.25}, {0.001, 0.9}, {0, 4},
+	{0, 1}, {1, 6},
+	{0, 2}, {0, 2},
+}
+
+// Optimize fits the 19 FSRS weights to the review histories by minimizing the
+// log-loss between the predicted retrievability and the observed outcome
+// (pass = any rating above Again). Gradients are computed numerically and
+// applied with Adam; weights are clamped to their valid range after each step.
+//
+// If the fitted weights do not beat the starting weights, the starting
+// weights are returned unchanged. Optimize stops with ctx's error when ctx is
+// done.
+func Optimize(ctx context.Context, histories []History, initial algorithm.FSRSParams, cfg Config) (Result, error) {
+	histories = sampleHistories(histories, cfg.MaxReviews)
+	initialLoss, n := LogLoss(histories, initial)
+	if n < MinReviews {
+		return Result{}, ErrNotEnoughReviews
+	}
+
+	objective := func(p algorithm.FSRSParams) float64 {
+		loss, _ := LogLoss(histories, p)
+		return loss + cfg.Regularization*penalty(p, initial)
+	}
+
+	const (
+		beta1 = 0.9
+		beta2 = 0.999
+		eps   = 1e-8
+	)
+	var m, v [19]float64
+
+	params := clampParams(initial)
+	best := params
+	bestLoss := objective(params)
+
+	for iter := 1; iter <= cfg.Iterations; iter++ {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		grad := gradient(objective, params)
+		for i := range params.W {
+			m[i] = beta1*m[i] + (1-beta1)*grad[i]
+			v[i] = beta2*v[i] + (1-beta2)*grad[i]*grad[i]
+			mHat := m[i] / (1 - math.Pow(beta1, float64(iter)))
+			vHat := v[i] / (1 - math.Pow(beta2, float64(iter)))
+			params.W[i] -= cfg.LearningRate * mHat / (math.Sqrt(vHat) + eps)
+		}
+		params = clampParams(params)
+
+		if loss := objective(params); loss < bestLoss {
+			best, bestLoss = params, loss
+		}
+	}
+
+	loss, _ := LogLoss(histories, best)
+	if loss >= initialLoss {
+		return Result{Params: initial, Loss: initialLoss, InitialLoss: initialLoss, Reviews: n}, nil
+	}
+	return Result{Params: best, Loss: loss, InitialLoss: initialLoss, Reviews: n}, nil
+}
+
+// sampleHistories keeps whole histories, spread evenly over the log, until
+// about maxReviews reviews are kept. Logs within the bound are kept as they
+// are.
+func sampleHistories(histories []History, maxReviews int) []History {
+	total := 0
+	for _, h := range histories {
+		total += len(h)
+	}
+	if maxReviews <= 0 || total <= maxReviews {
+		return histories
+	}
+	var sampled []History
+	kept, seen := 0, 0
+	for _, h := range histories {
+		seen += len(h)
+		if kept < seen*maxReviews/total {
+			sampled = append(sampled, h)
+			kept += len(h)
+		}
+	}
+	return sampled
+}
+
+// LogLoss replays every history with params and returns the mean binary
+// cross-entropy of the predicted recall probability, together with the number
+// of reviews that were scored. First and same-day reviews are not scored since
+// the forgetting curve says nothing about them.
+func LogLoss(histories []History, params algorithm.FSRSParams) (float64, int) {
+	var total float64
+	var n int
+	for _, h := range histories {
+		var s, d float64
+		for i, r := range h {
+			sameDay := i > 0 && r.ElapsedDays == 0
+			if i > 0 && !sameDay {
+				p := algorithm.ForgettingCurve(r.ElapsedDays, s)
+				p = math.Min(math.Max(p, 1e-6), 1-1e-6)
+				if r.Rating > algorithm.Again {
+					total -= math.Log(p)
+				} else {
+					total -= math.Log(1 - p)
+				}
+				n++
+			}
+			s, d, _, _ = algorithm.ReviewCard(s, d, r.ElapsedDays, r.Rating, params, sameDay, 0.9)
+		}
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return total / float64(n), n
+}
+
+// gradient estimates the gradient of f with central differences, staying
+// inside the weight bounds.
+func gradient(f func(algorithm.FSRSParams) float64, params algorithm.FSRSParams) [19]float64 {
+	var grad [19]float64
+	for i := range params.W {
+		h := 1e-4 * math.Max(1, math.Abs(params.W[i]))
+		lo, hi := params, params
+		lo.W[i] = math.Max(params.W[i]-h, bounds[i][0])
+		hi.W[i] = math.Min(params.W[i]+h, bounds[i][1])
+		if hi.W[i] == lo.W[i] {
+			continue
+		}
+		grad[i] = (f(hi) - f(lo)) / (hi.W[i] - lo.W[i])
+	}
+	return grad
+}
+
+// penalty is the mean squared relative distance of params from the reference weights.
+func penalty(params, reference algorithm.FSRSParams) float64 {
+	var sum float64
+	for i := range params.W {
+		span := bounds[i][1] - bounds[i][0]
+		diff := (params.W[i] - reference.W[i]) / span
+		sum += diff * diff
+	}
+	return sum / float64(len(params.W))
+}
+
+// CheckParams returns ErrInvalidWeights unless every weight lies within the
+// bounds Optimize keeps it to. Weights outside them can make stability NaN or
+// infinite.
+func CheckParams(params algorithm.FSRSParams) error {
+	for i, w := range params.W {
+		if math.IsNaN(w) || w < bounds[i][0] || w > bounds[i][1] {
+			return fmt.Errorf("%w: weight %d is %v, want %v to %v", ErrInvalidWeights, i, w, bounds[i][0], bounds[i][1])
+		}
+	}
+	return nil
+}
+
+func clampParams(params algorithm.FSRSParams) algorithm.FSRSParams {
+	for i := range params.W {
+		params.W[i] = math.Min(math.Max(params.W[i], bounds[i][0]), bounds[i][1])
+	}
+	return params
+}
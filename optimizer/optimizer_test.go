@@ -0,0 +1,133 @@
+package optimizer
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	algorithm "github.com/threeroundsoftware/voidabyss/algo"
+)
+
+// syntheticHistories reviews cards whose memory follows params: each review
+// comes some time around the interval params schedule, and is passed with the
+// probability params predict.
+func syntheticHistories(params algorithm.FSRSParams, cards, reviews int, seed int64) []History {
+	rng := rand.New(rand.NewSource(seed))
+	histories := make([]History, 0, cards)
+	for range cards {
+		var h History
+		var s, d, elapsed float64
+		for i := range reviews {
+			rating := algorithm.Rating(1 + rng.Intn(4))
+			if i > 0 {
+				rating = algorithm.Again
+				if rng.Float64() < algorithm.ForgettingCurve(elapsed, s) {
+					rating = algorithm.Rating(2 + rng.Intn(3))
+				}
+			}
+			h = append(h, Review{Rating: rating, ElapsedDays: elapsed})
+			var interval float64
+			s, d, _, interval = algorithm.ReviewCard(s, d, elapsed, rating, params, false, 0.9)
+			elapsed = math.Max(1, math.Round(interval*(0.5+1.5*rng.Float64())))
+		}
+		histories = append(histories, h)
+	}
+	return histories
+}
+
+func TestOptimizeLowersLoss(t *testing.T) {
+	truth := algorithm.DefaultParams()
+	histories := syntheticHistories(truth, 300, 6, 1)
+
+	initial := truth
+	for i := range 4 {
+		initial.W[i] *= 4
+	}
+	cfg := DefaultConfig()
+	cfg.Iterations = 40
+
+	result, err := Optimize(context.Background(), histories, initial, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Loss >= result.InitialLoss {
+		t.Errorf("loss = %v, want below the initial %v", result.Loss, result.InitialLoss)
+	}
+	if loss, _ := LogLoss(histories, result.Params); loss != result.Loss {
+		t.Errorf("loss of the fitted weights = %v, want %v", loss, result.Loss)
+	}
+	if result.Reviews != 300*5 {
+		t.Errorf("reviews = %d, want %d", result.Reviews, 300*5)
+	}
+}
+
+func TestOptimizeKeepsBounds(t *testing.T) {
+	histories := syntheticHistories(algorithm.DefaultParams(), 100, 6, 2)
+	cfg := DefaultConfig()
+	cfg.Iterations = 20
+	cfg.LearningRate = 10
+
+	result, err := Optimize(context.Background(), histories, algorithm.DefaultParams(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, w := range result.Params.W {
+		if w < bounds[i][0] || w > bounds[i][1] {
+			t.Errorf("w[%d] = %v, want within %v", i, w, bounds[i])
+		}
+	}
+}
+
+func TestOptimizeNotEnoughReviews(t *testing.T) {
+	// the first review of a card is not scored
+	histories := syntheticHistories(algorithm.DefaultParams(), 1, MinReviews, 3)
+	_, err := Optimize(context.Background(), histories, algorithm.DefaultParams(), DefaultConfig())
+	if !errors.Is(err, ErrNotEnoughReviews) {
+		t.Errorf("err = %v, want ErrNotEnoughReviews", err)
+	}
+
+	histories = syntheticHistories(algorithm.DefaultParams(), 1, MinReviews+1, 3)
+	cfg := DefaultConfig()
+	cfg.Iterations = 1
+	if _, err := Optimize(context.Background(), histories, algorithm.DefaultParams(), cfg); err != nil {
+		t.Errorf("%d scored reviews: err = %v", MinReviews, err)
+	}
+}
+
+func TestOptimizeCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	histories := syntheticHistories(algorithm.DefaultParams(), 10, 6, 4)
+	if _, err := Optimize(ctx, histories, algorithm.DefaultParams(), DefaultConfig()); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestSampleHistories(t *testing.T) {
+	histories := syntheticHistories(algorithm.DefaultParams(), 100, 5, 5)
+	if got := sampleHistories(histories, 0); len(got) != 100 {
+		t.Errorf("unbounded: kept %d histories, want 100", len(got))
+	}
+	if got := sampleHistories(histories, 500); len(got) != 100 {
+		t.Errorf("within the bound: kept %d histories, want 100", len(got))
+	}
+	got := sampleHistories(histories, 100)
+	if len(got) != 20 {
+		t.Errorf("kept %d histories, want 20", len(got))
+	}
+}
+
+func TestCheckParams(t *testing.T) {
+	if err := CheckParams(algorithm.DefaultParams()); err != nil {
+		t.Fatalf("default weights: %v", err)
+	}
+	for _, w := range []float64{0, -1, 1e9, math.NaN(), math.Inf(1)} {
+		params := algorithm.DefaultParams()
+		params.W[0] = w
+		if err := CheckParams(params); !errors.Is(err, ErrInvalidWeights) {
+			t.Errorf("w[0] = %v: err = %v, want ErrInvalidWeights", w, err)
+		}
+	}
+}
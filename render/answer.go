@@ -0,0 +1,278 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// typePattern matches {{type:Field}}, optionally with options before the
+// field name, as in {{type:nocase:noaccents:Field}}.
+var typePattern = regexp.MustCompile(`\{\{\s*type:((?:(?:nocase|noaccents):)*)([^{}]+?)\s*\}\}`)
+
+// tagPattern matches an HTML tag.
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// maxCompareCells bounds the work of comparing two answers: answers whose
+// lengths multiply to more are only compared as a whole.
+const maxCompareCells = 1 << 20
+
+// TypeAnswer is a {{type:Field}} directive, which asks for Field to be typed
+// in. On the front it renders an input; on the back, the field's content,
+// compared with the typed answer when there is one.
+type TypeAnswer struct {
+	Field   string
+	Options CompareOptions
+}
+
+// CompareOptions are how loosely a typed answer is compared.
+type CompareOptions struct {
+	// IgnoreCase is set by the nocase option.
+	IgnoreCase bool
+	// IgnoreAccents is set by the noaccents option.
+	IgnoreAccents bool
+}
+
+// Comparison is a typed answer compared character by character with the
+// expected one.
+type Comparison struct {
+	Expected string
+	// Correct is set when the answers match under the options.
+	Correct bool
+	// Distance is the number of characters inserted, removed or replaced to
+	// turn the typed answer into the expected one.
+	Distance int
+	// Diff shows the typed answer with its good and bad characters and, when
+	// it is not correct, the expected answer with the characters it missed,
+	// marked by the classes type-good, type-bad and type-missed.
+	Diff template.HTML
+}
+
+// TypeAnswers returns the {{type:Field}} directives of a template, in order.
+func TypeAnswers(text string) []TypeAnswer {
+	var answers []TypeAnswer
+	for _, m := range typePattern.FindAllStringSubmatch(text, -1) {
+		answers = append(answers, TypeAnswer{Field: m[2], Options: parseOptions(m[1])})
+	}
+	return answers
+}
+
+// parseOptions reads the options of a {{type:Field}} directive.
+func parseOptions(options string) CompareOptions {
+	return CompareOptions{
+		IgnoreCase:    strings.Contains(options, "nocase:"),
+		IgnoreAccents: strings.Contains(options, "noaccents:"),
+	}
+}
+
+// AnswerText is the text a field's content is to be typed as: the content of
+// a text field, or of an HTML field without its tags and with its entities
+// unescaped.
+func AnswerText(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case template.HTML:
+		return strings.TrimSpace(html.UnescapeString(tagPattern.ReplaceAllString(string(v), "")))
+	}
+	return ""
+}
+
+// Compare compares a typed answer with the expected one. Surrounding space
+// is ignored on both.
+func Compare(typed, expected string, opts CompareOptions) Comparison {
+	typed = norm.NFC.String(strings.TrimSpace(typed))
+	expected = norm.NFC.String(strings.TrimSpace(expected))
+	a, b := []rune(typed), []rune(expected)
+	ops := align(foldRunes(a, opts), foldRunes(b, opts))
+
+	c := Comparison{Expected: expected}
+	for _, op := range ops {
+		if op != opEqual {
+			c.Distance++
+		}
+	}
+	c.Correct = c.Distance == 0
+
+	var typedLine, expectedLine diffWriter
+	i, j := 0, 0
+	for _, op := range ops {
+		switch op {
+		case opEqual:
+			typedLine.write("type-good", a[i])
+			expectedLine.write("type-good", b[j])
+			i, j = i+1, j+1
+		case opReplace:
+			typedLine.write("type-bad", a[i])
+			expectedLine.write("type-missed", b[j])
+			i, j = i+1, j+1
+		case opInsert:
+			typedLine.write("type-bad", a[i])
+			i++
+		case opDelete:
+			expectedLine.write("type-missed", b[j])
+			j++
+		}
+	}
+
+	var diff strings.Builder
+	diff.WriteString(`<code class="type-answer">`)
+	switch {
+	case c.Correct:
+		diff.WriteString(typedLine.String())
+	case typed == "":
+		diff.WriteString(expectedLine.String())
+	default:
+		diff.WriteString(typedLine.String())
+		diff.WriteString(`<br><span class="type-arrow">&darr;</span><br>`)
+		diff.WriteString(expectedLine.String())
+	}
+	diff.WriteString("</code>")
+	c.Diff = template.HTML(diff.String())
+	return c
+}
+
+// foldRunes returns what each rune is compared as under opts.
+func foldRunes(runes []rune, opts CompareOptions) []string {
+	keys := make([]string, len(runes))
+	for i, r := range runes {
+		if opts.IgnoreCase {
+			r = unicode.ToLower(r)
+		}
+		key := string(r)
+		if opts.IgnoreAccents {
+			key = strings.Map(func(r rune) rune {
+				if unicode.Is(unicode.Mn, r) {
+					return -1
+				}
+				return r
+			}, norm.NFD.String(key))
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+type diffOp int
+
+const (
+	opEqual diffOp = iota
+	opReplace
+	// opInsert is a character of the typed answer that is not in the
+	// expected one.
+	opInsert
+	// opDelete is a character of the expected answer missing from the typed
+	// one.
+	opDelete
+)
+
+// align returns the fewest operations turning a into b, as found by their
+// edit distance.
+func align(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	if n*m > maxCompareCells {
+		if slices.Equal(a, b) {
+			return slices.Repeat([]diffOp{opEqual}, n)
+		}
+		return append(slices.Repeat([]diffOp{opInsert}, n), slices.Repeat([]diffOp{opDelete}, m)...)
+	}
+
+	// dist[i][j] is the edit distance between a[i:] and b[j:].
+	dist := make([][]int, n+1)
+	for i := range dist {
+		dist[i] = make([]int, m+1)
+		dist[i][m] = n - i
+	}
+	for j := 0; j <= m; j++ {
+		dist[n][j] = m - j
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dist[i][j] = dist[i+1][j+1]
+			} else {
+				dist[i][j] = 1 + min(dist[i+1][j+1], dist[i+1][j], dist[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, max(n, m))
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j] && dist[i][j] == dist[i+1][j+1]:
+			ops = append(ops, opEqual)
+			i, j = i+1, j+1
+		case i < n && j < m && dist[i][j] == 1+dist[i+1][j+1]:
+			ops = append(ops, opReplace)
+			i, j = i+1, j+1
+		case i < n && dist[i][j] == 1+dist[i+1][j]:
+			ops = append(ops, opInsert)
+			i++
+		default:
+			ops = append(ops, opDelete)
+			j++
+		}
+	}
+	return ops
+}
+
+// diffWriter writes characters as escaped HTML, each run of characters of
+// one class in a single span.
+type diffWriter struct {
+	b     strings.Builder
+	class string
+	run   []rune
+}
+
+func (w *diffWriter) write(class string, r rune) {
+	if class != w.class {
+		w.flush()
+		w.class = class
+	}
+	w.run = append(w.run, r)
+}
+
+func (w *diffWriter) flush() {
+	if len(w.run) > 0 {
+		fmt.Fprintf(&w.b, `<span class="%s">%s</span>`, w.class, template.HTMLEscapeString(string(w.run)))
+		w.run = w.run[:0]
+	}
+}
+
+func (w *diffWriter) String() string {
+	w.flush()
+	return w.b.String()
+}
+
+// expandTypeAnswers rewrites each {{type:Field}} in text as a call to the
+// template's typeAnswer function, so that the template can be parsed.
+func expandTypeAnswers(text string) string {
+	return typePattern.ReplaceAllStringFunc(text, func(directive string) string {
+		m := typePattern.FindStringSubmatch(directive)
+		field := strconv.Quote(m[2])
+		return fmt.Sprintf("{{typeAnswer %s %s (index $ %s)}}", field, strconv.Quote(m[1]), field)
+	})
+}
+
+// typeAnswer is the template's typeAnswer function. typed is the typed
+// answer, or nil when there is none.
+func typeAnswer(field, options string, v any, reveal bool, typed *string) template.HTML {
+	if !reveal {
+		return template.HTML(fmt.Sprintf(
+			`<input type="text" class="type-answer" name="typed_answer" data-field="%s" autocomplete="off" autocapitalize="off" spellcheck="false">`,
+			template.HTMLEscapeString(field)))
+	}
+	expected := AnswerText(v)
+	if typed == nil {
+		return template.HTML(`<code class="type-answer">` + template.HTMLEscapeString(expected) + "</code>")
+	}
+	return Compare(*typed, expected, parseOptions(options)).Diff
+}
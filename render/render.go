@@ -0,0 +1,283 @@
+// Package render executes card templates against a note's fields to produce
+// the HTML shown on each side of a card.
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// ErrInvalidTemplate is returned when a card template cannot be parsed or
+// executed.
+var ErrInvalidTemplate = errors.New("invalid card template")
+
+// clozePattern matches {{cN::answer}} and {{cN::answer::hint}}.
+var clozePattern = regexp.MustCompile(`(?s)\{\{c(\d+)::(.*?)(?:::(.*?))?\}\}`)
+
+// Card is a card rendered for study.
+type Card struct {
+	Front template.HTML
+	Back  template.HTML
+}
+
+// Render executes a template's front and back against the note fields, which
+// are available to the template as {{.FieldName}}. A field given as a string
+// is escaped like any other text; one given as template.HTML is inserted as
+// it is. ordinal is the cloze number the card tests, or 0 if it tests none:
+// the template's cloze function hides that cloze on the front and reveals it
+// on the back, and shows every other cloze as plain text. funcs are further
+// functions the template can call, such as the user's own; they cannot
+// replace the built-in ones. A {{type:Field}} directive renders an input for
+// the answer on the front and the field's content on the back.
+func Render(front, back string, fields map[string]any, ordinal int, funcs template.FuncMap) (Card, error) {
+	return renderCard(front, back, fields, ordinal, funcs, nil)
+}
+
+// RenderAnswer is Render for a card answered by typing typed: on the back, a
+// {{type:Field}} directive shows typed compared with the field's content.
+func RenderAnswer(front, back string, fields map[string]any, ordinal int, funcs template.FuncMap, typed string) (Card, error) {
+	return renderCard(front, back, fields, ordinal, funcs, &typed)
+}
+
+func renderCard(front, back string, fields map[string]any, ordinal int, funcs template.FuncMap, typed *string) (Card, error) {
+	f, err := execute("front", front, fields, ordinal, false, funcs, typed)
+	if err != nil {
+		return Card{}, err
+	}
+	b, err := execute("back", back, fields, ordinal, true, funcs, typed)
+	if err != nil {
+		return Card{}, err
+	}
+	return Card{Front: f, Back: b}, nil
+}
+
+// FieldNames returns the fields a template refers to, in the order they
+// first appear. funcs are the further functions the template can call, as
+// given to Render.
+func FieldNames(text string, funcs template.FuncMap) ([]string, error) {
+	tmpl, err := newTemplate("template", text, 0, false, funcs, nil)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	seen := make(map[string]bool)
+	walk(tmpl.Tree.Root, func(node parse.Node) {
+		if name, ok := fieldName(node); ok && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	})
+	return names, nil
+}
+
+// ClozeFields returns the fields a template passes to cloze, as
+// {{cloze .Text}} or {{.Text | cloze}}, in the order they first appear. A
+// template with any is a cloze template, which makes a card for each cloze
+// number in those fields.
+func ClozeFields(text string, funcs template.FuncMap) ([]string, error) {
+	tmpl, err := newTemplate("template", text, 0, false, funcs, nil)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	add := func(arg parse.Node) {
+		if name, ok := fieldName(arg); ok && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	walk(tmpl.Tree.Root, func(node parse.Node) {
+		pipe, ok := node.(*parse.PipeNode)
+		if !ok {
+			return
+		}
+		for i, cmd := range pipe.Cmds {
+			if ident, ok := cmd.Args[0].(*parse.IdentifierNode); !ok || ident.Ident != "cloze" {
+				continue
+			}
+			for _, arg := range cmd.Args[1:] {
+				add(arg)
+			}
+			if len(cmd.Args) == 1 && i > 0 {
+				add(pipe.Cmds[i-1].Args[0])
+			}
+		}
+	})
+	return names, nil
+}
+
+// IsBuiltin reports whether name is a function every template has, either
+// from html/template or from this package.
+func IsBuiltin(name string) bool {
+	switch name {
+	case "cloze", "typeAnswer",
+		"and", "or", "not", "len", "index", "slice", "call", "print", "printf", "println",
+		"html", "js", "urlquery", "eq", "ne", "lt", "le", "gt", "ge":
+		return true
+	}
+	return false
+}
+
+func newTemplate(name, text string, ordinal int, reveal bool, funcs template.FuncMap, typed *string) (*template.Template, error) {
+	tmpl, err := template.New(name).
+		Funcs(funcs).
+		Funcs(template.FuncMap{
+			"cloze": func(v any) (template.HTML, error) { return cloze(v, ordinal, reveal) },
+			"typeAnswer": func(field, options string, v any) template.HTML {
+				return typeAnswer(field, options, v, reveal, typed)
+			},
+		}).
+		Option("missingkey=zero").
+		Parse(expandTypeAnswers(text))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidTemplate, name, err)
+	}
+	return tmpl, nil
+}
+
+// walk calls visit with node and every node below it.
+func walk(node parse.Node, visit func(parse.Node)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		visit(n)
+		for _, child := range n.Nodes {
+			walk(child, visit)
+		}
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		visit(n)
+		for _, cmd := range n.Cmds {
+			walk(cmd, visit)
+		}
+	case *parse.ActionNode:
+		visit(n)
+		walk(n.Pipe, visit)
+	case *parse.CommandNode:
+		visit(n)
+		for _, arg := range n.Args {
+			walk(arg, visit)
+		}
+	case *parse.ChainNode:
+		visit(n)
+		walk(n.Node, visit)
+	case *parse.IfNode:
+		visit(n)
+		walk(n.Pipe, visit)
+		walk(n.List, visit)
+		walk(n.ElseList, visit)
+	case *parse.RangeNode:
+		visit(n)
+		walk(n.Pipe, visit)
+		walk(n.List, visit)
+		walk(n.ElseList, visit)
+	case *parse.WithNode:
+		visit(n)
+		walk(n.Pipe, visit)
+		walk(n.List, visit)
+		walk(n.ElseList, visit)
+	case *parse.TemplateNode:
+		visit(n)
+		walk(n.Pipe, visit)
+	default:
+		visit(node)
+	}
+}
+
+// fieldName returns the name of the field node refers to, as .Name, $.Name
+// or index $ "Name".
+func fieldName(node parse.Node) (string, bool) {
+	switch n := node.(type) {
+	case *parse.CommandNode:
+		if len(n.Args) != 3 {
+			break
+		}
+		ident, ok := n.Args[0].(*parse.IdentifierNode)
+		root, isVar := n.Args[1].(*parse.VariableNode)
+		name, isString := n.Args[2].(*parse.StringNode)
+		if ok && ident.Ident == "index" && isVar && len(root.Ident) == 1 && root.Ident[0] == "$" && isString {
+			return name.Text, true
+		}
+	case *parse.FieldNode:
+		return n.Ident[0], true
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			return n.Ident[1], true
+		}
+	}
+	return "", false
+}
+
+func execute(side, text string, fields map[string]any, ordinal int, reveal bool, funcs template.FuncMap, typed *string) (template.HTML, error) {
+	tmpl, err := newTemplate(side, text, ordinal, reveal, funcs, typed)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, fields); err != nil {
+		return "", fmt.Errorf("%w: %s: %w", ErrInvalidTemplate, side, err)
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// cloze is the template's cloze function. It takes a field either way it
+// can be given.
+func cloze(v any, ordinal int, reveal bool) (template.HTML, error) {
+	switch text := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return clozeText(text, ordinal, reveal, template.HTMLEscapeString), nil
+	case template.HTML:
+		return clozeText(string(text), ordinal, reveal, func(s string) string { return s }), nil
+	default:
+		return "", fmt.Errorf("cloze of %T", v)
+	}
+}
+
+// Cloze renders text containing cloze deletions for the card testing
+// ordinal. On the front (reveal false) that cloze is replaced by its hint, or
+// by "[...]" when it has none; on the back its answer is shown. Both are
+// wrapped in <span class="cloze">. Other clozes show their answer as plain
+// text. The rest of text is escaped.
+func Cloze(text string, ordinal int, reveal bool) template.HTML {
+	return clozeText(text, ordinal, reveal, template.HTMLEscapeString)
+}
+
+func clozeText(text string, ordinal int, reveal bool, escape func(string) string) template.HTML {
+	var b strings.Builder
+	last := 0
+	for _, m := range clozePattern.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(escape(text[last:m[0]]))
+		last = m[1]
+
+		n, _ := strconv.Atoi(text[m[2]:m[3]])
+		answer := escape(text[m[4]:m[5]])
+		if n != ordinal {
+			b.WriteString(answer)
+			continue
+		}
+		b.WriteString(`<span class="cloze">`)
+		switch {
+		case reveal:
+			b.WriteString(answer)
+		case m[6] >= 0:
+			b.WriteString("[" + escape(text[m[6]:m[7]]) + "]")
+		default:
+			b.WriteString("[...]")
+		}
+		b.WriteString("</span>")
+	}
+	b.WriteString(escape(text[last:]))
+	return template.HTML(b.String())
+}
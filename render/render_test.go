@@ -0,0 +1,201 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCloze(t *testing.T) {
+	text := "{{c1::Paris::city}} is the capital of {{c2::France}} & more"
+	tests := []struct {
+		ordinal int
+		reveal  bool
+		want    string
+	}{
+		{1, false, `<span class="cloze">[city]</span> is the capital of France &amp; more`},
+		{1, true, `<span class="cloze">Paris</span> is the capital of France &amp; more`},
+		{2, false, `Paris is the capital of <span class="cloze">[...]</span> &amp; more`},
+		{2, true, `Paris is the capital of <span class="cloze">France</span> &amp; more`},
+		{0, false, `Paris is the capital of France &amp; more`},
+	}
+	for _, tt := range tests {
+		if got := string(Cloze(text, tt.ordinal, tt.reveal)); got != tt.want {
+			t.Errorf("Cloze(%d, %v) = %q, want %q", tt.ordinal, tt.reveal, got, tt.want)
+		}
+	}
+}
+
+func TestClozeEscapesAnswers(t *testing.T) {
+	got := string(Cloze("{{c1::<b>x</b>::<i>}}", 1, false))
+	if want := `<span class="cloze">[&lt;i&gt;]</span>`; got != want {
+		t.Errorf("front = %q, want %q", got, want)
+	}
+	got = string(Cloze("{{c1::<b>x</b>::<i>}}", 1, true))
+	if want := `<span class="cloze">&lt;b&gt;x&lt;/b&gt;</span>`; got != want {
+		t.Errorf("back = %q, want %q", got, want)
+	}
+}
+
+func TestRender(t *testing.T) {
+	fields := map[string]any{
+		"Front": "1 < 2",
+		"Back":  template.HTML("<b>2</b>"),
+		"Text":  "{{c1::Mona Lisa::painting}} and {{c2::The Last Supper}}",
+		"Rich":  template.HTML("<i>{{c1::Louvre}}</i>"),
+	}
+
+	card, err := Render("Q: {{.Front}}{{.Missing}} {{.Back}}", "{{cloze .Text}} {{cloze .Rich}}", fields, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Q: 1 &lt; 2 <b>2</b>"; string(card.Front) != want {
+		t.Errorf("front = %q, want %q", card.Front, want)
+	}
+	if want := `Mona Lisa and <span class="cloze">The Last Supper</span> <i>Louvre</i>`; string(card.Back) != want {
+		t.Errorf("back = %q, want %q", card.Back, want)
+	}
+
+	if _, err := Render("{{.Front", "", fields, 0, nil); !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("unparsable template: err = %v, want ErrInvalidTemplate", err)
+	} else if n := strings.Count(err.Error(), ErrInvalidTemplate.Error()); n != 1 {
+		t.Errorf("unparsable template: err = %q, want ErrInvalidTemplate once", err)
+	}
+	if _, err := Render("{{unknown .Front}}", "", fields, 0, nil); !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("unknown function: err = %v, want ErrInvalidTemplate", err)
+	}
+}
+
+func TestRenderFuncs(t *testing.T) {
+	funcs := template.FuncMap{
+		"shout": func(v any) (any, error) { return fmt.Sprint(v, "!"), nil },
+		"cloze": func(v any) string { return "replaced" },
+		"fail":  func(v any) (any, error) { return nil, errors.New("broken") },
+	}
+	fields := map[string]any{"Front": "<hi>", "Text": "{{c1::x}}"}
+
+	card, err := Render("{{shout .Front}}", "{{cloze .Text}}", fields, 1, funcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "&lt;hi&gt;!"; string(card.Front) != want {
+		t.Errorf("front = %q, want %q", card.Front, want)
+	}
+	if want := `<span class="cloze">x</span>`; string(card.Back) != want {
+		t.Errorf("back = %q, want %q", card.Back, want)
+	}
+	if _, err := Render("{{fail .Front}}", "", fields, 0, funcs); !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("failing function: err = %v, want ErrInvalidTemplate", err)
+	}
+	if _, err := FieldNames("{{shout .Front}}", funcs); err != nil {
+		t.Errorf("FieldNames with funcs: %v", err)
+	}
+}
+
+func TestFieldNames(t *testing.T) {
+	text := `{{.Front}} {{cloze .Text}} {{if .Hint}}{{.Hint | html}}{{else}}{{$.Back}}{{end}} {{range $x := .Tags}}{{end}} {{.Front}}`
+	got, err := FieldNames(text, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Front", "Text", "Hint", "Back", "Tags"}; !slices.Equal(got, want) {
+		t.Errorf("FieldNames = %v, want %v", got, want)
+	}
+	if _, err := FieldNames("{{.Front", nil); !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("unparsable template: err = %v, want ErrInvalidTemplate", err)
+	}
+}
+
+func TestClozeFields(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"{{.Front}}", nil},
+		{"Fill in: {{cloze .Text}}", []string{"Text"}},
+		{"{{.Extra | cloze}} {{if .Hint}}{{cloze $.Text}}{{end}} {{cloze .Extra}}", []string{"Extra", "Text"}},
+		{"{{len .Text}} {{.Front | html}}", nil},
+	}
+	for _, tt := range tests {
+		got, err := ClozeFields(tt.text, nil)
+		if err != nil {
+			t.Errorf("ClozeFields(%q) error: %v", tt.text, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ClozeFields(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRenderTypeAnswer(t *testing.T) {
+	fields := map[string]any{"Front": "hola", "Back": template.HTML("<b>Hello</b> &amp; bye")}
+	front, back := "{{.Front}} {{type:nocase:Back}}", "{{type:nocase:Back}}"
+
+	card, err := Render(front, back, fields, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(card.Front), `<input type="text" class="type-answer" name="typed_answer" data-field="Back"`) {
+		t.Errorf("front = %s, want an input", card.Front)
+	}
+	if want := `<code class="type-answer">Hello &amp; bye</code>`; string(card.Back) != want {
+		t.Errorf("back = %s, want %s", card.Back, want)
+	}
+
+	card, err = RenderAnswer(front, back, fields, 0, nil, "hello & BYE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<code class="type-answer"><span class="type-good">hello &amp; BYE</span></code>`; string(card.Back) != want {
+		t.Errorf("answered back = %s, want %s", card.Back, want)
+	}
+
+	names, err := FieldNames(front, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Front", "Back"}; !slices.Equal(names, want) {
+		t.Errorf("FieldNames = %v, want %v", names, want)
+	}
+	answers := TypeAnswers(back)
+	if len(answers) != 1 || answers[0].Field != "Back" || !answers[0].Options.IgnoreCase || answers[0].Options.IgnoreAccents {
+		t.Errorf("TypeAnswers = %+v", answers)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		typed, expected string
+		opts            CompareOptions
+		distance        int
+		diff            string
+	}{
+		{" Paris ", "Paris", CompareOptions{}, 0, `<span class="type-good">Paris</span>`},
+		{"paris", "Paris", CompareOptions{}, 1,
+			`<span class="type-bad">p</span><span class="type-good">aris</span><br><span class="type-arrow">&darr;</span><br>` +
+				`<span class="type-missed">P</span><span class="type-good">aris</span>`},
+		{"paris", "Paris", CompareOptions{IgnoreCase: true}, 0, `<span class="type-good">paris</span>`},
+		{"cafe", "Café", CompareOptions{IgnoreAccents: true, IgnoreCase: true}, 0, `<span class="type-good">cafe</span>`},
+		{"Café", "Café", CompareOptions{}, 0, `<span class="type-good">Café</span>`},
+		{"Pars", "Paris", CompareOptions{}, 1,
+			`<span class="type-good">Pars</span><br><span class="type-arrow">&darr;</span><br>` +
+				`<span class="type-good">Par</span><span class="type-missed">i</span><span class="type-good">s</span>`},
+		{"a<b", "ab", CompareOptions{}, 1,
+			`<span class="type-good">a</span><span class="type-bad">&lt;</span><span class="type-good">b</span><br><span class="type-arrow">&darr;</span><br>` +
+				`<span class="type-good">ab</span>`},
+		{"", "ab", CompareOptions{}, 2, `<span class="type-missed">ab</span>`},
+	}
+	for _, tt := range tests {
+		c := Compare(tt.typed, tt.expected, tt.opts)
+		if c.Distance != tt.distance || c.Correct != (tt.distance == 0) {
+			t.Errorf("Compare(%q, %q) distance = %d, correct = %v, want %d", tt.typed, tt.expected, c.Distance, c.Correct, tt.distance)
+		}
+		if want := `<code class="type-answer">` + tt.diff + "</code>"; string(c.Diff) != want {
+			t.Errorf("Compare(%q, %q) diff =\n%s\nwant\n%s", tt.typed, tt.expected, c.Diff, want)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package script
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"math/rand/v2"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+type builtin struct {
+	minArgs int
+	// maxArgs is -1 for a builtin taking any number of arguments.
+	maxArgs int
+	// pattern is the index of the argument that must be a regular
+	// expression given as a string literal, or 0 when there is none.
+	pattern int
+	fn      func(m *machine, args []any) (any, error)
+}
+
+func (b *builtin) arity() string {
+	switch {
+	case b.maxArgs < 0:
+		return fmt.Sprintf("at least %d arguments", b.minArgs)
+	case b.minArgs == b.maxArgs && b.minArgs == 1:
+		return "1 argument"
+	case b.minArgs == b.maxArgs:
+		return fmt.Sprintf("%d arguments", b.minArgs)
+	}
+	return fmt.Sprintf("%d to %d arguments", b.minArgs, b.maxArgs)
+}
+
+func (b *builtin) call(m *machine, args []any) (any, error) {
+	if err := m.step(1); err != nil {
+		return nil, err
+	}
+	return b.fn(m, args)
+}
+
+// work charges the steps for going through n bytes or elements.
+func (m *machine) work(n int) error {
+	return m.step(n / 64)
+}
+
+// builtins are the functions a body can call.
+var builtins = map[string]*builtin{
+	"upper":        {minArgs: 1, maxArgs: 1, fn: mapString(strings.ToUpper)},
+	"lower":        {minArgs: 1, maxArgs: 1, fn: mapString(strings.ToLower)},
+	"trim":         {minArgs: 1, maxArgs: 1, fn: mapString(strings.TrimSpace)},
+	"escape":       {minArgs: 1, maxArgs: 1, fn: mapString(html.EscapeString)},
+	"str":          {minArgs: 1, maxArgs: 1, fn: builtinStr},
+	"num":          {minArgs: 1, maxArgs: 1, fn: builtinNum},
+	"len":          {minArgs: 1, maxArgs: 1, fn: builtinLen},
+	"substr":       {minArgs: 2, maxArgs: 3, fn: builtinSubstr},
+	"split":        {minArgs: 2, maxArgs: 2, fn: builtinSplit},
+	"join":         {minArgs: 2, maxArgs: 2, fn: builtinJoin},
+	"replace":      {minArgs: 3, maxArgs: 3, fn: builtinReplace},
+	"repeat":       {minArgs: 2, maxArgs: 2, fn: builtinRepeat},
+	"contains":     {minArgs: 2, maxArgs: 2, fn: builtinContains},
+	"startsWith":   {minArgs: 2, maxArgs: 2, fn: stringTest(strings.HasPrefix)},
+	"endsWith":     {minArgs: 2, maxArgs: 2, fn: stringTest(strings.HasSuffix)},
+	"choice":       {minArgs: 1, maxArgs: -1, fn: builtinChoice},
+	"round":        {minArgs: 1, maxArgs: 1, fn: builtinRound},
+	"min":          {minArgs: 1, maxArgs: -1, fn: numberFold(math.Min)},
+	"max":          {minArgs: 1, maxArgs: -1, fn: numberFold(math.Max)},
+	"regexMatch":   {minArgs: 2, maxArgs: 2, pattern: 1, fn: builtinRegexMatch},
+	"regexReplace": {minArgs: 3, maxArgs: 3, pattern: 1, fn: builtinRegexReplace},
+}
+
+// mapString makes a builtin that transforms a string.
+func mapString(f func(string) string) func(*machine, []any) (any, error) {
+	return func(m *machine, args []any) (any, error) {
+		s, err := toString(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if err := m.work(len(s)); err != nil {
+			return nil, err
+		}
+		// Changing case can at most triple a string's length.
+		if err := m.alloc(3 * len(s)); err != nil {
+			return nil, err
+		}
+		return f(s), nil
+	}
+}
+
+// stringTest makes a builtin that tests two strings.
+func stringTest(f func(s, t string) bool) func(*machine, []any) (any, error) {
+	return func(m *machine, args []any) (any, error) {
+		s, err := toString(args[0])
+		if err != nil {
+			return nil, err
+		}
+		t, err := toString(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return f(s, t), nil
+	}
+}
+
+// numberFold makes a builtin that combines numbers pairwise.
+func numberFold(f func(a, b float64) float64) func(*machine, []any) (any, error) {
+	return func(m *machine, args []any) (any, error) {
+		if err := m.work(len(args)); err != nil {
+			return nil, err
+		}
+		result := math.NaN()
+		for i, arg := range args {
+			n, err := toNumber(arg)
+			if err != nil {
+				return nil, err
+			}
+			if i == 0 {
+				result = n
+			} else {
+				result = f(result, n)
+			}
+		}
+		return result, nil
+	}
+}
+
+func builtinStr(m *machine, args []any) (any, error) {
+	return toString(args[0])
+}
+
+func builtinNum(m *machine, args []any) (any, error) {
+	return toNumber(args[0])
+}
+
+// builtinLen is the number of characters in a string or elements in a list.
+func builtinLen(m *machine, args []any) (any, error) {
+	switch v := args[0].(type) {
+	case []any:
+		return float64(len(v)), nil
+	case string:
+		if err := m.work(len(v)); err != nil {
+			return nil, err
+		}
+		return float64(utf8.RuneCountInString(v)), nil
+	}
+	return nil, fmt.Errorf("cannot take the length of %s", typeName(args[0]))
+}
+
+// builtinSubstr is substr(s, start, end): the characters of s from start up
+// to end, or to the end of s. Negative positions count from the end, and
+// positions past either end are clamped.
+func builtinSubstr(m *machine, args []any) (any, error) {
+	s, err := toString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := m.work(len(s)); err != nil {
+		return nil, err
+	}
+	runes := []rune(s)
+	if err := m.alloc(4 * len(runes)); err != nil {
+		return nil, err
+	}
+	position := func(arg any) (int, error) {
+		f, err := toNumber(arg)
+		if err != nil {
+			return 0, err
+		}
+		i := int(f)
+		if i < 0 {
+			i += len(runes)
+		}
+		return min(max(i, 0), len(runes)), nil
+	}
+
+	start, err := position(args[1])
+	if err != nil {
+		return nil, err
+	}
+	end := len(runes)
+	if len(args) == 3 {
+		if end, err = position(args[2]); err != nil {
+			return nil, err
+		}
+	}
+	if end <= start {
+		return "", nil
+	}
+	return string(runes[start:end]), nil
+}
+
+// builtinSplit is split(s, sep): the list of the parts of s between each
+// sep, or of its characters when sep is empty.
+func builtinSplit(m *machine, args []any) (any, error) {
+	s, err := toString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	sep, err := toString(args[1])
+	if err != nil {
+		return nil, err
+	}
+	n := strings.Count(s, sep) + 1
+	if err := m.work(len(s) + 64*n); err != nil {
+		return nil, err
+	}
+	if err := m.alloc(len(s) + listElemSize*n); err != nil {
+		return nil, err
+	}
+	parts := strings.Split(s, sep)
+	list := make([]any, len(parts))
+	for i, part := range parts {
+		list[i] = part
+	}
+	return list, nil
+}
+
+// builtinJoin is join(list, sep): the elements of list, as strings, with sep
+// between them.
+func builtinJoin(m *machine, args []any) (any, error) {
+	list, ok := args[0].([]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot join %s", typeName(args[0]))
+	}
+	sep, err := toString(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if err := m.work(64 * len(list)); err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(list))
+	size := len(sep) * max(len(list)-1, 0)
+	for i, elem := range list {
+		if parts[i], err = toString(elem); err != nil {
+			return nil, err
+		}
+		size += len(parts[i])
+	}
+	if err := m.alloc(size); err != nil {
+		return nil, err
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// builtinReplace is replace(s, old, repl): s with every old replaced by repl.
+func builtinReplace(m *machine, args []any) (any, error) {
+	var strs [3]string
+	for i := range strs {
+		s, err := toString(args[i])
+		if err != nil {
+			return nil, err
+		}
+		strs[i] = s
+	}
+	s, old, repl := strs[0], strs[1], strs[2]
+	if err := m.work(len(s)); err != nil {
+		return nil, err
+	}
+	n := strings.Count(s, old)
+	if err := m.alloc(len(s) + n*max(len(repl)-len(old), 0)); err != nil {
+		return nil, err
+	}
+	return strings.ReplaceAll(s, old, repl), nil
+}
+
+// builtinRepeat is repeat(s, n): n copies of s.
+func builtinRepeat(m *machine, args []any) (any, error) {
+	s, err := toString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	f, err := toNumber(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if f < 0 {
+		return nil, fmt.Errorf("negative count")
+	}
+	if len(s) > 0 && f > float64(m.limits.Bytes/len(s)) {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrLimit, m.limits.Bytes)
+	}
+	n := int(f)
+	if err := m.work(len(s) * n); err != nil {
+		return nil, err
+	}
+	if err := m.alloc(len(s) * n); err != nil {
+		return nil, err
+	}
+	return strings.Repeat(s, n), nil
+}
+
+// builtinContains is contains(x, v): whether the string x contains the
+// string v, or the list x has an element equal to v.
+func builtinContains(m *machine, args []any) (any, error) {
+	if list, ok := args[0].([]any); ok {
+		if err := m.work(64 * len(list)); err != nil {
+			return nil, err
+		}
+		for _, elem := range list {
+			if eq, err := equal(elem, args[1]); err == nil && eq {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	s, err := toString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	t, err := toString(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if err := m.work(len(s)); err != nil {
+		return nil, err
+	}
+	return strings.Contains(s, t), nil
+}
+
+// builtinChoice picks one of its arguments at random, or one element of a
+// list given alone.
+func builtinChoice(m *machine, args []any) (any, error) {
+	options := args
+	if list, ok := args[0].([]any); ok && len(args) == 1 {
+		options = list
+	}
+	if len(options) == 0 {
+		return nil, fmt.Errorf("nothing to choose from")
+	}
+	return options[rand.IntN(len(options))], nil
+}
+
+func builtinRound(m *machine, args []any) (any, error) {
+	f, err := toNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return math.Round(f), nil
+}
+
+// builtinRegexMatch is regexMatch(s, pattern): whether s contains a match of
+// the regular expression.
+func builtinRegexMatch(m *machine, args []any) (any, error) {
+	s, err := toString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := m.work(len(s)); err != nil {
+		return nil, err
+	}
+	return args[1].(*regexp.Regexp).MatchString(s), nil
+}
+
+// builtinRegexReplace is regexReplace(s, pattern, repl): s with every match
+// of the regular expression replaced by repl, in which $1 or ${name} stands
+// for the text of a group.
+func builtinRegexReplace(m *machine, args []any) (any, error) {
+	s, err := toString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	re := args[1].(*regexp.Regexp)
+	repl, err := toString(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if err := m.work(len(s)); err != nil {
+		return nil, err
+	}
+
+	// Each match costs a step, so no more matches are looked for than the
+	// call can afford.
+	matches := re.FindAllStringSubmatchIndex(s, m.stepsLeft()+1)
+	if err := m.step(len(matches)); err != nil {
+		return nil, err
+	}
+	// A group is part of its match, so expanding repl takes at most its own
+	// length plus the match's for every $ in it.
+	refs := strings.Count(repl, "$")
+	var out []byte
+	last := 0
+	for _, match := range matches {
+		bound := match[0] - last + len(repl) + refs*(match[1]-match[0])
+		if err := m.alloc(bound); err != nil {
+			return nil, err
+		}
+		size := len(out)
+		out = append(out, s[last:match[0]]...)
+		out = re.ExpandString(out, repl, s, match)
+		m.bytes -= bound - (len(out) - size)
+		last = match[1]
+	}
+	if err := m.alloc(len(s) - last); err != nil {
+		return nil, err
+	}
+	out = append(out, s[last:]...)
+	return string(out), nil
+}
@@ -0,0 +1,402 @@
+package script
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// A value is a string, float64, bool or []any of values, or, only as a
+// builtin's argument, a compiled *regexp.Regexp.
+
+type node interface{}
+
+type literalNode struct {
+	pos   int
+	value any
+}
+
+type paramNode struct {
+	pos   int
+	index int
+}
+
+type unaryNode struct {
+	pos int
+	op  string
+	x   node
+}
+
+type binaryNode struct {
+	pos  int
+	op   string
+	x, y node
+}
+
+type condNode struct {
+	pos             int
+	cond, then, els node
+}
+
+type indexNode struct {
+	pos      int
+	x, index node
+}
+
+type listNode struct {
+	pos   int
+	elems []node
+}
+
+type callNode struct {
+	pos  int
+	name string
+	fn   *builtin
+	args []node
+}
+
+// listElemSize is what a list element is charged in bytes, on top of its
+// own size.
+const listElemSize = 16
+
+// machine evaluates one call within its limits.
+type machine struct {
+	limits Limits
+	args   []any
+	steps  int
+	bytes  int
+}
+
+// step charges n steps.
+func (m *machine) step(n int) error {
+	m.steps += n
+	if m.steps > m.limits.Steps {
+		return fmt.Errorf("%w: more than %d steps", ErrLimit, m.limits.Steps)
+	}
+	return nil
+}
+
+// alloc charges n bytes. It is called before the memory is used.
+func (m *machine) alloc(n int) error {
+	m.bytes += n
+	if m.bytes > m.limits.Bytes {
+		return fmt.Errorf("%w: more than %d bytes", ErrLimit, m.limits.Bytes)
+	}
+	return nil
+}
+
+// stepsLeft is the number of steps the call can still take.
+func (m *machine) stepsLeft() int {
+	return max(m.limits.Steps-m.steps, 0)
+}
+
+func (m *machine) eval(n node) (any, error) {
+	if err := m.step(1); err != nil {
+		return nil, err
+	}
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+	case *paramNode:
+		return m.args[n.index], nil
+	case *unaryNode:
+		x, err := m.eval(n.x)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			return !truthy(x), nil
+		}
+		f, err := toNumber(x)
+		return -f, err
+	case *binaryNode:
+		return m.binary(n)
+	case *condNode:
+		cond, err := m.eval(n.cond)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(cond) {
+			return m.eval(n.then)
+		}
+		return m.eval(n.els)
+	case *indexNode:
+		return m.index(n)
+	case *listNode:
+		if err := m.alloc(listElemSize * len(n.elems)); err != nil {
+			return nil, err
+		}
+		list := make([]any, 0, len(n.elems))
+		for _, elem := range n.elems {
+			v, err := m.eval(elem)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case *callNode:
+		args := make([]any, 0, len(n.args))
+		for _, arg := range n.args {
+			v, err := m.eval(arg)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, v)
+		}
+		v, err := n.fn.call(m, args)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", n.name, err)
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("unknown node %T", n)
+}
+
+func (m *machine) binary(n *binaryNode) (any, error) {
+	x, err := m.eval(n.x)
+	if err != nil {
+		return nil, err
+	}
+	// && and || only evaluate their right side when it decides the result.
+	switch n.op {
+	case "&&":
+		if !truthy(x) {
+			return false, nil
+		}
+		y, err := m.eval(n.y)
+		return truthy(y), err
+	case "||":
+		if truthy(x) {
+			return true, nil
+		}
+		y, err := m.eval(n.y)
+		return truthy(y), err
+	}
+	y, err := m.eval(n.y)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==", "!=":
+		eq, err := equal(x, y)
+		if err != nil {
+			return nil, err
+		}
+		return eq == (n.op == "=="), nil
+	case "<", "<=", ">", ">=":
+		c, err := compare(x, y)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	case "+":
+		return m.add(x, y)
+	}
+
+	a, err := toNumber(x)
+	if err != nil {
+		return nil, err
+	}
+	b, err := toNumber(y)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return a / b, nil
+	}
+	if b == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	return math.Mod(a, b), nil
+}
+
+// add adds numbers, joins lists and otherwise concatenates strings.
+func (m *machine) add(x, y any) (any, error) {
+	if a, ok := x.(float64); ok {
+		if b, ok := y.(float64); ok {
+			return a + b, nil
+		}
+	}
+	if a, ok := x.([]any); ok {
+		b, ok := y.([]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot add %s to a list", typeName(y))
+		}
+		if err := m.alloc(listElemSize * (len(a) + len(b))); err != nil {
+			return nil, err
+		}
+		return append(append(make([]any, 0, len(a)+len(b)), a...), b...), nil
+	}
+	a, err := toString(x)
+	if err != nil {
+		return nil, err
+	}
+	b, err := toString(y)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.alloc(len(a) + len(b)); err != nil {
+		return nil, err
+	}
+	return a + b, nil
+}
+
+// index returns an element of a list or a character of a string. Negative
+// indexes count from the end.
+func (m *machine) index(n *indexNode) (any, error) {
+	x, err := m.eval(n.x)
+	if err != nil {
+		return nil, err
+	}
+	v, err := m.eval(n.index)
+	if err != nil {
+		return nil, err
+	}
+	f, err := toNumber(v)
+	if err != nil {
+		return nil, err
+	}
+	i := int(f)
+
+	switch x := x.(type) {
+	case []any:
+		if i < 0 {
+			i += len(x)
+		}
+		if i < 0 || i >= len(x) {
+			return nil, fmt.Errorf("index %d out of range", int(f))
+		}
+		return x[i], nil
+	case string:
+		if err := m.step(1 + len(x)/64); err != nil {
+			return nil, err
+		}
+		runes := []rune(x)
+		if i < 0 {
+			i += len(runes)
+		}
+		if i < 0 || i >= len(runes) {
+			return nil, fmt.Errorf("index %d out of range", int(f))
+		}
+		return string(runes[i]), nil
+	}
+	return nil, fmt.Errorf("cannot index %s", typeName(x))
+}
+
+// truthy reports whether v counts as true: false, 0, "" and the empty list
+// do not.
+func truthy(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []any:
+		return len(v) > 0
+	}
+	return false
+}
+
+func toNumber(v any) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", truncate(v))
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("cannot use %s as a number", typeName(v))
+}
+
+func toString(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", fmt.Errorf("cannot use %s as a string", typeName(v))
+}
+
+// equal compares values of the same type. Lists are not comparable.
+func equal(x, y any) (bool, error) {
+	switch x.(type) {
+	case []any:
+		return false, fmt.Errorf("cannot compare lists")
+	}
+	switch y.(type) {
+	case []any:
+		return false, fmt.Errorf("cannot compare lists")
+	}
+	return x == y, nil
+}
+
+// compare orders two numbers or two strings.
+func compare(x, y any) (int, error) {
+	switch a := x.(type) {
+	case float64:
+		if b, ok := y.(float64); ok {
+			return cmp.Compare(a, b), nil
+		}
+	case string:
+		if b, ok := y.(string); ok {
+			return cmp.Compare(a, b), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %s with %s", typeName(x), typeName(y))
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case []any:
+		return "a list"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// truncate shortens s for an error message.
+func truncate(s string) string {
+	const n = 32
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
+}
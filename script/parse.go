@@ -0,0 +1,393 @@
+package script
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxDepth bounds how deeply expressions nest, which bounds the stack used
+// to parse and evaluate them.
+const maxDepth = 64
+
+// Error is a mistake in a function's body, at a line and column counted from 1.
+type Error struct {
+	Line int
+	Col  int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	pos  int
+	text string
+	// value is a number or string literal's value.
+	value any
+}
+
+// operators are the punctuation tokens, longest first.
+var operators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"+", "-", "*", "/", "%", "<", ">", "!", "?", ":", "(", ")", "[", "]", ",",
+}
+
+type parser struct {
+	src    string
+	params []string
+	tok    token
+	next   int
+	depth  int
+}
+
+// parse parses body as one expression over params.
+func parse(body string, params []string) (node, error) {
+	p := &parser{src: body, params: params}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokEOF {
+		return nil, p.errorf(p.tok.pos, "empty body")
+	}
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf(p.tok.pos, "unexpected %s", p.tok.text)
+	}
+	return n, nil
+}
+
+func (p *parser) errorf(pos int, format string, args ...any) error {
+	line := 1 + strings.Count(p.src[:pos], "\n")
+	col := 1 + utf8.RuneCountInString(p.src[strings.LastIndexByte(p.src[:pos], '\n')+1:pos])
+	return &Error{Line: line, Col: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// advance reads the next token.
+func (p *parser) advance() error {
+	for p.next < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.next:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		p.next += size
+	}
+	start := p.next
+	if start == len(p.src) {
+		p.tok = token{kind: tokEOF, pos: start, text: "end of body"}
+		return nil
+	}
+
+	r, _ := utf8.DecodeRuneInString(p.src[start:])
+	switch {
+	case r >= '0' && r <= '9':
+		end := start
+		for end < len(p.src) && (p.src[end] >= '0' && p.src[end] <= '9' || p.src[end] == '.') {
+			end++
+		}
+		v, err := strconv.ParseFloat(p.src[start:end], 64)
+		if err != nil {
+			return p.errorf(start, "invalid number %s", p.src[start:end])
+		}
+		p.tok = token{kind: tokNumber, pos: start, text: p.src[start:end], value: v}
+		p.next = end
+	case r == '"' || r == '\'':
+		s, end, err := p.scanString(start)
+		if err != nil {
+			return err
+		}
+		p.tok = token{kind: tokString, pos: start, text: p.src[start:end], value: s}
+		p.next = end
+	case r == '_' || unicode.IsLetter(r):
+		end := start
+		for end < len(p.src) {
+			r, size := utf8.DecodeRuneInString(p.src[end:])
+			if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				break
+			}
+			end += size
+		}
+		p.tok = token{kind: tokIdent, pos: start, text: p.src[start:end]}
+		p.next = end
+	default:
+		for _, op := range operators {
+			if strings.HasPrefix(p.src[start:], op) {
+				p.tok = token{kind: tokPunct, pos: start, text: op}
+				p.next = start + len(op)
+				return nil
+			}
+		}
+		return p.errorf(start, "unexpected character %q", r)
+	}
+	return nil
+}
+
+// scanString reads the string literal starting at start, returning its value
+// and where it ends.
+func (p *parser) scanString(start int) (string, int, error) {
+	quote := p.src[start]
+	var b strings.Builder
+	for i := start + 1; i < len(p.src); i++ {
+		c := p.src[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\n':
+			return "", 0, p.errorf(start, "unterminated string")
+		case c == '\\':
+			i++
+			if i == len(p.src) {
+				return "", 0, p.errorf(start, "unterminated string")
+			}
+			switch p.src[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"', '\'':
+				b.WriteByte(p.src[i])
+			default:
+				return "", 0, p.errorf(i-1, "unknown escape \\%c", p.src[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, p.errorf(start, "unterminated string")
+}
+
+func (p *parser) is(text string) bool {
+	return p.tok.kind == tokPunct && p.tok.text == text
+}
+
+func (p *parser) expect(text string) error {
+	if !p.is(text) {
+		return p.errorf(p.tok.pos, "expected %s, found %s", text, p.tok.text)
+	}
+	return p.advance()
+}
+
+// expr parses a conditional expression: or ("?" expr ":" expr)?
+func (p *parser) expr() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, p.errorf(p.tok.pos, "expression nested too deeply")
+	}
+
+	cond, err := p.binary(0)
+	if err != nil || !p.is("?") {
+		return cond, err
+	}
+	pos := p.tok.pos
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	then, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	els, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	return &condNode{pos: pos, cond: cond, then: then, els: els}, nil
+}
+
+// precedence lists the binary operators from the loosest binding.
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) binary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.unary()
+	}
+	x, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokPunct && slices.Contains(precedence[level], p.tok.text) {
+		op, pos := p.tok.text, p.tok.pos
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		y, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryNode{pos: pos, op: op, x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.is("!") || p.is("-") {
+		op, pos := p.tok.text, p.tok.pos
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxDepth {
+			return nil, p.errorf(pos, "expression nested too deeply")
+		}
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{pos: pos, op: op, x: x}, nil
+	}
+	return p.postfix()
+}
+
+// postfix parses a primary expression followed by any number of [index].
+func (p *parser) postfix() (node, error) {
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("[") {
+		pos := p.tok.pos
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		index, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		x = &indexNode{pos: pos, x: x, index: index}
+	}
+	return x, nil
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.tok
+	switch {
+	case tok.kind == tokNumber || tok.kind == tokString:
+		return &literalNode{pos: tok.pos, value: tok.value}, p.advance()
+	case tok.kind == tokIdent:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.is("(") {
+			return p.call(tok)
+		}
+		switch tok.text {
+		case "true":
+			return &literalNode{pos: tok.pos, value: true}, nil
+		case "false":
+			return &literalNode{pos: tok.pos, value: false}, nil
+		}
+		for i, name := range p.params {
+			if name == tok.text {
+				return &paramNode{pos: tok.pos, index: i}, nil
+			}
+		}
+		return nil, p.errorf(tok.pos, "unknown name %s", tok.text)
+	case p.is("("):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(")")
+	case p.is("["):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		elems, err := p.list("]")
+		if err != nil {
+			return nil, err
+		}
+		return &listNode{pos: tok.pos, elems: elems}, nil
+	}
+	return nil, p.errorf(tok.pos, "unexpected %s", tok.text)
+}
+
+// call parses the arguments of a call to the builtin named by fn and checks
+// them against it.
+func (p *parser) call(fn token) (node, error) {
+	b, ok := builtins[fn.text]
+	if !ok {
+		return nil, p.errorf(fn.pos, "unknown function %s", fn.text)
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	args, err := p.list(")")
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < b.minArgs || (b.maxArgs >= 0 && len(args) > b.maxArgs) {
+		return nil, p.errorf(fn.pos, "%s takes %s", fn.text, b.arity())
+	}
+	if b.pattern > 0 {
+		lit, ok := args[b.pattern].(*literalNode)
+		var pattern string
+		if ok {
+			pattern, ok = lit.value.(string)
+		}
+		if !ok {
+			return nil, p.errorf(fn.pos, "the pattern of %s must be a string literal", fn.text)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, p.errorf(lit.pos, "invalid pattern: %v", err)
+		}
+		args[b.pattern] = &literalNode{pos: lit.pos, value: re}
+	}
+	return &callNode{pos: fn.pos, name: fn.text, fn: b, args: args}, nil
+}
+
+// list parses comma-separated expressions up to and including end.
+func (p *parser) list(end string) ([]node, error) {
+	var elems []node
+	for !p.is(end) {
+		if len(elems) > 0 {
+			if !p.is(",") {
+				return nil, p.errorf(p.tok.pos, "expected , or %s, found %s", end, p.tok.text)
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, x)
+	}
+	return elems, p.advance()
+}
@@ -0,0 +1,159 @@
+// Package script is a small expression language for the template functions
+// users define for their cards. A function is a single expression over its
+// parameters, built from literals, operators and a fixed set of builtins such
+// as upper, split and regexReplace. The language has no loops, no recursion
+// and no way to reach the file system, the network or the clock, and every
+// call runs within a budget of steps and bytes, so a function cannot hang or
+// exhaust the server whatever it is given.
+package script
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"regexp"
+)
+
+// The types a function's result is converted to.
+const (
+	OutputString  = "string"
+	OutputHTML    = "html"
+	OutputBoolean = "boolean"
+	OutputNumber  = "number"
+)
+
+const (
+	// MaxBodyLength bounds the size of a function's body in bytes.
+	MaxBodyLength = 4096
+	// MaxParams bounds the number of parameters a function takes.
+	MaxParams = 8
+)
+
+// ErrLimit is returned when a call runs out of its budget.
+var ErrLimit = errors.New("function exceeded its limits")
+
+// namePattern is what function and parameter names look like, so that a
+// function can be called from a template.
+var namePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,63}$`)
+
+// Limits is the budget of one call.
+type Limits struct {
+	// Steps bounds the work done: every operation costs a step, and builtins
+	// a step more for every 64 bytes or list element they go through.
+	Steps int
+	// Bytes bounds the memory used by the strings and lists the call
+	// creates.
+	Bytes int
+}
+
+// DefaultLimits are the limits functions are compiled with.
+var DefaultLimits = Limits{Steps: 10_000, Bytes: 1 << 20}
+
+// Function is a compiled function.
+type Function struct {
+	Name   string
+	Params []string
+	Output string
+	Limits Limits
+	body   node
+}
+
+// Compile checks and compiles a function. Its body may only use its
+// parameters and the builtins, call them with the right number of arguments
+// and give regular expressions as valid string literals.
+func Compile(name string, params []string, output, body string) (*Function, error) {
+	if !namePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid function name %q", name)
+	}
+	if len(params) > MaxParams {
+		return nil, fmt.Errorf("a function takes at most %d parameters", MaxParams)
+	}
+	for i, param := range params {
+		if !namePattern.MatchString(param) || param == "true" || param == "false" {
+			return nil, fmt.Errorf("invalid parameter name %q", param)
+		}
+		for _, other := range params[:i] {
+			if other == param {
+				return nil, fmt.Errorf("parameter %q is listed twice", param)
+			}
+		}
+	}
+	switch output {
+	case OutputString, OutputHTML, OutputBoolean, OutputNumber:
+	default:
+		return nil, fmt.Errorf("unknown output type %q", output)
+	}
+	if len(body) > MaxBodyLength {
+		return nil, fmt.Errorf("body is longer than %d bytes", MaxBodyLength)
+	}
+
+	n, err := parse(body, params)
+	if err != nil {
+		return nil, err
+	}
+	return &Function{Name: name, Params: params, Output: output, Limits: DefaultLimits, body: n}, nil
+}
+
+// Call runs the function. Arguments may be strings, template.HTML, numbers,
+// bools or nil, which is the empty string. The result is a string,
+// template.HTML, bool or float64 according to the function's output type.
+// Its signature lets it be used directly in a template.FuncMap.
+func (f *Function) Call(args ...any) (any, error) {
+	if len(args) != len(f.Params) {
+		return nil, fmt.Errorf("%s: takes %d arguments, got %d", f.Name, len(f.Params), len(args))
+	}
+	values := make([]any, len(args))
+	for i, arg := range args {
+		v, err := fromGo(arg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: argument %d: %w", f.Name, i+1, err)
+		}
+		values[i] = v
+	}
+
+	m := &machine{limits: f.Limits, args: values}
+	result, err := m.eval(f.body)
+	if err == nil {
+		result, err = convertOutput(result, f.Output)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return result, nil
+}
+
+// fromGo converts a template argument into a value of the language.
+func fromGo(arg any) (any, error) {
+	switch v := arg.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case template.HTML:
+		return string(v), nil
+	case bool:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", arg)
+	}
+}
+
+func convertOutput(v any, output string) (any, error) {
+	switch output {
+	case OutputBoolean:
+		return truthy(v), nil
+	case OutputNumber:
+		return toNumber(v)
+	case OutputHTML:
+		s, err := toString(v)
+		return template.HTML(s), err
+	default:
+		return toString(v)
+	}
+}
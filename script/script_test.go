@@ -0,0 +1,163 @@
+package script
+
+import (
+	"errors"
+	"html/template"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	tests := []struct {
+		params []string
+		output string
+		body   string
+		args   []any
+		want   any
+	}{
+		{[]string{"s"}, OutputString, "upper(s)", []any{"abc"}, "ABC"},
+		{[]string{"s"}, OutputString, `s + "!" + 2`, []any{template.HTML("<b>x</b>")}, "<b>x</b>!2"},
+		{[]string{"a", "b"}, OutputNumber, "a * (b + 1) % 7", []any{3, 4.0}, 1.0},
+		{[]string{"n"}, OutputString, `num(n) >= 10 ? "many" : num(n) == 1 ? "one" : "few"`, []any{"1"}, "one"},
+		{[]string{"s"}, OutputBoolean, `s && !contains(s, "x")`, []any{nil}, false},
+		{[]string{"s"}, OutputString, `join(split(s, ","), " / ")`, []any{"a,b,c"}, "a / b / c"},
+		{[]string{"s"}, OutputString, `split(s, ",")[-1] + s[0]`, []any{"a,b,c"}, "ca"},
+		{[]string{"s"}, OutputString, `substr(s, 1, -1)`, []any{"日本語です"}, "本語で"},
+		{[]string{"s"}, OutputNumber, `len(s) + len([1, 2])`, []any{"日本"}, 4.0},
+		{nil, OutputString, `replace("a-b-c", "-", "+")`, nil, "a+b+c"},
+		{nil, OutputNumber, `max(1, round(2.6), min(9, 4))`, nil, 4.0},
+		{nil, OutputBoolean, `regexMatch("card 12", "[0-9]+$")`, nil, true},
+	}
+	for _, tt := range tests {
+		f, err := Compile("f", tt.params, tt.output, tt.body)
+		if err != nil {
+			t.Errorf("Compile(%q) error: %v", tt.body, err)
+			continue
+		}
+		got, err := f.Call(tt.args...)
+		if err != nil {
+			t.Errorf("%q: Call error: %v", tt.body, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q = %#v, want %#v", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestFurigana(t *testing.T) {
+	f, err := Compile("furigana", []string{"text"}, OutputHTML,
+		`regexReplace(escape(text), " ?([^ \\[]+)\\[([^\\]]+)\\]", "<ruby>$1<rt>$2</rt></ruby>")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := f.Call("日本[にほん] 語[ご]<")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := template.HTML("<ruby>日本<rt>にほん</rt></ruby><ruby>語<rt>ご</rt></ruby>&lt;")
+	if got != want {
+		t.Errorf("furigana = %q, want %q", got, want)
+	}
+}
+
+func TestChoice(t *testing.T) {
+	f, err := Compile("pick", []string{"options"}, OutputString, `choice(split(options, "|"))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 20 {
+		got, err := f.Call("red|green|blue")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Contains([]any{"red", "green", "blue"}, got) {
+			t.Fatalf("choice = %q", got)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []string
+		output string
+		body   string
+		want   string
+	}{
+		{"1f", nil, OutputString, `"x"`, "invalid function name"},
+		{"f", []string{"a", "a"}, OutputString, `a`, "listed twice"},
+		{"f", nil, "list", `"x"`, "unknown output type"},
+		{"f", nil, OutputString, ``, "empty body"},
+		{"f", nil, OutputString, `b`, "1:1: unknown name b"},
+		{"f", []string{"s"}, OutputString, "s +\n  open(s)", "2:3: unknown function open"},
+		{"f", []string{"s"}, OutputString, `upper(s, s)`, "upper takes 1 argument"},
+		{"f", []string{"s"}, OutputString, `regexMatch(s, s)`, "must be a string literal"},
+		{"f", []string{"s"}, OutputString, `regexMatch(s, "(")`, "invalid pattern"},
+		{"f", []string{"s"}, OutputString, `(s`, "expected )"},
+		{"f", []string{"s"}, OutputString, `"abc`, "unterminated string"},
+		{"f", []string{"s"}, OutputString, strings.Repeat("(", 100) + "s" + strings.Repeat(")", 100), "nested too deeply"},
+		{"f", nil, OutputString, strings.Repeat("1+", MaxBodyLength), "longer than"},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.name, tt.params, tt.output, tt.body)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Compile(%q) error = %v, want %q", tt.body, err, tt.want)
+		}
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	tests := []struct {
+		body string
+		args []any
+		want string
+	}{
+		{`num(s)`, []any{"abc"}, `num: "abc" is not a number`},
+		{`s[5]`, []any{"abc"}, "index 5 out of range"},
+		{`[s] == [s]`, []any{"abc"}, "cannot compare lists"},
+		{`s >= 10`, []any{"abc"}, "cannot compare a string with a number"},
+		{`1 / (len(s) - 3)`, []any{"abc"}, "division by zero"},
+	}
+	for _, tt := range tests {
+		f, err := Compile("f", []string{"s"}, OutputString, tt.body)
+		if err != nil {
+			t.Fatalf("Compile(%q) error: %v", tt.body, err)
+		}
+		_, err = f.Call(tt.args...)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q error = %v, want %q", tt.body, err, tt.want)
+		}
+	}
+
+	f, _ := Compile("f", []string{"s"}, OutputString, `s`)
+	if _, err := f.Call(); err == nil {
+		t.Error("Call with too few arguments succeeded")
+	}
+	if _, err := f.Call([]string{"x"}); err == nil {
+		t.Error("Call with a slice argument succeeded")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []string{
+		// Memory: results far larger than the budget, built in few steps.
+		`repeat(repeat(repeat(s, 1000), 1000), 1000)`,
+		`repeat(s, 100000000)`,
+		`join(split(repeat(s, 200000), ""), "--------")`,
+		`regexReplace(repeat(s, 100000), ".+", "$0$0$0$0$0$0$0$0$0$0")`,
+		// Steps: long chains of work over a large string, or many matches.
+		`regexReplace(repeat(s, 100000), ".", "x")`,
+		strings.Repeat(`len(repeat(s, 100000)) + `, 100) + "0",
+	}
+	for _, body := range tests {
+		f, err := Compile("f", []string{"s"}, OutputString, body)
+		if err != nil {
+			t.Fatalf("Compile(%q) error: %v", body, err)
+		}
+		if _, err := f.Call("ab"); !errors.Is(err, ErrLimit) {
+			t.Errorf("%q error = %v, want ErrLimit", body, err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+	algorithm "github.com/threeroundsoftware/voidabyss/algo"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+	"github.com/threeroundsoftware/voidabyss/render"
+)
+
+// ErrNoTypedAnswer is returned when an answer is typed for a card whose
+// template does not ask for one with {{type:Field}}.
+var ErrNoTypedAnswer = errors.New("card has no typed answer")
+
+// CheckAnswerRequest compares an answer typed for one of the user's cards
+// with the card's field, without reviewing the card.
+type CheckAnswerRequest struct {
+	CardID      string `param:"cardID" validate:"required,alphanum,len=10"`
+	TypedAnswer string `json:"typed_answer" validate:"max=1024"`
+}
+
+// TypedAnswerResponse is a typed answer compared with the expected one.
+type TypedAnswerResponse struct {
+	Field       string `json:"field"`
+	TypedAnswer string `json:"typed_answer"`
+	Expected    string `json:"expected"`
+	Correct     bool   `json:"correct"`
+	// Distance is the number of characters that differ.
+	Distance int `json:"distance"`
+	// Diff marks up both answers character by character.
+	Diff            string `json:"diff"`
+	SuggestedRating int64  `json:"suggested_rating"`
+}
+
+// CheckAnswerResponse is the card rendered with the typed answer compared on
+// its back, and the comparison itself.
+type CheckAnswerResponse struct {
+	Card   RenderedCardResponse `json:"card"`
+	Answer TypedAnswerResponse  `json:"answer"`
+}
+
+// AnswerCheck is an answer typed for a card compared with the card's field.
+type AnswerCheck struct {
+	Field string
+	Typed string
+	render.Comparison
+	SuggestedRating algorithm.Rating
+}
+
+// FuncCheckAnswerHandler compares an answer typed for a card with the card's
+// field and renders the card with the comparison on its back, so that it can
+// be shown before the card is rated.
+func FuncCheckAnswerHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req CheckAnswerRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating check answer request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		ctx := c.Request().Context()
+		card, note, err := getOwnedCard(ctx, app.Queries, user.ID, req.CardID)
+		if err != nil {
+			return respondAnswerError(c, err)
+		}
+		content, err := loadCardContent(ctx, app.Queries, card, note)
+		if err != nil {
+			return respondAnswerError(c, err)
+		}
+		check, err := compareTypedAnswer(content, req.TypedAnswer)
+		if err != nil {
+			return respondAnswerError(c, err)
+		}
+		rendered, err := render.RenderAnswer(content.Template.FrontHtml, content.Template.BackHtml, content.Data, content.Ordinal, content.Funcs, req.TypedAnswer)
+		if err != nil {
+			return respondAnswerError(c, err)
+		}
+		return c.JSON(http.StatusOK, CheckAnswerResponse{
+			Card:   convertRenderedCardToResponse(card, content, rendered),
+			Answer: convertAnswerCheckToResponse(check),
+		})
+	}
+}
+
+// CheckTypedAnswer compares an answer typed for card with the field its
+// template asks for.
+func CheckTypedAnswer(ctx context.Context, q *database.Queries, card database.Card, note database.Note, typed string) (AnswerCheck, error) {
+	content, err := loadCardContent(ctx, q, card, note)
+	if err != nil {
+		return AnswerCheck{}, err
+	}
+	return compareTypedAnswer(content, typed)
+}
+
+// compareTypedAnswer compares typed with the field named by the first
+// {{type:Field}} on the front of the card's template.
+func compareTypedAnswer(content cardContent, typed string) (AnswerCheck, error) {
+	answers := render.TypeAnswers(content.Template.FrontHtml)
+	if len(answers) == 0 {
+		return AnswerCheck{}, ErrNoTypedAnswer
+	}
+	answer := answers[0]
+	comparison := render.Compare(typed, render.AnswerText(content.Data[answer.Field]), answer.Options)
+	return AnswerCheck{
+		Field:           answer.Field,
+		Typed:           typed,
+		Comparison:      comparison,
+		SuggestedRating: suggestRating(comparison),
+	}, nil
+}
+
+// suggestRating suggests a rating for a typed answer: good when it is
+// correct, hard when it is off by no more than one character in five, and
+// again otherwise.
+func suggestRating(c render.Comparison) algorithm.Rating {
+	switch {
+	case c.Correct:
+		return algorithm.Good
+	case c.Distance*5 <= utf8.RuneCountInString(c.Expected):
+		return algorithm.Hard
+	default:
+		return algorithm.Again
+	}
+}
+
+// checkReviewAnswer checks the answer typed for a review, if there is one and
+// it has not been checked yet, and rates the review with the suggested rating
+// when it has no rating of its own.
+func checkReviewAnswer(ctx context.Context, q *database.Queries, card database.Card, note database.Note, input *ReviewInput) error {
+	if !input.TypedAnswer.Valid || input.Answer != nil {
+		return nil
+	}
+	check, err := CheckTypedAnswer(ctx, q, card, note, input.TypedAnswer.String)
+	if err != nil {
+		return err
+	}
+	input.Answer = &check
+	if input.Grade == 0 {
+		input.Grade = check.SuggestedRating
+	}
+	return nil
+}
+
+// respondAnswerError writes the response for an error checking a typed
+// answer.
+func respondAnswerError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrCardNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Card not found"})
+	case errors.Is(err, ErrNoTypedAnswer):
+		return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{Error: "Card has no answer to type"})
+	case errors.Is(err, render.ErrInvalidTemplate):
+		logging.SlogLogger.Error("Error rendering card", "error", err)
+		return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{Error: "Card template is invalid"})
+	}
+	logging.SlogLogger.Error("Error checking typed answer", "error", err)
+	return c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error: "Failed to check answer",
+	})
+}
+
+func convertAnswerCheckToResponse(check AnswerCheck) TypedAnswerResponse {
+	return TypedAnswerResponse{
+		Field:           check.Field,
+		TypedAnswer:     check.Typed,
+		Expected:        check.Expected,
+		Correct:         check.Correct,
+		Distance:        check.Distance,
+		Diff:            string(check.Diff),
+		SuggestedRating: int64(check.SuggestedRating),
+	}
+}
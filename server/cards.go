@@ -3,9 +3,11 @@ package server
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"html/template"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/threeroundsoftware/voidabyss/app"
 	"github.com/threeroundsoftware/voidabyss/database"
 	"github.com/threeroundsoftware/voidabyss/internal/logging"
+	"github.com/threeroundsoftware/voidabyss/render"
 )
 
 // GetDeckRequest defines the structure for route parameters with validation
@@ -53,6 +56,8 @@ type CombinedCardResponse struct {
 	FieldName       string `json:"field_name"`
 	FrontContent    string `json:"front_content"`
 	BackContent     string `json:"back_content"`
+	// Fields holds every field of the note by name.
+	Fields map[string]string `json:"fields"`
 }
 
 type CustomDeckResponse struct {
@@ -135,19 +140,16 @@ func FuncUserCardsByDeck(app *app.App) echo.HandlerFunc {
 					NoteFieldID:     convertNullString(row.NoteFieldID),
 					FrontContent:    "",
 					BackContent:     "",
+					Fields:          make(map[string]string),
 				}
+			}
 
-				fieldName := convertNullString(row.FieldName)
-				fieldContent := convertNullString(row.FieldContent)
-				if fieldName == "Front" {
-					card.FrontContent = fieldContent
-				} else if fieldName == "Back" {
-					card.BackContent = fieldContent
-				}
-
-				// Update the map.
-				cardMap[row.CardID] = card
+			if row.FieldName.Valid {
+				card.Fields[row.FieldName.String] = convertNullString(row.FieldContent)
 			}
+
+			// Update the map.
+			cardMap[row.CardID] = card
 		}
 
 		// verify ownership
@@ -158,8 +160,27 @@ func FuncUserCardsByDeck(app *app.App) echo.HandlerFunc {
 			})
 		}
 
+		// front and back content are the note type's first two fields
+		noteTypeFields := make(map[string][]database.NoteTypeField)
 		var cards []CombinedCardResponse
 		for _, card := range cardMap {
+			fields, ok := noteTypeFields[card.NoteTypeID]
+			if !ok {
+				fields, err = app.Queries.ListNoteTypeFields(c.Request().Context(), card.NoteTypeID)
+				if err != nil {
+					logging.SlogLogger.Error("Error retrieving note type fields", "error", err, "note type", card.NoteTypeID)
+					return c.JSON(http.StatusInternalServerError, ErrorResponse{
+						Error: "Failed to retrieve cards",
+					})
+				}
+				noteTypeFields[card.NoteTypeID] = fields
+			}
+			if len(fields) > 0 {
+				card.FrontContent = card.Fields[fields[0].Name]
+			}
+			if len(fields) > 1 {
+				card.BackContent = card.Fields[fields[1].Name]
+			}
 			cards = append(cards, card)
 		}
 
@@ -171,42 +192,186 @@ func FuncUserCardsByDeck(app *app.App) echo.HandlerFunc {
 	}
 }
 
-// createOneCardWithFocus is if you want to store "FocusCloze" in the card's status or a custom column.
-func createOneCardWithFocus(ctx context.Context, q *database.Queries, noteID, templateID string, focus int) error {
-	// maybe store FocusCloze in 'status' or a custom col
-	statusVal := fmt.Sprintf("clozeFocus=%d", focus)
-	log.Printf("statusVal: %s", statusVal)
+// RenderedCardResponse is a card's front and back rendered from its template.
+type RenderedCardResponse struct {
+	CardID  string `json:"card_id"`
+	Ordinal int    `json:"ordinal"`
+	Front   string `json:"front"`
+	Back    string `json:"back"`
+	Css     string `json:"css"`
+}
 
-	_, err := q.CreateCard(ctx, database.CreateCardParams{
-		NoteID:         noteID,
-		CardTemplateID: templateID,
-		DueDate:        sql.NullTime{},
-		Stability:      sql.NullFloat64{},
-		Difficulty:     sql.NullFloat64{},
-		Interval:       sql.NullInt64{},
-		Status:         sql.NullString{String: "new", Valid: true},
-	})
-	return err
+// FuncRenderCardHandler renders one of the user's cards for study.
+func FuncRenderCardHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req CardActionRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating card request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		ctx := c.Request().Context()
+		card, note, err := getOwnedCard(ctx, app.Queries, user.ID, req.CardID)
+		if errors.Is(err, ErrCardNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Card not found",
+			})
+		}
+		if err != nil {
+			logging.SlogLogger.Error("Error retrieving card", "user", user.ID, "card", req.CardID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to render card",
+			})
+		}
+
+		response, err := RenderCard(ctx, app.Queries, card, note)
+		if errors.Is(err, render.ErrInvalidTemplate) {
+			logging.SlogLogger.Error("Error rendering card", "card", card.ID, "error", err)
+			return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+				Error: "Card template is invalid",
+			})
+		}
+		if err != nil {
+			logging.SlogLogger.Error("Error rendering card", "card", card.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to render card",
+			})
+		}
+		return c.JSON(http.StatusOK, response)
+	}
+}
+
+// RenderCard renders card's template against its note's fields.
+func RenderCard(ctx context.Context, q *database.Queries, card database.Card, note database.Note) (RenderedCardResponse, error) {
+	content, err := loadCardContent(ctx, q, card, note)
+	if err != nil {
+		return RenderedCardResponse{}, err
+	}
+	rendered, err := render.Render(content.Template.FrontHtml, content.Template.BackHtml, content.Data, content.Ordinal, content.Funcs)
+	if err != nil {
+		return RenderedCardResponse{}, err
+	}
+	return convertRenderedCardToResponse(card, content, rendered), nil
+}
+
+// cardContent is what a card is rendered from.
+type cardContent struct {
+	Template database.CardTemplate
+	// Data is the note's fields as the template sees them.
+	Data    map[string]any
+	Ordinal int
+	Funcs   template.FuncMap
 }
 
-// createOneCard is a simple helper that inserts a single "cards" row referencing noteID and templateID.
-func createOneCard(ctx context.Context, q *database.Queries, noteID, templateID string) error {
+// loadCardContent loads what card is rendered from.
+func loadCardContent(ctx context.Context, q *database.Queries, card database.Card, note database.Note) (cardContent, error) {
+	tpl, err := q.GetCardTemplate(ctx, card.CardTemplateID)
+	if err != nil {
+		return cardContent{}, fmt.Errorf("failed to get card template: %w", err)
+	}
+	fields, err := q.ListFieldsByNote(ctx, card.NoteID)
+	if err != nil {
+		return cardContent{}, fmt.Errorf("failed to get note fields: %w", err)
+	}
+	values := make(map[string]string, len(fields))
+	for _, f := range fields {
+		values[f.FieldName] = f.FieldContent
+	}
+	schema, err := q.ListNoteTypeFields(ctx, note.NoteTypeID)
+	if err != nil {
+		return cardContent{}, fmt.Errorf("failed to get note type fields: %w", err)
+	}
+
+	ordinal, err := clozeOrdinal(ctx, q, card, values)
+	if err != nil {
+		return cardContent{}, err
+	}
+	funcs, err := userTemplateFuncs(ctx, q, note.OwnerID)
+	if err != nil {
+		return cardContent{}, err
+	}
+	return cardContent{Template: tpl, Data: renderData(schema, values), Ordinal: ordinal, Funcs: funcs}, nil
+}
+
+func convertRenderedCardToResponse(card database.Card, content cardContent, rendered render.Card) RenderedCardResponse {
+	return RenderedCardResponse{
+		CardID:  card.ID,
+		Ordinal: content.Ordinal,
+		Front:   string(rendered.Front),
+		Back:    string(rendered.Back),
+		Css:     convertNullString(content.Template.Css),
+	}
+}
+
+// clozeOrdinal is the cloze number card tests. Cards made before ordinals
+// were stored have none until their note's cards are next generated; until
+// then the note's cards of the same template are matched, in id order, to the
+// note's cloze numbers in ascending order. It is 0 when the note has no
+// clozes.
+func clozeOrdinal(ctx context.Context, q *database.Queries, card database.Card, fields map[string]string) (int, error) {
+	if card.Ordinal > 0 {
+		return int(card.Ordinal), nil
+	}
+	var numbers []int
+	for _, content := range fields {
+		numbers = append(numbers, findAllPlaceholderNumbers(content)...)
+	}
+	if len(numbers) == 0 {
+		return 0, nil
+	}
+	numbers = uniqueIntSlice(numbers)
+	slices.Sort(numbers)
+
+	cards, err := q.ListCardsByNote(ctx, card.NoteID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list note cards: %w", err)
+	}
+	i := 0
+	for _, sibling := range cards {
+		if sibling.ID == card.ID {
+			break
+		}
+		if sibling.CardTemplateID == card.CardTemplateID {
+			i++
+		}
+	}
+	return numbers[i%len(numbers)], nil
+}
+
+// createOneCard is a simple helper that inserts a single new "cards" row
+// referencing noteID and templateID.
+func createOneCard(ctx context.Context, q *database.Queries, noteID, templateID string, ordinal int64) error {
 	_, err := q.CreateCard(ctx, database.CreateCardParams{
 		NoteID:         noteID,
 		CardTemplateID: templateID,
-		DueDate:        sql.NullTime{Time: time.Now(), Valid: true},
+		DueDate:        sql.NullTime{Time: time.Now().UTC(), Valid: true},
 		Stability:      sql.NullFloat64{},
 		Difficulty:     sql.NullFloat64{},
 		Interval:       sql.NullInt64{},
 		Status:         sql.NullString{String: "new", Valid: true},
+		Ordinal:        ordinal,
 	})
 	return err
 }
 
-// GenerateCardsForNote is called after you've created a note and note fields.
-// It looks up the relevant card_template(s) for the note_type_id,
-// and for each template, it "renders" the front/back with a Go template approach.
-// For reverse or multi-cloze, it can create multiple card rows as needed.
+// GenerateCardsForNote is called after you've created a note and note fields,
+// and again whenever they change. It looks up the relevant card_template(s)
+// for the note_type_id and works out the cards each calls for, as
+// templateOrdinals describes: one, one per cloze number for cloze templates,
+// or none when its condition field is empty. Missing cards are created and
+// cards whose cloze or condition field was removed are suspended as orphans;
+// no card is deleted, so scheduling and review history are always kept.
 func GenerateCardsForNote(
 	ctx context.Context,
 	q *database.Queries,
@@ -228,51 +393,160 @@ func GenerateCardsForNote(
 	if err != nil {
 		return fmt.Errorf("failed to get note fields: %w", err)
 	}
-	dataMap := make(map[string]interface{})
+	values := make(map[string]string, len(fields))
 	for _, f := range fields {
-		dataMap[f.FieldName] = f.FieldContent
+		values[f.FieldName] = f.FieldContent
+	}
+
+	funcs, err := userTemplateFuncs(ctx, q, ownerID)
+	if err != nil {
+		return err
+	}
+
+	cards, err := q.ListCardsByNote(ctx, noteID)
+	if err != nil {
+		return fmt.Errorf("failed to list note cards: %w", err)
 	}
 
 	for _, tpl := range templates {
-		templateName := tpl.TemplateName
+		ordinals, err := templateOrdinals(tpl, values, funcs)
+		if err != nil {
+			return fmt.Errorf("failed to read template %q: %w", tpl.TemplateName, err)
+		}
+		if err := syncTemplateCards(ctx, q, noteID, tpl.ID, cards, ordinals); err != nil {
+			return err
+		}
+	}
 
-		// If it's "Reverse Note Template" and you want 2 directions, you might store front/back in separate records, or do a second template row.
-		// We'll assume each row in 'templates' is exactly 1 card creation.
+	return nil
+}
 
-		// If it's a Cloze, let's see if we need single or multi approach:
-		if strings.Contains(strings.ToLower(templateName), "cloze") {
-			// multi approach, parse placeholders to find c1, c2, etc.
-			textVal := dataMap["Text"]
-			if textVal == nil {
-				continue
-			}
-			placeholders := findAllPlaceholderNumbers(textVal.(string)) // e.g. [1, 2]
+// templateOrdinals returns the ordinals of the cards tpl makes for a note
+// with the given field values. A template whose condition field is empty on
+// the note makes none. A cloze template makes one for each cloze number in
+// the fields it clozes, or a single card 0 if they have none; any other
+// template makes a single card 0.
+func templateOrdinals(tpl database.CardTemplate, values map[string]string, funcs template.FuncMap) ([]int64, error) {
+	if tpl.ConditionField.Valid && strings.TrimSpace(values[tpl.ConditionField.String]) == "" {
+		return nil, nil
+	}
 
-			if len(placeholders) == 0 {
-				// treat as single => just generate 1 card
-				err := createOneCard(ctx, q, noteID, tpl.ID)
-				if err != nil {
-					return err
-				}
-			} else {
-				// for each unique placeholder number, create a card with that focus
-				uniqueFocuses := uniqueIntSlice(placeholders)
-				for _, focus := range uniqueFocuses {
-					err := createOneCardWithFocus(ctx, q, noteID, tpl.ID, focus)
-					if err != nil {
-						return err
-					}
-				}
+	var clozeFields []string
+	for _, text := range []string{tpl.FrontHtml, tpl.BackHtml} {
+		names, err := render.ClozeFields(text, funcs)
+		if err != nil {
+			return nil, err
+		}
+		clozeFields = append(clozeFields, names...)
+	}
+	if len(clozeFields) == 0 {
+		return []int64{0}, nil
+	}
+
+	var numbers []int
+	present := false
+	for _, name := range clozeFields {
+		content, ok := values[name]
+		present = present || ok
+		numbers = append(numbers, findAllPlaceholderNumbers(content)...)
+	}
+	switch {
+	case !present:
+		return nil, nil
+	case len(numbers) == 0:
+		return []int64{0}, nil
+	}
+	var ordinals []int64
+	for _, n := range uniqueIntSlice(numbers) {
+		ordinals = append(ordinals, int64(n))
+	}
+	slices.Sort(ordinals)
+	return ordinals, nil
+}
+
+// syncTemplateCards makes the note's cards of one template match ordinals.
+// Cards made before ordinals were stored all have ordinal 0; when a cloze
+// template needs other ordinals they are given the missing ones, in id order,
+// rather than being replaced. Cards no longer called for are orphaned, not
+// deleted, and orphans called for again are restored, as when a condition
+// field is cleared and filled in again.
+func syncTemplateCards(ctx context.Context, q *database.Queries, noteID, templateID string, cards []database.Card, ordinals []int64) error {
+	wanted := make(map[int64]bool, len(ordinals))
+	for _, ordinal := range ordinals {
+		wanted[ordinal] = true
+	}
+
+	have := make(map[int64]bool)
+	var unnumbered []database.Card
+	for _, card := range cards {
+		if card.CardTemplateID != templateID {
+			continue
+		}
+		switch {
+		case wanted[card.Ordinal] && !have[card.Ordinal]:
+			have[card.Ordinal] = true
+			if err := restoreOrphanCard(ctx, q, card); err != nil {
+				return err
+			}
+		case card.Ordinal == 0:
+			unnumbered = append(unnumbered, card)
+		default:
+			if err := orphanCard(ctx, q, card); err != nil {
+				return err
 			}
+		}
+	}
 
-		} else {
-			// It's a basic or reverse, just produce 1 card from the template
-			err := createOneCard(ctx, q, noteID, tpl.ID)
+	for _, ordinal := range ordinals {
+		if have[ordinal] {
+			continue
+		}
+		if len(unnumbered) > 0 {
+			err := q.UpdateCardOrdinal(ctx, database.UpdateCardOrdinalParams{Ordinal: ordinal, ID: unnumbered[0].ID})
 			if err != nil {
+				return fmt.Errorf("failed to number card: %w", err)
+			}
+			if err := restoreOrphanCard(ctx, q, unnumbered[0]); err != nil {
 				return err
 			}
+			unnumbered = unnumbered[1:]
+			continue
+		}
+		if err := createOneCard(ctx, q, noteID, templateID, ordinal); err != nil {
+			return err
 		}
 	}
 
+	for _, card := range unnumbered {
+		if err := orphanCard(ctx, q, card); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orphanCard suspends a card its template no longer makes and flags it as an
+// orphan. It is kept with its reviews; only deleting the template or the note
+// deletes it.
+func orphanCard(ctx context.Context, q *database.Queries, card database.Card) error {
+	err := q.OrphanCard(ctx, database.OrphanCardParams{
+		OrphanedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:         card.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to orphan card: %w", err)
+	}
+	return nil
+}
+
+// restoreOrphanCard unsuspends an orphaned card its template makes again and
+// clears its orphan flag. Cards that are not orphans are left alone.
+func restoreOrphanCard(ctx context.Context, q *database.Queries, card database.Card) error {
+	if !card.OrphanedAt.Valid {
+		return nil
+	}
+	if err := q.RestoreOrphanCard(ctx, card.ID); err != nil {
+		return fmt.Errorf("failed to restore orphaned card: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,276 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	algorithm "github.com/threeroundsoftware/voidabyss/algo"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+)
+
+// defaultCramSteps are used when a card's settings have no learning steps,
+// which would otherwise let every card pass on its first answer.
+var defaultCramSteps = []time.Duration{time.Minute, 10 * time.Minute}
+
+// StartCramSessionRequest starts a cram session over any mix of decks, tags
+// and cards, whether or not they are due.
+type StartCramSessionRequest struct {
+	Name    string   `json:"name" validate:"max=128"`
+	DeckIDs []string `json:"deck_ids" validate:"max=100,dive,alphanum,len=10"`
+	Tags    []string `json:"tags" validate:"max=64,dive,required,max=64"`
+	CardIDs []string `json:"card_ids" validate:"max=1000,dive,alphanum,len=10"`
+	Order   string   `json:"order" validate:"omitempty,oneof=deck random"`
+	Limit   int      `json:"limit" validate:"min=0,max=1000"`
+	// Reschedule lets cram answers update the cards' scheduling like normal reviews.
+	Reschedule bool `json:"reschedule"`
+}
+
+// CramSelection chooses the cards of a cram session.
+type CramSelection struct {
+	DeckIDs []string
+	Tags    []string
+	CardIDs []string
+}
+
+// FuncStartCramSessionHandler starts a cram session. Cards are shown again at
+// the cram steps until they pass, and unless the session reschedules, the
+// answers are recorded without changing the cards' scheduling.
+func FuncStartCramSessionHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req StartCramSessionRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating cram session request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+		if len(req.DeckIDs) == 0 && len(req.Tags) == 0 && len(req.CardIDs) == 0 {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Select decks, tags or cards to cram",
+			})
+		}
+
+		ctx := c.Request().Context()
+		cards, err := CramCards(ctx, app.Queries, user.ID, CramSelection{
+			DeckIDs: req.DeckIDs,
+			Tags:    req.Tags,
+			CardIDs: req.CardIDs,
+		})
+		if errors.Is(err, ErrDeckNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Deck not found",
+			})
+		}
+		if errors.Is(err, ErrCardNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Card not found",
+			})
+		}
+		if err != nil {
+			logging.SlogLogger.Error("Error selecting cram cards", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to start session",
+			})
+		}
+
+		if req.Order == QueueOrderRandom {
+			rand.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+		}
+		if req.Limit > 0 && len(cards) > req.Limit {
+			cards = cards[:req.Limit]
+		}
+		cardIDs := make([]string, 0, len(cards))
+		for _, card := range cards {
+			cardIDs = append(cardIDs, card.ID)
+		}
+
+		session, err := StartStudySession(ctx, app, user.ID, SessionOptions{
+			Mode:       SessionModeCram,
+			Name:       req.Name,
+			Reschedule: req.Reschedule,
+		}, uniqueStringSlice(req.DeckIDs), cardIDs)
+		if err != nil {
+			logging.SlogLogger.Error("Error starting cram session", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to start session",
+			})
+		}
+
+		return respondWithSession(c, app.Queries, http.StatusCreated, session, time.Now().UTC())
+	}
+}
+
+// CramCards returns the user's cards in the selected decks, tags and cards,
+// each once, in deck order. Suspended cards are left out.
+func CramCards(ctx context.Context, q *database.Queries, userID string, sel CramSelection) ([]database.Card, error) {
+	var cards []database.Card
+	seen := make(map[string]bool)
+	add := func(list ...database.Card) {
+		for _, card := range list {
+			if !seen[card.ID] && convertNullString(card.Status) != CardStatusSuspended {
+				seen[card.ID] = true
+				cards = append(cards, card)
+			}
+		}
+	}
+
+	if len(sel.DeckIDs) > 0 {
+		decks, err := queueDecks(ctx, q, userID, sel.DeckIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, deck := range decks {
+			list, err := q.ListCardsByDeck(ctx, deck.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list deck cards: %w", err)
+			}
+			add(list...)
+		}
+	}
+
+	for _, tag := range normalizeTags(sel.Tags) {
+		list, err := q.ListCardsByTag(ctx, database.ListCardsByTagParams{OwnerID: userID, Name: tag})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tagged cards: %w", err)
+		}
+		add(list...)
+	}
+
+	for _, id := range uniqueStringSlice(sel.CardIDs) {
+		card, _, err := getOwnedCard(ctx, q, userID, id)
+		if err != nil {
+			return nil, err
+		}
+		add(card)
+	}
+	return cards, nil
+}
+
+// answerCramCard answers a card of a cram session, moving it through the
+// cram steps. Sessions that reschedule review the card as usual; otherwise the
+// review is recorded as unscheduled and the card is left as it was.
+func answerCramCard(
+	ctx context.Context,
+	app *app.App,
+	userID string,
+	session database.Session,
+	sessionCard database.SessionCard,
+	input ReviewInput,
+	now time.Time,
+) (ReviewResult, error) {
+	card, note, err := getOwnedCard(ctx, app.Queries, userID, sessionCard.CardID)
+	if err != nil {
+		return ReviewResult{}, err
+	}
+	// the typed answer can decide the grade the cram steps go by
+	if err := checkReviewAnswer(ctx, app.Queries, card, note, &input); err != nil {
+		return ReviewResult{}, err
+	}
+	deck, err := app.Queries.GetDeck(ctx, note.DeckID)
+	if err != nil {
+		return ReviewResult{}, fmt.Errorf("failed to get deck: %w", err)
+	}
+	settings, err := ResolveSchedulerSettings(ctx, app.Queries, userID, deck)
+	if err != nil {
+		return ReviewResult{}, err
+	}
+	steps := settings.LearningSteps
+	if len(steps) == 0 {
+		steps = defaultCramSteps
+	}
+
+	step, due, passed := algorithm.CramStep(steps, int(sessionCard.CramStep), input.Grade, now)
+	update := database.UpdateSessionCardParams{
+		Status:      sql.NullString{String: SessionCardPending, Valid: true},
+		NextCramDue: sql.NullTime{Time: due, Valid: true},
+		CramStep:    int64(step),
+		SessionID:   session.ID,
+		CardID:      card.ID,
+	}
+	if passed {
+		update.Status.String = SessionCardDone
+		update.NextCramDue = sql.NullTime{}
+	}
+	updateSessionCard := func(qtx *database.Queries, _ ReviewResult) error {
+		if _, err := qtx.UpdateSessionCard(ctx, update); err != nil {
+			return fmt.Errorf("failed to update session card: %w", err)
+		}
+		return nil
+	}
+
+	if session.Reschedule {
+		return submitReview(ctx, app, userID, card.ID, input, updateSessionCard)
+	}
+
+	tx, err := app.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return ReviewResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := app.Queries.WithTx(tx)
+
+	R, err := currentRetrievability(ctx, qtx, card, settings.Day, now)
+	if err != nil {
+		return ReviewResult{}, err
+	}
+	review, err := qtx.CreateReview(ctx, withPriorState(database.CreateReviewParams{
+		CardID:        card.ID,
+		ReviewTime:    now,
+		RatingID:      sql.NullString{String: strconv.Itoa(int(input.Grade)), Valid: true},
+		ReviewSeconds: sql.NullInt64{Int64: input.ReviewSeconds, Valid: true},
+		NewInterval:   card.Interval,
+		NewStability:  card.Stability,
+		NewDifficulty: card.Difficulty,
+		NewDueDate:    card.DueDate,
+		SessionID:     input.SessionID,
+		Scheduled:     false,
+		TypedAnswer:   input.TypedAnswer,
+	}, nil, input.SessionCard))
+	if err != nil {
+		return ReviewResult{}, fmt.Errorf("failed to create review: %w", err)
+	}
+
+	result := ReviewResult{Review: review, Card: card, Retrievability: R, Answer: input.Answer}
+	if err := updateSessionCard(qtx, result); err != nil {
+		return ReviewResult{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return ReviewResult{}, fmt.Errorf("failed to commit review: %w", err)
+	}
+	return result, nil
+}
+
+// currentRetrievability is the card's retrievability at now, counted in the
+// user's study days from its last scheduled review, as when it is reviewed.
+// Cards that were never reviewed have none.
+func currentRetrievability(ctx context.Context, q *database.Queries, card database.Card, day algorithm.StudyDay, now time.Time) (float64, error) {
+	if !card.Stability.Valid || card.Stability.Float64 <= 0 {
+		return 0, nil
+	}
+	last, err := q.GetLatestReviewByCard(ctx, card.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last review: %w", err)
+	}
+	return algorithm.ForgettingCurve(day.Elapsed(last.ReviewTime, now), card.Stability.Float64), nil
+}
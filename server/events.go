@@ -0,0 +1,682 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	algorithm "github.com/threeroundsoftware/voidabyss/algo"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+)
+
+// User event types, statuses and amnesia modes.
+const (
+	UserEventVacation = "vacation"
+	UserEventAmnesia  = "amnesia"
+
+	UserEventPending   = "pending"
+	UserEventApplied   = "applied"
+	UserEventCancelled = "cancelled"
+
+	AmnesiaReset = "reset"
+	AmnesiaDecay = "decay"
+
+	// userEventJobInterval is how often the server applies events that have started.
+	userEventJobInterval = time.Hour
+	// defaultVacationDailyCap spreads vacation backlogs when neither the event
+	// nor the user's reviews per day set a cap.
+	defaultVacationDailyCap = 200
+)
+
+var (
+	ErrUserEventNotFound   = errors.New("user event not found")
+	ErrUserEventNotPending = errors.New("user event is not pending")
+)
+
+// CreateUserEventRequest schedules a vacation or amnesia event.
+type CreateUserEventRequest struct {
+	EventType string     `json:"event_type" validate:"required,oneof=vacation amnesia"`
+	StartDate time.Time  `json:"start_date" validate:"required"`
+	EndDate   *time.Time `json:"end_date"`
+	// DailyCap limits the vacation backlog spread on each day; 0 uses the
+	// user's reviews per day.
+	DailyCap    int64    `json:"daily_cap" validate:"min=0,max=9999"`
+	DeckIDs     []string `json:"deck_ids" validate:"max=100,dive,alphanum,len=10"`
+	AmnesiaMode string   `json:"amnesia_mode" validate:"omitempty,oneof=reset decay"`
+	// Decay is the fraction of stability an amnesia decay takes away.
+	Decay float64 `json:"decay" validate:"min=0,lt=1"`
+}
+
+// UserEventDetailRequest defines the structure for route parameters with validation
+type UserEventDetailRequest struct {
+	ID string `param:"eventID" validate:"required,alphanum,len=10"`
+}
+
+// UserEventChangeResponse is what applying an event changed on a card.
+type UserEventChangeResponse struct {
+	CardID        string  `json:"card_id"`
+	OldStatus     string  `json:"old_status"`
+	NewStatus     string  `json:"new_status"`
+	OldDueDate    string  `json:"old_due_date"`
+	NewDueDate    string  `json:"new_due_date"`
+	OldInterval   int64   `json:"old_interval"`
+	NewInterval   int64   `json:"new_interval"`
+	OldStability  float64 `json:"old_stability"`
+	NewStability  float64 `json:"new_stability"`
+	OldDifficulty float64 `json:"old_difficulty"`
+	NewDifficulty float64 `json:"new_difficulty"`
+}
+
+// UserEventResponse represents a vacation or amnesia event.
+type UserEventResponse struct {
+	ID          string                    `json:"id"`
+	EventType   string                    `json:"event_type"`
+	StartDate   time.Time                 `json:"start_date"`
+	EndDate     string                    `json:"end_date"`
+	Status      string                    `json:"status"`
+	AppliedAt   string                    `json:"applied_at"`
+	DailyCap    int64                     `json:"daily_cap"`
+	AmnesiaMode string                    `json:"amnesia_mode"`
+	Decay       float64                   `json:"decay"`
+	DeckIDs     []string                  `json:"deck_ids"`
+	Changes     []UserEventChangeResponse `json:"changes,omitempty"`
+}
+
+// UserEventsListResponse encapsulates a list of UserEventResponse.
+type UserEventsListResponse struct {
+	Events []UserEventResponse `json:"events"`
+}
+
+// FuncCreateUserEventHandler schedules a user event. Events that have
+// already started are applied straight away.
+func FuncCreateUserEventHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req CreateUserEventRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating user event request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+		if msg := checkUserEventRequest(req); msg != "" {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: msg,
+			})
+		}
+
+		ctx := c.Request().Context()
+		event, err := CreateUserEvent(ctx, app, user.ID, req)
+		if errors.Is(err, ErrDeckNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Deck not found",
+			})
+		}
+		if err != nil {
+			logging.SlogLogger.Error("Error creating user event", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to create event",
+			})
+		}
+
+		now := time.Now().UTC()
+		if !event.StartDate.After(now) {
+			applied, _, err := ApplyUserEvent(ctx, app, event, now)
+			if err != nil {
+				logging.SlogLogger.Error("Error applying user event", "event", event.ID, "error", err)
+				return c.JSON(http.StatusInternalServerError, ErrorResponse{
+					Error: "Failed to apply event",
+				})
+			}
+			event = applied
+		}
+
+		return respondWithUserEvent(c, app.Queries, http.StatusCreated, event, false)
+	}
+}
+
+// FuncGetUserEventsHandler lists the user's events, latest first.
+func FuncGetUserEventsHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		ctx := c.Request().Context()
+		events, err := app.Queries.ListUserEventsByUser(ctx, user.ID)
+		if err != nil {
+			logging.SlogLogger.Error("Error listing user events", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to retrieve events",
+			})
+		}
+
+		response := UserEventsListResponse{Events: make([]UserEventResponse, 0, len(events))}
+		for _, event := range events {
+			eventResponse, err := buildUserEventResponse(ctx, app.Queries, event, false)
+			if err != nil {
+				logging.SlogLogger.Error("Error building user event response", "event", event.ID, "error", err)
+				return c.JSON(http.StatusInternalServerError, ErrorResponse{
+					Error: "Failed to retrieve events",
+				})
+			}
+			response.Events = append(response.Events, eventResponse)
+		}
+		return c.JSON(http.StatusOK, response)
+	}
+}
+
+// FuncGetUserEventHandler returns an event with the changes it made.
+func FuncGetUserEventHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req UserEventDetailRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating user event request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		event, err := getOwnedUserEvent(c.Request().Context(), app.Queries, user.ID, req.ID)
+		if errors.Is(err, ErrUserEventNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Event not found",
+			})
+		}
+		if err != nil {
+			logging.SlogLogger.Error("Error retrieving user event", "event", req.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to retrieve event",
+			})
+		}
+		return respondWithUserEvent(c, app.Queries, http.StatusOK, event, true)
+	}
+}
+
+// FuncCancelUserEventHandler cancels an event that has not been applied yet.
+func FuncCancelUserEventHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req UserEventDetailRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating user event request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		ctx := c.Request().Context()
+		event, err := getOwnedUserEvent(ctx, app.Queries, user.ID, req.ID)
+		if err == nil {
+			event, err = app.Queries.CancelUserEvent(ctx, event.ID)
+			if errors.Is(err, sql.ErrNoRows) {
+				err = ErrUserEventNotPending
+			}
+		}
+		switch {
+		case errors.Is(err, ErrUserEventNotFound):
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Event not found",
+			})
+		case errors.Is(err, ErrUserEventNotPending):
+			return c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Only pending events can be cancelled",
+			})
+		case err != nil:
+			logging.SlogLogger.Error("Error cancelling user event", "event", req.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to cancel event",
+			})
+		}
+		return respondWithUserEvent(c, app.Queries, http.StatusOK, event, false)
+	}
+}
+
+// checkUserEventRequest checks what the validator cannot, returning the error message.
+func checkUserEventRequest(req CreateUserEventRequest) string {
+	switch req.EventType {
+	case UserEventVacation:
+		if req.EndDate == nil || !req.EndDate.After(req.StartDate) {
+			return "A vacation needs an end date after its start date"
+		}
+	case UserEventAmnesia:
+		if len(req.DeckIDs) == 0 {
+			return "Select the decks affected by amnesia"
+		}
+		if req.AmnesiaMode == "" {
+			return "Choose whether amnesia resets or decays cards"
+		}
+		if req.AmnesiaMode == AmnesiaDecay && req.Decay <= 0 {
+			return "A decay needs a decay fraction between 0 and 1"
+		}
+	}
+	return ""
+}
+
+// CreateUserEvent stores a pending event and, for amnesia, the decks it affects.
+func CreateUserEvent(ctx context.Context, app *app.App, userID string, req CreateUserEventRequest) (database.UserEvent, error) {
+	var decks []database.Deck
+	if req.EventType == UserEventAmnesia {
+		var err error
+		decks, err = queueDecks(ctx, app.Queries, userID, req.DeckIDs)
+		if err != nil {
+			return database.UserEvent{}, err
+		}
+	}
+
+	tx, err := app.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return database.UserEvent{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := app.Queries.WithTx(tx)
+
+	params := database.CreateUserEventParams{
+		UserID:    userID,
+		EventType: sql.NullString{String: req.EventType, Valid: true},
+		StartDate: req.StartDate.UTC(),
+	}
+	if req.EndDate != nil {
+		params.EndDate = sql.NullTime{Time: req.EndDate.UTC(), Valid: true}
+	}
+	switch req.EventType {
+	case UserEventVacation:
+		params.DailyCap = sql.NullInt64{Int64: req.DailyCap, Valid: req.DailyCap > 0}
+	case UserEventAmnesia:
+		params.AmnesiaMode = sql.NullString{String: req.AmnesiaMode, Valid: true}
+		params.Decay = sql.NullFloat64{Float64: req.Decay, Valid: req.AmnesiaMode == AmnesiaDecay}
+	}
+	event, err := qtx.CreateUserEvent(ctx, params)
+	if err != nil {
+		return database.UserEvent{}, fmt.Errorf("failed to create user event: %w", err)
+	}
+
+	for _, deck := range decks {
+		err := qtx.AddDeckToUserEvent(ctx, database.AddDeckToUserEventParams{
+			UserEventID: event.ID,
+			DeckID:      deck.ID,
+		})
+		if err != nil {
+			return database.UserEvent{}, fmt.Errorf("failed to add deck to user event: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.UserEvent{}, fmt.Errorf("failed to commit user event: %w", err)
+	}
+	return event, nil
+}
+
+// RunUserEventJob applies events as they start, checking every interval
+// until ctx is done.
+func RunUserEventJob(ctx context.Context, app *app.App, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		applied, err := ApplyDueUserEvents(ctx, app, time.Now().UTC())
+		if err != nil {
+			logging.SlogLogger.Error("Error applying user events", "error", err)
+		} else if applied > 0 {
+			logging.SlogLogger.Info("Applied user events", "count", applied)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ApplyDueUserEvents applies every pending event that has started by now and
+// returns how many it applied.
+func ApplyDueUserEvents(ctx context.Context, app *app.App, now time.Time) (int, error) {
+	events, err := app.Queries.ListPendingUserEvents(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pending user events: %w", err)
+	}
+
+	applied := 0
+	for _, event := range events {
+		_, changed, err := ApplyUserEvent(ctx, app, event, now)
+		if errors.Is(err, ErrUserEventNotPending) {
+			continue
+		}
+		if err != nil {
+			return applied, fmt.Errorf("event %s: %w", event.ID, err)
+		}
+		logging.SlogLogger.Info("Applied user event", "event", event.ID, "type", convertNullString(event.EventType), "cards", changed)
+		applied++
+	}
+	return applied, nil
+}
+
+// ApplyUserEvent rewrites the schedule of the cards an event affects in one
+// transaction, records each change and marks the event applied. It returns
+// the applied event and the number of cards changed.
+func ApplyUserEvent(ctx context.Context, app *app.App, event database.UserEvent, now time.Time) (database.UserEvent, int, error) {
+	tx, err := app.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return database.UserEvent{}, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := app.Queries.WithTx(tx)
+
+	var changed int
+	switch convertNullString(event.EventType) {
+	case UserEventVacation:
+		changed, err = applyVacation(ctx, qtx, event, now)
+	case UserEventAmnesia:
+		changed, err = applyAmnesia(ctx, qtx, event, now)
+	default:
+		err = fmt.Errorf("unknown event type %q", convertNullString(event.EventType))
+	}
+	if err != nil {
+		return database.UserEvent{}, 0, err
+	}
+
+	event, err = qtx.MarkUserEventApplied(ctx, database.MarkUserEventAppliedParams{
+		AppliedAt: sql.NullTime{Time: now, Valid: true},
+		ID:        event.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.UserEvent{}, 0, ErrUserEventNotPending
+	}
+	if err != nil {
+		return database.UserEvent{}, 0, fmt.Errorf("failed to mark user event applied: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.UserEvent{}, 0, fmt.Errorf("failed to commit user event: %w", err)
+	}
+	return event, changed, nil
+}
+
+// applyVacation moves the review cards due before the vacation ends (including
+// any backlog from before it) to the days after it, most overdue first and at
+// most the daily cap a day on top of the cards already due then.
+func applyVacation(ctx context.Context, q *database.Queries, event database.UserEvent, now time.Time) (int, error) {
+	if !event.EndDate.Valid {
+		return 0, errors.New("vacation has no end date")
+	}
+	day, err := userStudyDay(ctx, q, event.UserID)
+	if err != nil {
+		return 0, err
+	}
+	first := day.Start(event.EndDate.Time)
+	if today := day.Start(now); first.Before(today) {
+		first = today
+	}
+
+	cards, err := q.ListReviewCardsDueBefore(ctx, database.ListReviewCardsDueBeforeParams{
+		OwnerID: event.UserID,
+		DueDate: sql.NullTime{Time: first, Valid: true},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list due cards: %w", err)
+	}
+	if len(cards) == 0 {
+		return 0, nil
+	}
+
+	limit, err := vacationDailyCap(ctx, q, event)
+	if err != nil {
+		return 0, err
+	}
+
+	// widen the window of existing load until the spread fits inside it
+	load := dueLoad{ctx: ctx, q: q, userID: event.UserID, day: day}
+	horizon := int64(len(cards))/limit + 30
+	var days []int64
+	for {
+		days = algorithm.SpreadDays(len(cards), limit, load.DueCounts(first, 0, horizon-1))
+		if days[len(days)-1] < horizon {
+			break
+		}
+		horizon *= 2
+	}
+
+	for i, card := range cards {
+		oldDue := card.DueDate.Time
+		newDue := day.AddDays(first, int(days[i])).Add(oldDue.Sub(day.Start(oldDue)))
+		update := cardUpdateFromCard(card)
+		update.DueDate = sql.NullTime{Time: newDue, Valid: true}
+		// keep the interval counted from the last review
+		update.Interval = sql.NullInt64{
+			Int64: convertNullInt64(card.Interval) + int64(day.Elapsed(oldDue, newDue)),
+			Valid: true,
+		}
+		if err := changeCardForEvent(ctx, q, event, card, update, now); err != nil {
+			return 0, err
+		}
+	}
+	return len(cards), nil
+}
+
+// applyAmnesia resets or decays the cards of the event's decks. New cards are left alone.
+func applyAmnesia(ctx context.Context, q *database.Queries, event database.UserEvent, now time.Time) (int, error) {
+	eventDecks, err := q.ListUserEventDecks(ctx, event.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list user event decks: %w", err)
+	}
+
+	changed := 0
+	for _, eventDeck := range eventDecks {
+		deck, err := q.GetDeck(ctx, eventDeck.DeckID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get deck: %w", err)
+		}
+		settings, err := ResolveSchedulerSettings(ctx, q, event.UserID, deck)
+		if err != nil {
+			return 0, err
+		}
+		cfg := settings.Config(ctx, q, event.UserID)
+
+		cards, err := q.ListCardsByDeck(ctx, deck.ID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list deck cards: %w", err)
+		}
+		for _, card := range cards {
+			state := cardStateFromCard(card)
+			if state.State == algorithm.StateNew {
+				continue
+			}
+			// decay counts from the last review; cards with none on record
+			// are taken to have been reviewed an interval before they are due
+			last, err := q.GetLatestReviewByCard(ctx, card.ID)
+			switch {
+			case err == nil:
+				state.LastReview = last.ReviewTime
+			case errors.Is(err, sql.ErrNoRows):
+				state.LastReview = state.Due.AddDate(0, 0, -int(state.Interval))
+			default:
+				return 0, fmt.Errorf("failed to get last review: %w", err)
+			}
+
+			if convertNullString(event.AmnesiaMode) == AmnesiaReset {
+				state = algorithm.Reset(state)
+			} else {
+				state = algorithm.Decay(state, 1-convertNullFloat64(event.Decay), now, cfg)
+			}
+			if err := changeCardForEvent(ctx, q, event, card, cardUpdateFromState(card.ID, state), now); err != nil {
+				return 0, err
+			}
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// vacationDailyCap is the event's daily cap, falling back to the user's
+// reviews per day and then defaultVacationDailyCap.
+func vacationDailyCap(ctx context.Context, q *database.Queries, event database.UserEvent) (int64, error) {
+	if event.DailyCap.Valid && event.DailyCap.Int64 > 0 {
+		return event.DailyCap.Int64, nil
+	}
+	setting, err := q.GetUserSetting(ctx, event.UserID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user settings: %w", err)
+	}
+	settings, err := userSchedulerSettings(setting)
+	if err != nil {
+		return 0, err
+	}
+	if settings.ReviewsPerDay > 0 {
+		return settings.ReviewsPerDay, nil
+	}
+	return defaultVacationDailyCap, nil
+}
+
+// changeCardForEvent applies update to card and records the change against
+// event as made at now, the time reviews are compared with.
+func changeCardForEvent(ctx context.Context, q *database.Queries, event database.UserEvent, card database.Card, update database.UpdateCardSchedulingParams, now time.Time) error {
+	updated, err := q.UpdateCardScheduling(ctx, update)
+	if err != nil {
+		return fmt.Errorf("failed to update card: %w", err)
+	}
+	err = q.CreateUserEventChange(ctx, database.CreateUserEventChangeParams{
+		UserEventID:   event.ID,
+		CardID:        card.ID,
+		OldStatus:     card.Status,
+		NewStatus:     updated.Status,
+		OldDueDate:    card.DueDate,
+		NewDueDate:    updated.DueDate,
+		OldInterval:   card.Interval,
+		NewInterval:   updated.Interval,
+		OldStability:  card.Stability,
+		NewStability:  updated.Stability,
+		OldDifficulty: card.Difficulty,
+		NewDifficulty: updated.Difficulty,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record card change: %w", err)
+	}
+	return nil
+}
+
+// cardUpdateFromCard is an update that leaves card as it is.
+func cardUpdateFromCard(card database.Card) database.UpdateCardSchedulingParams {
+	return database.UpdateCardSchedulingParams{
+		DueDate:    card.DueDate,
+		Stability:  card.Stability,
+		Difficulty: card.Difficulty,
+		Interval:   card.Interval,
+		Status:     sql.NullString{String: cardSchedulingStatus(card.Status, card.SuspendedFrom), Valid: card.Status.Valid},
+		Reps:       card.Reps,
+		Lapses:     card.Lapses,
+		Step:       card.Step,
+		Ease:       card.Ease,
+		ID:         card.ID,
+	}
+}
+
+// getOwnedUserEvent loads an event, returning ErrUserEventNotFound unless it belongs to userID.
+func getOwnedUserEvent(ctx context.Context, q *database.Queries, userID, eventID string) (database.UserEvent, error) {
+	event, err := q.GetUserEvent(ctx, eventID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && event.UserID != userID) {
+		return database.UserEvent{}, ErrUserEventNotFound
+	}
+	if err != nil {
+		return database.UserEvent{}, fmt.Errorf("failed to get user event: %w", err)
+	}
+	return event, nil
+}
+
+// respondWithUserEvent writes the event with its decks, and its changes when withChanges is set.
+func respondWithUserEvent(c echo.Context, q *database.Queries, status int, event database.UserEvent, withChanges bool) error {
+	response, err := buildUserEventResponse(c.Request().Context(), q, event, withChanges)
+	if err != nil {
+		logging.SlogLogger.Error("Error building user event response", "event", event.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to retrieve event",
+		})
+	}
+	return c.JSON(status, response)
+}
+
+func buildUserEventResponse(ctx context.Context, q *database.Queries, event database.UserEvent, withChanges bool) (UserEventResponse, error) {
+	decks, err := q.ListUserEventDecks(ctx, event.ID)
+	if err != nil {
+		return UserEventResponse{}, fmt.Errorf("failed to list user event decks: %w", err)
+	}
+	var changes []database.UserEventChange
+	if withChanges {
+		changes, err = q.ListUserEventChanges(ctx, event.ID)
+		if err != nil {
+			return UserEventResponse{}, fmt.Errorf("failed to list user event changes: %w", err)
+		}
+	}
+	return convertUserEventToResponse(event, decks, changes), nil
+}
+
+func convertUserEventToResponse(event database.UserEvent, decks []database.UserEventDeck, changes []database.UserEventChange) UserEventResponse {
+	deckIDs := make([]string, 0, len(decks))
+	for _, deck := range decks {
+		deckIDs = append(deckIDs, deck.DeckID)
+	}
+
+	response := UserEventResponse{
+		ID:          event.ID,
+		EventType:   convertNullString(event.EventType),
+		StartDate:   event.StartDate,
+		EndDate:     convertNullTime(event.EndDate),
+		Status:      event.Status,
+		AppliedAt:   convertNullTime(event.AppliedAt),
+		DailyCap:    convertNullInt64(event.DailyCap),
+		AmnesiaMode: convertNullString(event.AmnesiaMode),
+		Decay:       convertNullFloat64(event.Decay),
+		DeckIDs:     deckIDs,
+	}
+	for _, change := range changes {
+		response.Changes = append(response.Changes, UserEventChangeResponse{
+			CardID:        change.CardID,
+			OldStatus:     convertNullString(change.OldStatus),
+			NewStatus:     convertNullString(change.NewStatus),
+			OldDueDate:    convertNullTime(change.OldDueDate),
+			NewDueDate:    convertNullTime(change.NewDueDate),
+			OldInterval:   convertNullInt64(change.OldInterval),
+			NewInterval:   convertNullInt64(change.NewInterval),
+			OldStability:  convertNullFloat64(change.OldStability),
+			NewStability:  convertNullFloat64(change.NewStability),
+			OldDifficulty: convertNullFloat64(change.OldDifficulty),
+			NewDifficulty: convertNullFloat64(change.NewDifficulty),
+		})
+	}
+	return response
+}
@@ -0,0 +1,362 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+	"github.com/threeroundsoftware/voidabyss/render"
+	"github.com/threeroundsoftware/voidabyss/script"
+)
+
+var (
+	ErrFunctionNotFound = errors.New("user function not found")
+	// ErrInvalidFunction is returned when a function cannot be compiled.
+	ErrInvalidFunction = errors.New("invalid user function")
+	// ErrFunctionInUse is returned when removing or renaming a function would
+	// break one of the user's templates.
+	ErrFunctionInUse = errors.New("user function in use")
+)
+
+// FunctionError says why a function cannot be saved. It is an
+// ErrInvalidFunction.
+type FunctionError struct {
+	Message string
+}
+
+func (e *FunctionError) Error() string {
+	return "invalid user function: " + e.Message
+}
+
+func (e *FunctionError) Is(target error) bool {
+	return target == ErrInvalidFunction
+}
+
+// UserFunction is a template function as the user writes it: a body in the
+// script language over named parameters, whose result is used as OutputType.
+type UserFunction struct {
+	Name       string   `json:"name" validate:"required,max=64"`
+	Params     []string `json:"params" validate:"max=8"`
+	OutputType string   `json:"output_type" validate:"required,oneof=string html boolean number"`
+	Body       string   `json:"body" validate:"required,max=4096"`
+}
+
+// UpdateFunctionRequest replaces one of the user's functions.
+type UpdateFunctionRequest struct {
+	ID string `param:"functionID" validate:"required,alphanum,len=10"`
+	UserFunction
+}
+
+// FunctionDetailRequest names one of the user's functions.
+type FunctionDetailRequest struct {
+	ID string `param:"functionID" validate:"required,alphanum,len=10"`
+}
+
+// UserFunctionResponse is a saved template function.
+type UserFunctionResponse struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Params     []string  `json:"params"`
+	OutputType string    `json:"output_type"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// UserFunctionsListResponse lists the user's functions by name.
+type UserFunctionsListResponse struct {
+	Functions []UserFunctionResponse `json:"functions"`
+}
+
+// FuncGetFunctionsHandler lists the user's template functions.
+func FuncGetFunctionsHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		functions, err := app.Queries.ListUserFunctionsByOwner(c.Request().Context(), user.ID)
+		if err != nil {
+			return respondFunctionError(c, fmt.Errorf("failed to list user functions: %w", err), "retrieve functions")
+		}
+		response := UserFunctionsListResponse{
+			Functions: make([]UserFunctionResponse, 0, len(functions)),
+		}
+		for _, fn := range functions {
+			response.Functions = append(response.Functions, convertUserFunctionToResponse(fn))
+		}
+		return c.JSON(http.StatusOK, response)
+	}
+}
+
+// FuncCreateFunctionHandler saves a new template function once it compiles.
+func FuncCreateFunctionHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req UserFunction
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating create function request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		fn, err := SaveUserFunction(c.Request().Context(), app.Queries, user.ID, "", req)
+		if err != nil {
+			return respondFunctionError(c, err, "create function")
+		}
+		return c.JSON(http.StatusCreated, convertUserFunctionToResponse(fn))
+	}
+}
+
+// FuncUpdateFunctionHandler replaces a template function once the new
+// version compiles.
+func FuncUpdateFunctionHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req UpdateFunctionRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating update function request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		fn, err := SaveUserFunction(c.Request().Context(), app.Queries, user.ID, req.ID, req.UserFunction)
+		if err != nil {
+			return respondFunctionError(c, err, "update function")
+		}
+		return c.JSON(http.StatusOK, convertUserFunctionToResponse(fn))
+	}
+}
+
+// FuncDeleteFunctionHandler deletes a template function no template uses.
+func FuncDeleteFunctionHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req FunctionDetailRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating function request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		if err := DeleteUserFunction(c.Request().Context(), app.Queries, user.ID, req.ID); err != nil {
+			return respondFunctionError(c, err, "delete function")
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+// SaveUserFunction compiles fn and saves it, as a new function when id is
+// empty and otherwise over the user's function id. A function that does not
+// compile, takes the name of a built-in or another of the user's functions,
+// or is renamed while a template still calls it by its old name is refused.
+func SaveUserFunction(ctx context.Context, q *database.Queries, userID, id string, fn UserFunction) (database.UserFunction, error) {
+	if _, err := compileUserFunction(fn.Name, fn.Params, fn.OutputType, fn.Body); err != nil {
+		return database.UserFunction{}, err
+	}
+
+	functions, err := q.ListUserFunctionsByOwner(ctx, userID)
+	if err != nil {
+		return database.UserFunction{}, fmt.Errorf("failed to list user functions: %w", err)
+	}
+	var existing *database.UserFunction
+	for i, other := range functions {
+		if other.ID == id {
+			existing = &functions[i]
+		} else if other.Name == fn.Name {
+			return database.UserFunction{}, &FunctionError{Message: fmt.Sprintf("A function named %q already exists", fn.Name)}
+		}
+	}
+
+	params := strings.Join(fn.Params, ",")
+	if id == "" {
+		saved, err := q.CreateUserFunction(ctx, database.CreateUserFunctionParams{
+			OwnerID:    userID,
+			Name:       fn.Name,
+			Params:     params,
+			OutputType: fn.OutputType,
+			Body:       fn.Body,
+		})
+		if err != nil {
+			return database.UserFunction{}, fmt.Errorf("failed to create user function: %w", err)
+		}
+		return saved, nil
+	}
+
+	if existing == nil {
+		return database.UserFunction{}, ErrFunctionNotFound
+	}
+	if existing.Name != fn.Name {
+		if err := checkFunctionUnused(ctx, q, userID, functions, existing.Name); err != nil {
+			return database.UserFunction{}, err
+		}
+	}
+	saved, err := q.UpdateUserFunction(ctx, database.UpdateUserFunctionParams{
+		Name:       fn.Name,
+		Params:     params,
+		OutputType: fn.OutputType,
+		Body:       fn.Body,
+		ID:         existing.ID,
+	})
+	if err != nil {
+		return database.UserFunction{}, fmt.Errorf("failed to update user function: %w", err)
+	}
+	return saved, nil
+}
+
+// DeleteUserFunction deletes one of the user's functions unless a template
+// calls it.
+func DeleteUserFunction(ctx context.Context, q *database.Queries, userID, id string) error {
+	functions, err := q.ListUserFunctionsByOwner(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list user functions: %w", err)
+	}
+	for _, fn := range functions {
+		if fn.ID != id {
+			continue
+		}
+		if err := checkFunctionUnused(ctx, q, userID, functions, fn.Name); err != nil {
+			return err
+		}
+		if err := q.DeleteUserFunction(ctx, fn.ID); err != nil {
+			return fmt.Errorf("failed to delete user function: %w", err)
+		}
+		return nil
+	}
+	return ErrFunctionNotFound
+}
+
+// checkFunctionUnused returns ErrFunctionInUse if one of the user's templates
+// that parses with all of functions stops parsing without the one named name.
+func checkFunctionUnused(ctx context.Context, q *database.Queries, userID string, functions []database.UserFunction, name string) error {
+	with, without := template.FuncMap{}, template.FuncMap{}
+	for _, fn := range functions {
+		with[fn.Name] = func() string { return "" }
+		if fn.Name != name {
+			without[fn.Name] = with[fn.Name]
+		}
+	}
+	templates, err := q.ListCardTemplatesByOwner(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list card templates: %w", err)
+	}
+	for _, tpl := range templates {
+		for _, text := range []string{tpl.FrontHtml, tpl.BackHtml} {
+			if _, err := render.FieldNames(text, with); err != nil {
+				continue
+			}
+			if _, err := render.FieldNames(text, without); err != nil {
+				return fmt.Errorf("%w: %s is used by template %s", ErrFunctionInUse, name, tpl.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// compileUserFunction compiles a function, refusing names that would hide a
+// built-in template function.
+func compileUserFunction(name string, params []string, output, body string) (*script.Function, error) {
+	if render.IsBuiltin(name) {
+		return nil, &FunctionError{Message: fmt.Sprintf("%q is the name of a built-in function", name)}
+	}
+	fn, err := script.Compile(name, params, output, body)
+	if err != nil {
+		return nil, &FunctionError{Message: err.Error()}
+	}
+	return fn, nil
+}
+
+// userTemplateFuncs returns the user's functions for rendering their
+// templates. A saved function that no longer compiles is left out, so the
+// templates calling it fail to render rather than every template.
+func userTemplateFuncs(ctx context.Context, q *database.Queries, userID string) (template.FuncMap, error) {
+	functions, err := q.ListUserFunctionsByOwner(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user functions: %w", err)
+	}
+	funcs := make(template.FuncMap, len(functions))
+	for _, saved := range functions {
+		fn, err := compileUserFunction(saved.Name, splitParams(saved.Params), saved.OutputType, saved.Body)
+		if err != nil {
+			logging.SlogLogger.Error("Saved user function does not compile", "function", saved.ID, "error", err)
+			continue
+		}
+		funcs[fn.Name] = fn.Call
+	}
+	return funcs, nil
+}
+
+// splitParams splits a function's stored parameter list.
+func splitParams(params string) []string {
+	if params == "" {
+		return []string{}
+	}
+	return strings.Split(params, ",")
+}
+
+func respondFunctionError(c echo.Context, err error, action string) error {
+	var functionErr *FunctionError
+	switch {
+	case errors.As(err, &functionErr):
+		return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{Error: functionErr.Message})
+	case errors.Is(err, ErrFunctionNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Function not found"})
+	case errors.Is(err, ErrFunctionInUse):
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "Function is used by a card template"})
+	}
+	logging.SlogLogger.Error("Error handling user function", "action", action, "error", err)
+	return c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error: "Failed to " + action,
+	})
+}
+
+func convertUserFunctionToResponse(fn database.UserFunction) UserFunctionResponse {
+	return UserFunctionResponse{
+		ID:         fn.ID,
+		Name:       fn.Name,
+		Params:     splitParams(fn.Params),
+		OutputType: fn.OutputType,
+		Body:       fn.Body,
+		CreatedAt:  fn.CreatedAt,
+		UpdatedAt:  fn.UpdatedAt,
+	}
+}
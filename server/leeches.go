@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	algorithm "github.com/threeroundsoftware/voidabyss/algo"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+)
+
+// What happens to a card when it becomes a leech.
+const (
+	LeechActionTag     = "tag"
+	LeechActionSuspend = "suspend"
+	LeechActionMove    = "move"
+)
+
+const (
+	// defaultLeechThreshold is Anki's default.
+	defaultLeechThreshold = 8
+	// leechTag is the tag added to a leech's note.
+	leechTag = "leech"
+	// leechDeckName is the deck leeches are moved to, created when needed.
+	leechDeckName = "Leeches"
+)
+
+// LeechResponse is a leech with the times it lapsed.
+type LeechResponse struct {
+	CardID    string   `json:"card_id"`
+	NoteID    string   `json:"note_id"`
+	DeckID    string   `json:"deck_id"`
+	DeckName  string   `json:"deck_name"`
+	Status    string   `json:"status"`
+	Suspended bool     `json:"suspended"`
+	Lapses    int64    `json:"lapses"`
+	LeechedAt string   `json:"leeched_at"`
+	LapseLog  []string `json:"lapse_log"`
+}
+
+// LeechesListResponse lists the user's leeches, most recent first.
+type LeechesListResponse struct {
+	Leeches []LeechResponse `json:"leeches"`
+}
+
+// FuncGetLeechesHandler lists the user's leeches with their lapse history.
+func FuncGetLeechesHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		ctx := c.Request().Context()
+		leeches, err := app.Queries.ListLeechCardsByOwner(ctx, user.ID)
+		if err != nil {
+			logging.SlogLogger.Error("Error listing leeches", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to retrieve leeches",
+			})
+		}
+		lapses, err := app.Queries.ListLeechLapsesByOwner(ctx, user.ID)
+		if err != nil {
+			logging.SlogLogger.Error("Error listing leech lapses", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to retrieve leeches",
+			})
+		}
+
+		return c.JSON(http.StatusOK, convertLeechesToResponse(leeches, lapses))
+	}
+}
+
+// handleLeech checks a card that has just lapsed and, if it became a leech,
+// marks it and applies the deck's leech action. It runs inside the review's
+// transaction and returns the card as it is afterwards.
+func handleLeech(
+	ctx context.Context,
+	qtx *database.Queries,
+	userID string,
+	note database.Note,
+	card database.Card,
+	settings SchedulerSettings,
+	now time.Time,
+) (database.Card, bool, error) {
+	if !algorithm.IsLeech(convertNullInt64(card.Lapses), settings.LeechThreshold) {
+		return card, false, nil
+	}
+
+	card, err := qtx.MarkCardLeech(ctx, database.MarkCardLeechParams{
+		LeechedAt: sql.NullTime{Time: now, Valid: true},
+		ID:        card.ID,
+	})
+	if err != nil {
+		return database.Card{}, false, fmt.Errorf("failed to mark leech: %w", err)
+	}
+
+	switch settings.LeechAction {
+	case LeechActionSuspend:
+		card, err = qtx.SuspendCard(ctx, card.ID)
+		if err != nil {
+			return database.Card{}, false, fmt.Errorf("failed to suspend leech: %w", err)
+		}
+	case LeechActionMove:
+		if err := moveNoteToLeechDeck(ctx, qtx, userID, note); err != nil {
+			return database.Card{}, false, err
+		}
+	default:
+		tag, err := qtx.UpsertTag(ctx, database.UpsertTagParams{OwnerID: userID, Name: leechTag})
+		if err != nil {
+			return database.Card{}, false, fmt.Errorf("failed to create leech tag: %w", err)
+		}
+		err = qtx.AddTagToNote(ctx, database.AddTagToNoteParams{NoteID: note.ID, TagID: tag.ID})
+		if err != nil {
+			return database.Card{}, false, fmt.Errorf("failed to tag leech: %w", err)
+		}
+	}
+	return card, true, nil
+}
+
+// moveNoteToLeechDeck moves a leech's note, with all of its cards, to the
+// user's Leeches deck, creating the deck if it does not exist.
+func moveNoteToLeechDeck(ctx context.Context, qtx *database.Queries, userID string, note database.Note) error {
+	deck, err := qtx.GetDeckByOwnerAndName(ctx, database.GetDeckByOwnerAndNameParams{
+		OwnerID: userID,
+		Name:    leechDeckName,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		deck, err = qtx.CreateDeck(ctx, database.CreateDeckParams{
+			Name:        leechDeckName,
+			OwnerID:     userID,
+			Description: sql.NullString{String: "Cards that keep being forgotten", Valid: true},
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get leech deck: %w", err)
+	}
+	if note.DeckID == deck.ID {
+		return nil
+	}
+	_, err = qtx.UpdateNoteDeck(ctx, database.UpdateNoteDeckParams{DeckID: deck.ID, ID: note.ID})
+	if err != nil {
+		return fmt.Errorf("failed to move leech: %w", err)
+	}
+	return nil
+}
+
+func convertLeechesToResponse(leeches []database.ListLeechCardsByOwnerRow, lapses []database.ListLeechLapsesByOwnerRow) LeechesListResponse {
+	lapseLog := make(map[string][]string)
+	for _, lapse := range lapses {
+		lapseLog[lapse.CardID] = append(lapseLog[lapse.CardID], lapse.ReviewTime.Format(time.RFC3339))
+	}
+
+	response := LeechesListResponse{
+		Leeches: make([]LeechResponse, 0, len(leeches)),
+	}
+	for _, leech := range leeches {
+		log := lapseLog[leech.ID]
+		if log == nil {
+			log = []string{}
+		}
+		response.Leeches = append(response.Leeches, LeechResponse{
+			CardID:    leech.ID,
+			NoteID:    leech.NoteID,
+			DeckID:    leech.DeckID,
+			DeckName:  leech.DeckName,
+			Status:    cardSchedulingStatus(leech.Status, leech.SuspendedFrom),
+			Suspended: convertNullString(leech.Status) == CardStatusSuspended,
+			Lapses:    convertNullInt64(leech.Lapses),
+			LeechedAt: convertNullTime(leech.LeechedAt),
+			LapseLog:  log,
+		})
+	}
+	return response
+}
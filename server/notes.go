@@ -0,0 +1,512 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+)
+
+// CreateNoteRequest creates a note in one of the user's decks.
+type CreateNoteRequest struct {
+	DeckID     string            `json:"deck_id" validate:"required,alphanum,len=10"`
+	NoteTypeID string            `json:"note_type_id" validate:"required,alphanum,len=10"`
+	Fields     map[string]string `json:"fields" validate:"max=64"`
+}
+
+// UpdateNoteRequest changes some of a note's fields and can move it to
+// another deck. Fields left out keep their content.
+type UpdateNoteRequest struct {
+	ID     string            `param:"noteID" validate:"required,alphanum,len=10"`
+	DeckID string            `json:"deck_id" validate:"omitempty,alphanum,len=10"`
+	Fields map[string]string `json:"fields" validate:"max=64"`
+}
+
+// NoteDetailRequest names one of the user's notes.
+type NoteDetailRequest struct {
+	ID string `param:"noteID" validate:"required,alphanum,len=10"`
+}
+
+// ListNotesRequest optionally limits the notes listed to one deck.
+type ListNotesRequest struct {
+	DeckID string `query:"deck_id" validate:"omitempty,alphanum,len=10"`
+}
+
+// NoteFieldResponse is a field of a note with its content.
+type NoteFieldResponse struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// NoteResponse is a note with its fields in note type order and, for a
+// single note, its cards.
+type NoteResponse struct {
+	ID         string `json:"id"`
+	DeckID     string `json:"deck_id"`
+	NoteTypeID string `json:"note_type_id"`
+	// SortField is the content of the note type's sort field.
+	SortField string              `json:"sort_field"`
+	Fields    []NoteFieldResponse `json:"fields"`
+	Cards     []CardStateResponse `json:"cards,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// NotesListResponse lists notes by their sort field.
+type NotesListResponse struct {
+	Notes []NoteResponse `json:"notes"`
+}
+
+// NoteDetail is a note with its fields, its note type's fields and its cards.
+type NoteDetail struct {
+	Note   database.Note
+	Fields []database.NoteField
+	Schema []database.NoteTypeField
+	Cards  []database.Card
+}
+
+// FuncGetNotesHandler lists the user's notes, optionally in one deck.
+func FuncGetNotesHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req ListNotesRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating notes request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		notes, err := ListNotes(c.Request().Context(), app.Queries, user.ID, req.DeckID)
+		if err != nil {
+			return respondNoteError(c, err, "retrieve notes")
+		}
+		return c.JSON(http.StatusOK, convertNotesToResponse(notes))
+	}
+}
+
+// FuncGetNoteHandler returns one of the user's notes with its cards.
+func FuncGetNoteHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req NoteDetailRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating note request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		ctx := c.Request().Context()
+		note, err := getOwnedNote(ctx, app.Queries, user.ID, req.ID)
+		if err != nil {
+			return respondNoteError(c, err, "retrieve note")
+		}
+		detail, err := loadNoteDetail(ctx, app.Queries, note)
+		if err != nil {
+			return respondNoteError(c, err, "retrieve note")
+		}
+		return c.JSON(http.StatusOK, convertNoteToResponse(detail))
+	}
+}
+
+// FuncCreateNoteHandler creates a note and generates its cards.
+func FuncCreateNoteHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req CreateNoteRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating create note request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		detail, err := CreateNote(c.Request().Context(), app, user.ID, req)
+		if err != nil {
+			return respondNoteError(c, err, "create note")
+		}
+		return c.JSON(http.StatusCreated, convertNoteToResponse(detail))
+	}
+}
+
+// FuncUpdateNoteHandler edits a note's fields or deck and brings its cards in
+// line with the new content.
+func FuncUpdateNoteHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req UpdateNoteRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating update note request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		detail, err := UpdateNote(c.Request().Context(), app, user.ID, req)
+		if err != nil {
+			return respondNoteError(c, err, "update note")
+		}
+		return c.JSON(http.StatusOK, convertNoteToResponse(detail))
+	}
+}
+
+// FuncDeleteNoteHandler deletes a note with its cards and their reviews.
+func FuncDeleteNoteHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req NoteDetailRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating note request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		ctx := c.Request().Context()
+		note, err := getOwnedNote(ctx, app.Queries, user.ID, req.ID)
+		if err == nil {
+			err = app.Queries.DeleteNote(ctx, note.ID)
+		}
+		if err != nil {
+			return respondNoteError(c, err, "delete note")
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+// ListNotes returns the user's notes, only those in deckID when it is set,
+// ordered by their sort field.
+func ListNotes(ctx context.Context, q *database.Queries, userID, deckID string) ([]NoteDetail, error) {
+	var notes []database.Note
+	var err error
+	if deckID != "" {
+		if _, err := queueDecks(ctx, q, userID, []string{deckID}); err != nil {
+			return nil, err
+		}
+		notes, err = q.ListNotesByDeck(ctx, deckID)
+	} else {
+		notes, err = q.ListNotesByOwner(ctx, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+
+	fields, err := q.ListNoteFieldsByOwner(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list note fields: %w", err)
+	}
+	fieldsByNote := make(map[string][]database.NoteField)
+	for _, field := range fields {
+		fieldsByNote[field.NoteID] = append(fieldsByNote[field.NoteID], field)
+	}
+
+	schemas := make(map[string][]database.NoteTypeField)
+	details := make([]NoteDetail, 0, len(notes))
+	for _, note := range notes {
+		schema, ok := schemas[note.NoteTypeID]
+		if !ok {
+			schema, err = q.ListNoteTypeFields(ctx, note.NoteTypeID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get note type fields: %w", err)
+			}
+			schemas[note.NoteTypeID] = schema
+		}
+		details = append(details, NoteDetail{Note: note, Fields: fieldsByNote[note.ID], Schema: schema})
+	}
+
+	slices.SortStableFunc(details, func(a, b NoteDetail) int {
+		return strings.Compare(strings.ToLower(sortFieldValue(a)), strings.ToLower(sortFieldValue(b)))
+	})
+	return details, nil
+}
+
+// CreateNote creates a note with its fields and cards in one transaction.
+func CreateNote(ctx context.Context, app *app.App, userID string, req CreateNoteRequest) (NoteDetail, error) {
+	if _, err := queueDecks(ctx, app.Queries, userID, []string{req.DeckID}); err != nil {
+		return NoteDetail{}, err
+	}
+	noteType, err := getOwnedNoteType(ctx, app.Queries, userID, req.NoteTypeID)
+	if err != nil {
+		return NoteDetail{}, err
+	}
+	schema, err := app.Queries.ListNoteTypeFields(ctx, noteType.ID)
+	if err != nil {
+		return NoteDetail{}, fmt.Errorf("failed to get note type fields: %w", err)
+	}
+
+	tx, err := app.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return NoteDetail{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := app.Queries.WithTx(tx)
+
+	note, err := qtx.CreateNote(ctx, database.CreateNoteParams{
+		DeckID:     req.DeckID,
+		NoteTypeID: noteType.ID,
+		OwnerID:    userID,
+	})
+	if err != nil {
+		return NoteDetail{}, fmt.Errorf("failed to create note: %w", err)
+	}
+	if err := createNoteFields(ctx, qtx, note.ID, schema, req.Fields); err != nil {
+		return NoteDetail{}, err
+	}
+	if err := GenerateCardsForNote(ctx, qtx, note.ID, noteType.ID, userID); err != nil {
+		return NoteDetail{}, err
+	}
+
+	detail, err := loadNoteDetail(ctx, qtx, note)
+	if err != nil {
+		return NoteDetail{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return NoteDetail{}, fmt.Errorf("failed to commit note: %w", err)
+	}
+	return detail, nil
+}
+
+// UpdateNote applies an edit to a note and regenerates its cards: cards the
+// templates no longer call for are orphaned and new ones created, while the
+// cards that still apply keep their scheduling.
+func UpdateNote(ctx context.Context, app *app.App, userID string, req UpdateNoteRequest) (NoteDetail, error) {
+	note, err := getOwnedNote(ctx, app.Queries, userID, req.ID)
+	if err != nil {
+		return NoteDetail{}, err
+	}
+	if req.DeckID != "" {
+		if _, err := queueDecks(ctx, app.Queries, userID, []string{req.DeckID}); err != nil {
+			return NoteDetail{}, err
+		}
+	}
+	schema, err := app.Queries.ListNoteTypeFields(ctx, note.NoteTypeID)
+	if err != nil {
+		return NoteDetail{}, fmt.Errorf("failed to get note type fields: %w", err)
+	}
+	fields, err := app.Queries.ListFieldsByNote(ctx, note.ID)
+	if err != nil {
+		return NoteDetail{}, fmt.Errorf("failed to get note fields: %w", err)
+	}
+
+	// check the note as it will be: the note type's fields, edited or not,
+	// and any field the edit names
+	values := make(map[string]string, len(schema))
+	for _, field := range fields {
+		values[field.FieldName] = field.FieldContent
+	}
+	edited := make(map[string]string, len(schema))
+	for _, field := range schema {
+		edited[field.Name] = values[field.Name]
+	}
+	for name, content := range req.Fields {
+		edited[name] = content
+	}
+	if msg := checkNoteFields(schema, edited); msg != "" {
+		return NoteDetail{}, &NoteFieldsError{Message: msg}
+	}
+
+	tx, err := app.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return NoteDetail{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := app.Queries.WithTx(tx)
+
+	if req.DeckID != "" && req.DeckID != note.DeckID {
+		note, err = qtx.UpdateNoteDeck(ctx, database.UpdateNoteDeckParams{DeckID: req.DeckID, ID: note.ID})
+		if err != nil {
+			return NoteDetail{}, fmt.Errorf("failed to move note: %w", err)
+		}
+	}
+	for name, content := range req.Fields {
+		if current, ok := values[name]; ok && current == content {
+			continue
+		}
+		_, err := qtx.UpdateNoteField(ctx, database.UpdateNoteFieldParams{
+			FieldContent: content,
+			NoteID:       note.ID,
+			FieldName:    name,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			_, err = qtx.CreateNoteField(ctx, database.CreateNoteFieldParams{
+				NoteID:       note.ID,
+				FieldName:    name,
+				FieldContent: content,
+			})
+		}
+		if err != nil {
+			return NoteDetail{}, fmt.Errorf("failed to update note field: %w", err)
+		}
+	}
+	if err := GenerateCardsForNote(ctx, qtx, note.ID, note.NoteTypeID, userID); err != nil {
+		return NoteDetail{}, err
+	}
+
+	detail, err := loadNoteDetail(ctx, qtx, note)
+	if err != nil {
+		return NoteDetail{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return NoteDetail{}, fmt.Errorf("failed to commit note: %w", err)
+	}
+	return detail, nil
+}
+
+// getOwnedNote returns the note if it belongs to userID.
+func getOwnedNote(ctx context.Context, q *database.Queries, userID, noteID string) (database.Note, error) {
+	note, err := q.GetNote(ctx, noteID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && note.OwnerID != userID) {
+		return database.Note{}, ErrNoteNotFound
+	}
+	if err != nil {
+		return database.Note{}, fmt.Errorf("failed to get note: %w", err)
+	}
+	return note, nil
+}
+
+// loadNoteDetail loads a note's fields, note type fields and cards.
+func loadNoteDetail(ctx context.Context, q *database.Queries, note database.Note) (NoteDetail, error) {
+	fields, err := q.ListFieldsByNote(ctx, note.ID)
+	if err != nil {
+		return NoteDetail{}, fmt.Errorf("failed to get note fields: %w", err)
+	}
+	schema, err := q.ListNoteTypeFields(ctx, note.NoteTypeID)
+	if err != nil {
+		return NoteDetail{}, fmt.Errorf("failed to get note type fields: %w", err)
+	}
+	cards, err := q.ListCardsByNote(ctx, note.ID)
+	if err != nil {
+		return NoteDetail{}, fmt.Errorf("failed to list note cards: %w", err)
+	}
+	return NoteDetail{Note: note, Fields: fields, Schema: schema, Cards: cards}, nil
+}
+
+// sortFieldValue is the content of the note's sort field.
+func sortFieldValue(detail NoteDetail) string {
+	for _, field := range detail.Schema {
+		if !field.SortField {
+			continue
+		}
+		for _, f := range detail.Fields {
+			if f.FieldName == field.Name {
+				return f.FieldContent
+			}
+		}
+	}
+	return ""
+}
+
+func respondNoteError(c echo.Context, err error, action string) error {
+	var fieldsErr *NoteFieldsError
+	switch {
+	case errors.As(err, &fieldsErr):
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fieldsErr.Message})
+	case errors.Is(err, ErrNoteNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Note not found"})
+	case errors.Is(err, ErrNoteTypeNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Note type not found"})
+	case errors.Is(err, ErrDeckNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Deck not found"})
+	}
+	logging.SlogLogger.Error("Error handling note", "action", action, "error", err)
+	return c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error: "Failed to " + action,
+	})
+}
+
+func convertNotesToResponse(notes []NoteDetail) NotesListResponse {
+	response := NotesListResponse{
+		Notes: make([]NoteResponse, 0, len(notes)),
+	}
+	for _, note := range notes {
+		response.Notes = append(response.Notes, convertNoteToResponse(note))
+	}
+	return response
+}
+
+// convertNoteToResponse lists the note's fields in note type order, followed
+// by any it has that the note type no longer does.
+func convertNoteToResponse(detail NoteDetail) NoteResponse {
+	content := make(map[string]string, len(detail.Fields))
+	for _, field := range detail.Fields {
+		content[field.FieldName] = field.FieldContent
+	}
+
+	fields := make([]NoteFieldResponse, 0, len(detail.Fields))
+	listed := make(map[string]bool, len(detail.Schema))
+	for _, field := range detail.Schema {
+		fields = append(fields, NoteFieldResponse{Name: field.Name, Content: content[field.Name]})
+		listed[field.Name] = true
+	}
+	for _, field := range detail.Fields {
+		if !listed[field.FieldName] {
+			fields = append(fields, NoteFieldResponse{Name: field.FieldName, Content: field.FieldContent})
+		}
+	}
+
+	var cards []CardStateResponse
+	for _, card := range detail.Cards {
+		cards = append(cards, convertCardToStateResponse(card))
+	}
+
+	return NoteResponse{
+		ID:         detail.Note.ID,
+		DeckID:     detail.Note.DeckID,
+		NoteTypeID: detail.Note.NoteTypeID,
+		SortField:  sortFieldValue(detail),
+		Fields:     fields,
+		Cards:      cards,
+		CreatedAt:  detail.Note.CreatedAt,
+		UpdatedAt:  detail.Note.UpdatedAt,
+	}
+}
@@ -1,23 +1,85 @@
 package server
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
 	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/threeroundsoftware/voidabyss/app"
 	"github.com/threeroundsoftware/voidabyss/database"
 	"github.com/threeroundsoftware/voidabyss/internal/logging"
+	"github.com/threeroundsoftware/voidabyss/render"
 )
 
+// What a note type field holds.
+const (
+	FieldKindText  = "text"
+	FieldKindHTML  = "html"
+	FieldKindImage = "image"
+	FieldKindAudio = "audio"
+)
+
+var (
+	ErrNoteTypeNotFound = errors.New("note type not found")
+	// ErrInvalidNote is returned when a note's fields do not fit its note type.
+	ErrInvalidNote = errors.New("invalid note")
+)
+
+// NoteFieldsError says why a note's fields do not fit its note type. It is
+// an ErrInvalidNote.
+type NoteFieldsError struct {
+	Message string
+}
+
+func (e *NoteFieldsError) Error() string {
+	return "invalid note: " + e.Message
+}
+
+func (e *NoteFieldsError) Is(target error) bool {
+	return target == ErrInvalidNote
+}
+
 // NoteTypeResponse represents the structure of a single note type in the API response.
 type NoteTypeResponse struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	OwnerID     string    `json:"owner_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string                  `json:"id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	OwnerID     string                  `json:"owner_id"`
+	Fields      []NoteTypeFieldResponse `json:"fields,omitempty"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+// NoteTypeFieldResponse is one field of a note type.
+type NoteTypeFieldResponse struct {
+	Name      string `json:"name"`
+	Position  int64  `json:"position"`
+	Kind      string `json:"kind"`
+	Required  bool   `json:"required"`
+	SortField bool   `json:"sort_field"`
+}
+
+// NoteTypeFieldRequest is one field of a note type, given in position order.
+type NoteTypeFieldRequest struct {
+	Name      string `json:"name" validate:"required,max=64"`
+	Kind      string `json:"kind" validate:"omitempty,oneof=text html image audio"`
+	Required  bool   `json:"required"`
+	SortField bool   `json:"sort_field"`
+}
+
+// SetNoteTypeFieldsRequest replaces the fields of a note type.
+type SetNoteTypeFieldsRequest struct {
+	ID     string                 `param:"noteTypeID" validate:"required,alphanum,len=10"`
+	Fields []NoteTypeFieldRequest `json:"fields" validate:"required,min=1,max=64,dive"`
 }
 
 // NoteTypesListResponse encapsulates a list of TemplateResponse.
@@ -104,8 +166,17 @@ func FuncGetNoteTypeHandler(app *app.App) echo.HandlerFunc {
 			})
 		}
 
+		fields, err := app.Queries.ListNoteTypeFields(c.Request().Context(), noteType.ID)
+		if err != nil {
+			logging.SlogLogger.Error("Error retreiving note type fields", "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to retrieve note type",
+			})
+		}
+
 		// convert to response
 		responseNoteType := convertNoteTypeToResponse(noteType, Detail)
+		responseNoteType.Fields = convertNoteTypeFieldsToResponse(fields)
 		response := NoteTypeDetailResponse{
 			NoteType: responseNoteType,
 		}
@@ -114,6 +185,289 @@ func FuncGetNoteTypeHandler(app *app.App) echo.HandlerFunc {
 	}
 }
 
+// FuncSetNoteTypeFieldsHandler replaces the fields of one of the user's note
+// types. The note type's templates must only refer to the new fields.
+func FuncSetNoteTypeFieldsHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req SetNoteTypeFieldsRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating note type fields request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+		if msg := checkNoteTypeFields(req.Fields); msg != "" {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: msg,
+			})
+		}
+
+		ctx := c.Request().Context()
+		noteType, err := getOwnedNoteType(ctx, app.Queries, user.ID, req.ID)
+		if errors.Is(err, ErrNoteTypeNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Note type not found",
+			})
+		}
+		if err != nil {
+			logging.SlogLogger.Error("Error retreiving note type", "user", user.ID, "note type", req.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to update note type fields",
+			})
+		}
+
+		templates, err := app.Queries.ListCardTemplatesByNoteType(ctx, database.ListCardTemplatesByNoteTypeParams{
+			OwnerID:    user.ID,
+			NoteTypeID: noteType.ID,
+		})
+		if err != nil {
+			logging.SlogLogger.Error("Error listing note type templates", "note type", noteType.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to update note type fields",
+			})
+		}
+		funcs, err := userTemplateFuncs(ctx, app.Queries, user.ID)
+		if err != nil {
+			logging.SlogLogger.Error("Error loading user functions", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to update note type fields",
+			})
+		}
+		names := make([]string, 0, len(req.Fields))
+		for _, field := range req.Fields {
+			names = append(names, field.Name)
+		}
+		for _, tpl := range templates {
+			msg := checkTemplateFields(names, funcs, tpl.FrontHtml, tpl.BackHtml)
+			if msg == "" {
+				msg = checkConditionField(names, convertNullString(tpl.ConditionField))
+			}
+			if msg != "" {
+				return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+					Error: tpl.TemplateName + ": " + msg,
+				})
+			}
+		}
+
+		tx, err := app.DB.BeginTx(ctx, nil)
+		if err != nil {
+			logging.SlogLogger.Error("Error beginning transaction", "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to update note type fields",
+			})
+		}
+		defer tx.Rollback()
+		qtx := app.Queries.WithTx(tx)
+
+		err = qtx.DeleteNoteTypeFields(ctx, noteType.ID)
+		if err == nil {
+			err = createNoteTypeFields(ctx, qtx, noteType.ID, req.Fields)
+		}
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			logging.SlogLogger.Error("Error setting note type fields", "note type", noteType.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to update note type fields",
+			})
+		}
+
+		fields, err := app.Queries.ListNoteTypeFields(ctx, noteType.ID)
+		if err != nil {
+			logging.SlogLogger.Error("Error retreiving note type fields", "note type", noteType.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to retrieve note type",
+			})
+		}
+		response := convertNoteTypeToResponse(noteType, Detail)
+		response.Fields = convertNoteTypeFieldsToResponse(fields)
+		return c.JSON(http.StatusOK, NoteTypeDetailResponse{NoteType: response})
+	}
+}
+
+// getOwnedNoteType returns the note type if it belongs to userID.
+func getOwnedNoteType(ctx context.Context, q *database.Queries, userID, noteTypeID string) (database.NoteType, error) {
+	noteType, err := q.GetNoteType(ctx, noteTypeID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && noteType.OwnerID != userID) {
+		return database.NoteType{}, ErrNoteTypeNotFound
+	}
+	if err != nil {
+		return database.NoteType{}, fmt.Errorf("failed to get note type: %w", err)
+	}
+	return noteType, nil
+}
+
+// createNoteTypeFields stores fields in the order given. The first field is
+// the sort field unless another one is.
+func createNoteTypeFields(ctx context.Context, q *database.Queries, noteTypeID string, fields []NoteTypeFieldRequest) error {
+	sortField := 0
+	for i, field := range fields {
+		if field.SortField {
+			sortField = i
+		}
+	}
+	for i, field := range fields {
+		kind := field.Kind
+		if kind == "" {
+			kind = FieldKindText
+		}
+		_, err := q.CreateNoteTypeField(ctx, database.CreateNoteTypeFieldParams{
+			NoteTypeID: noteTypeID,
+			Name:       field.Name,
+			Position:   int64(i),
+			Kind:       kind,
+			Required:   field.Required,
+			SortField:  i == sortField,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create note type field: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkNoteTypeFields checks what the validator cannot, returning the error message.
+func checkNoteTypeFields(fields []NoteTypeFieldRequest) string {
+	seen := make(map[string]bool, len(fields))
+	sortFields := 0
+	for _, field := range fields {
+		if seen[field.Name] {
+			return fmt.Sprintf("Field %q is listed twice", field.Name)
+		}
+		seen[field.Name] = true
+		if field.SortField {
+			sortFields++
+		}
+	}
+	if sortFields > 1 {
+		return "Only one field can be the sort field"
+	}
+	return ""
+}
+
+// checkTemplateFields checks that templates parse, calling only the
+// built-in functions and funcs, and only refer to the named fields,
+// returning the error message.
+func checkTemplateFields(names []string, funcs template.FuncMap, templates ...string) string {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+	for _, text := range templates {
+		refs, err := render.FieldNames(text, funcs)
+		if err != nil {
+			return "Template is invalid: " + err.Error()
+		}
+		for _, ref := range refs {
+			if !known[ref] {
+				return fmt.Sprintf("Template refers to unknown field %q", ref)
+			}
+		}
+	}
+	return ""
+}
+
+// checkConditionField checks that a template's condition field, if it has
+// one, is among the note type's fields, returning the error message.
+func checkConditionField(names []string, condition string) string {
+	if condition != "" && !slices.Contains(names, condition) {
+		return fmt.Sprintf("Condition field %q is not a field of the note type", condition)
+	}
+	return ""
+}
+
+// checkNoteFields checks a note's field values against its note type's
+// fields, returning the error message.
+func checkNoteFields(fields []database.NoteTypeField, values map[string]string) string {
+	known := make(map[string]database.NoteTypeField, len(fields))
+	for _, field := range fields {
+		known[field.Name] = field
+	}
+	for name := range values {
+		if _, ok := known[name]; !ok {
+			return fmt.Sprintf("Unknown field %q", name)
+		}
+	}
+	for _, field := range fields {
+		value := values[field.Name]
+		if field.Required && strings.TrimSpace(value) == "" {
+			return fmt.Sprintf("Field %q is required", field.Name)
+		}
+		if (field.Kind == FieldKindImage || field.Kind == FieldKindAudio) && value != "" && !isMediaRef(value) {
+			return fmt.Sprintf("Field %q must be a file name or an http(s) URL", field.Name)
+		}
+	}
+	return ""
+}
+
+// isMediaRef reports whether value names a media file: an http(s) URL or a
+// plain file name.
+func isMediaRef(value string) bool {
+	if u, err := url.Parse(value); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+		return true
+	}
+	return !strings.ContainsAny(value, `/\:<>"`) && path.Base(value) == value && value != ".." && value != "."
+}
+
+// createNoteFields checks values against the note type's fields and stores
+// them on the note in field order. Fields without a value are stored empty.
+func createNoteFields(ctx context.Context, q *database.Queries, noteID string, fields []database.NoteTypeField, values map[string]string) error {
+	if msg := checkNoteFields(fields, values); msg != "" {
+		return &NoteFieldsError{Message: msg}
+	}
+	for _, field := range fields {
+		_, err := q.CreateNoteField(ctx, database.CreateNoteFieldParams{
+			NoteID:       noteID,
+			FieldName:    field.Name,
+			FieldContent: values[field.Name],
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create note field: %w", err)
+		}
+	}
+	return nil
+}
+
+// renderData is the data a note's cards are rendered with: its field values,
+// with rich HTML fields marked as safe to insert as they are.
+func renderData(fields []database.NoteTypeField, values map[string]string) map[string]any {
+	data := make(map[string]any, len(values))
+	for name, value := range values {
+		data[name] = value
+	}
+	for _, field := range fields {
+		if field.Kind == FieldKindHTML {
+			data[field.Name] = template.HTML(values[field.Name])
+		}
+	}
+	return data
+}
+
+// convertNoteTypeFieldsToResponse converts note type fields to their response.
+func convertNoteTypeFieldsToResponse(fields []database.NoteTypeField) []NoteTypeFieldResponse {
+	response := make([]NoteTypeFieldResponse, 0, len(fields))
+	for _, field := range fields {
+		response = append(response, NoteTypeFieldResponse{
+			Name:      field.Name,
+			Position:  field.Position,
+			Kind:      field.Kind,
+			Required:  field.Required,
+			SortField: field.SortField,
+		})
+	}
+	return response
+}
+
 // convertNoteTypesToResponse converts a slice of database.NoteType to a slice of NoteTypeListResponse.
 func convertNoteTypesToResponse(noteTypes []database.NoteType) []NoteTypeResponse {
 	responseNoteTypes := make([]NoteTypeResponse, 0, len(noteTypes))
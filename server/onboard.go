@@ -38,6 +38,24 @@ func OnboardNewUser(ctx context.Context, q *database.Queries, userID, displayNam
 	}
 	log.Printf("User %s note type added. Note Type: %v\n", userID, clozeNoteType)
 
+	// create note type fields
+	frontBack := []NoteTypeFieldRequest{
+		{Name: "Front", Kind: FieldKindText, Required: true, SortField: true},
+		{Name: "Back", Kind: FieldKindText},
+	}
+	if err := createNoteTypeFields(ctx, q, basicNoteType.ID, frontBack); err != nil {
+		return errors.New("Unable to create basic note type fields for user")
+	}
+	if err := createNoteTypeFields(ctx, q, reverseNoteType.ID, frontBack); err != nil {
+		return errors.New("Unable to create reverse note type fields for user")
+	}
+	err = createNoteTypeFields(ctx, q, clozeNoteType.ID, []NoteTypeFieldRequest{
+		{Name: "Text", Kind: FieldKindText, Required: true, SortField: true},
+	})
+	if err != nil {
+		return errors.New("Unable to create cloze note type fields for user")
+	}
+
 	// create card templates
 
 	basicCardTemplate, err := q.CreateCardTemplate(ctx, database.CreateCardTemplateParams{
@@ -53,14 +71,30 @@ func OnboardNewUser(ctx context.Context, q *database.Queries, userID, displayNam
 	}
 	log.Printf("User %s card template added. Card template: %v\n", userID, basicCardTemplate)
 
-	reverseCardTemplate, err := q.CreateCardTemplate(ctx, database.CreateCardTemplateParams{
+	// A reverse note makes a card each way, the reverse one only when Back
+	// is filled in.
+	forwardCardTemplate, err := q.CreateCardTemplate(ctx, database.CreateCardTemplateParams{
 		NoteTypeID:   reverseNoteType.ID,
-		TemplateName: "Reverse Template",
+		TemplateName: "Forward Template",
 		FrontHtml:    "{{.Front}}",
 		BackHtml:     "{{.Back}}",
 		Css:          sql.NullString{},
 		OwnerID:      userID,
 	})
+	if err != nil {
+		return errors.New("Unable to create forward card template for user")
+	}
+	log.Printf("User %s card template added. Card template: %v\n", userID, forwardCardTemplate)
+
+	reverseCardTemplate, err := q.CreateCardTemplate(ctx, database.CreateCardTemplateParams{
+		NoteTypeID:     reverseNoteType.ID,
+		TemplateName:   "Reverse Template",
+		FrontHtml:      "{{.Back}}",
+		BackHtml:       "{{.Front}}",
+		Css:            sql.NullString{},
+		OwnerID:        userID,
+		ConditionField: sql.NullString{String: "Back", Valid: true},
+	})
 	if err != nil {
 		return errors.New("Unable to create reverse card template for user")
 	}
@@ -70,7 +104,7 @@ func OnboardNewUser(ctx context.Context, q *database.Queries, userID, displayNam
 		NoteTypeID:   clozeNoteType.ID,
 		TemplateName: "Cloze Template",
 		FrontHtml:    "Fill in the blank: {{cloze .Text}}",
-		BackHtml:     "{{.Text}}",
+		BackHtml:     "{{cloze .Text}}",
 		Css:          sql.NullString{},
 		OwnerID:      userID,
 	})
@@ -80,6 +114,12 @@ func OnboardNewUser(ctx context.Context, q *database.Queries, userID, displayNam
 	}
 	log.Printf("User %s card template added. Card template: %v\n", userID, clozeCardTemplate)
 
+	for _, tpl := range []database.CardTemplate{basicCardTemplate, forwardCardTemplate, reverseCardTemplate, clozeCardTemplate} {
+		if _, err := q.CreateCardTemplateVersion(ctx, tpl.ID); err != nil {
+			return errors.New("Unable to save card template versions for user")
+		}
+	}
+
 	startingDeck, err := q.CreateDeck(ctx, database.CreateDeckParams{
 		Name:    "Unclaimed Deck",
 		OwnerID: userID,
@@ -115,83 +155,49 @@ func OnboardNewUser(ctx context.Context, q *database.Queries, userID, displayNam
 
 func GenerateExampleNotesAndCards(ctx context.Context, q *database.Queries, userID, deckID, basicNTID, reverseNTID, clozeNTID string) error {
 	// 1) Basic note
-	basicNote, err := q.CreateNote(ctx, database.CreateNoteParams{
-		DeckID: deckID, NoteTypeID: basicNTID, OwnerID: userID,
+	err := createExampleNote(ctx, q, userID, deckID, basicNTID, map[string]string{
+		"Front": "This 1941 drama by Orson Welles is often cited as greatest film.",
+		"Back":  "Citizen Kane",
 	})
 	if err != nil {
 		return fmt.Errorf("creating basic note: %w", err)
 	}
-	_, err = q.CreateNoteField(ctx, database.CreateNoteFieldParams{
-		NoteID:       basicNote.ID,
-		FieldName:    "Front",
-		FieldContent: "This 1941 drama by Orson Welles is often cited as greatest film.",
-	})
-	if err != nil {
-		return err
-	}
-	_, err = q.CreateNoteField(ctx, database.CreateNoteFieldParams{
-		NoteID:       basicNote.ID,
-		FieldName:    "Back",
-		FieldContent: "Citizen Kane",
-	})
-	if err != nil {
-		return err
-	}
-	// Then auto-generate cards for the note
-	err = GenerateCardsForNote(ctx, q, basicNote.ID, basicNTID, userID)
-	if err != nil {
-		return err
-	}
 
 	// 2) Reverse note
-	revNote, err := q.CreateNote(ctx, database.CreateNoteParams{
-		DeckID: deckID, NoteTypeID: reverseNTID, OwnerID: userID,
+	err = createExampleNote(ctx, q, userID, deckID, reverseNTID, map[string]string{
+		"Front": "She became the first female Prime Minister in 1979",
+		"Back":  "Margaret Thatcher",
 	})
 	if err != nil {
 		return fmt.Errorf("creating reverse note: %w", err)
 	}
-	_, err = q.CreateNoteField(ctx, database.CreateNoteFieldParams{
-		NoteID:       revNote.ID,
-		FieldName:    "Front",
-		FieldContent: "She became the first female Prime Minister in 1979",
-	})
-	if err != nil {
-		return err
-	}
-	_, err = q.CreateNoteField(ctx, database.CreateNoteFieldParams{
-		NoteID:       revNote.ID,
-		FieldName:    "Back",
-		FieldContent: "Margaret Thatcher",
-	})
-	if err != nil {
-		return err
-	}
-	err = GenerateCardsForNote(ctx, q, revNote.ID, reverseNTID, userID)
-	if err != nil {
-		return err
-	}
 
 	// 3) Cloze note
-	clozeNote, err := q.CreateNote(ctx, database.CreateNoteParams{
-		DeckID: deckID, NoteTypeID: clozeNTID, OwnerID: userID,
+	err = createExampleNote(ctx, q, userID, deckID, clozeNTID, map[string]string{
+		"Text": "Leonardo da Vinci painted the {{c1::Mona Lisa::painting}} and {{c1::The Last Supper}}",
 	})
 	if err != nil {
 		return fmt.Errorf("creating cloze note: %w", err)
 	}
-	_, err = q.CreateNoteField(ctx, database.CreateNoteFieldParams{
-		NoteID:       clozeNote.ID,
-		FieldName:    "Text",
-		FieldContent: "Leonardo da Vinci painted the {{c1::Mona Lisa::painting}} and {{c1::The Last Supper}}",
+
+	return nil
+}
+
+// createExampleNote creates a note with the given field values and
+// auto-generates its cards.
+func createExampleNote(ctx context.Context, q *database.Queries, userID, deckID, noteTypeID string, values map[string]string) error {
+	fields, err := q.ListNoteTypeFields(ctx, noteTypeID)
+	if err != nil {
+		return fmt.Errorf("failed to get note type fields: %w", err)
+	}
+	note, err := q.CreateNote(ctx, database.CreateNoteParams{
+		DeckID: deckID, NoteTypeID: noteTypeID, OwnerID: userID,
 	})
 	if err != nil {
 		return err
 	}
-	// The "back" might be same or omitted. It's optional if your system wants it.
-
-	err = GenerateCardsForNote(ctx, q, clozeNote.ID, clozeNTID, userID)
-	if err != nil {
+	if err := createNoteFields(ctx, q, note.ID, fields, values); err != nil {
 		return err
 	}
-
-	return nil
+	return GenerateCardsForNote(ctx, q, note.ID, noteTypeID, userID)
 }
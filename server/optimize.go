@@ -0,0 +1,297 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	algorithm "github.com/threeroundsoftware/voidabyss/algo"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+	"github.com/threeroundsoftware/voidabyss/optimizer"
+)
+
+// OptimizeParamsRequest optionally restricts the training data to one deck,
+// or to the decks sharing a preset.
+type OptimizeParamsRequest struct {
+	DeckID   string `json:"deck_id" validate:"omitempty,alphanum,len=10,excluded_with=PresetID"`
+	PresetID string `json:"preset_id" validate:"omitempty,alphanum,len=10"`
+}
+
+// OptimizeParamsResponse reports the fitted weights and how much they improved the fit.
+type OptimizeParamsResponse struct {
+	Weights     []float64 `json:"weights"`
+	Loss        float64   `json:"loss"`
+	InitialLoss float64   `json:"initial_loss"`
+	Reviews     int       `json:"reviews"`
+	Stored      bool      `json:"stored"`
+}
+
+// FuncOptimizeParamsHandler fits FSRS weights to the user's review log.
+// Weights fitted on the whole log are stored as the user's parameters and
+// weights fitted on a preset's decks are stored on the preset; weights fitted
+// on a single deck are only returned. Large logs are sampled, as
+// optimizer.Config.MaxReviews describes, and the fit stops when the request is
+// canceled.
+func FuncOptimizeParamsHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req OptimizeParamsRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating optimize request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		ctx := c.Request().Context()
+		if req.DeckID != "" {
+			deck, err := app.Queries.GetDeck(ctx, req.DeckID)
+			if err != nil || deck.OwnerID != user.ID {
+				logging.SlogLogger.Error("Unauthorized access to deck", "user", user.ID, "deck", req.DeckID, "error", err)
+				return c.JSON(http.StatusNotFound, ErrorResponse{
+					Error: "Deck not found",
+				})
+			}
+		}
+
+		var result optimizer.Result
+		if req.PresetID != "" {
+			preset, err := app.Queries.GetDeckPreset(ctx, req.PresetID)
+			if err != nil || preset.OwnerID != user.ID {
+				logging.SlogLogger.Error("Unauthorized access to preset", "user", user.ID, "preset", req.PresetID, "error", err)
+				return c.JSON(http.StatusNotFound, ErrorResponse{
+					Error: "Preset not found",
+				})
+			}
+			result, err = OptimizePresetParams(ctx, app.Queries, preset)
+		} else {
+			result, err = OptimizeUserParams(ctx, app.Queries, user.ID, req.DeckID)
+		}
+		if errors.Is(err, optimizer.ErrNotEnoughReviews) {
+			return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+				Error: "Not enough reviews to optimize parameters",
+			})
+		}
+		if err != nil {
+			logging.SlogLogger.Error("Error optimizing parameters", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to optimize parameters",
+			})
+		}
+
+		return c.JSON(http.StatusOK, OptimizeParamsResponse{
+			Weights:     result.Params.W[:],
+			Loss:        result.Loss,
+			InitialLoss: result.InitialLoss,
+			Reviews:     result.Reviews,
+			Stored:      req.DeckID == "",
+		})
+	}
+}
+
+// OptimizeUserParams fits FSRS weights to a user's review log, or to one of
+// their decks when deckID is set. Fits over the whole log are saved to the
+// user's settings.
+func OptimizeUserParams(ctx context.Context, q *database.Queries, userID, deckID string) (optimizer.Result, error) {
+	setting, err := q.GetUserSetting(ctx, userID)
+	if err != nil {
+		return optimizer.Result{}, fmt.Errorf("failed to get user settings: %w", err)
+	}
+
+	var entries []reviewLogEntry
+	if deckID == "" {
+		rows, err := q.ListReviewLogByOwner(ctx, userID)
+		if err != nil {
+			return optimizer.Result{}, fmt.Errorf("failed to list review log: %w", err)
+		}
+		for _, r := range rows {
+			entries = append(entries, reviewLogEntry{CardID: r.CardID, ReviewTime: r.ReviewTime, RatingID: r.RatingID})
+		}
+	} else {
+		rows, err := q.ListReviewLogByDeck(ctx, deckID)
+		if err != nil {
+			return optimizer.Result{}, fmt.Errorf("failed to list review log: %w", err)
+		}
+		for _, r := range rows {
+			entries = append(entries, reviewLogEntry{CardID: r.CardID, ReviewTime: r.ReviewTime, RatingID: r.RatingID})
+		}
+	}
+
+	initial, err := decodeFSRSWeights(setting.FsrsWeights)
+	if err != nil {
+		return optimizer.Result{}, err
+	}
+	day, err := studyDay(setting)
+	if err != nil {
+		return optimizer.Result{}, err
+	}
+
+	result, err := optimizer.Optimize(ctx, buildReviewHistories(entries, day), initial, optimizer.DefaultConfig())
+	if err != nil {
+		return optimizer.Result{}, err
+	}
+
+	if deckID == "" {
+		weights, err := encodeFSRSWeights(result.Params)
+		if err != nil {
+			return optimizer.Result{}, err
+		}
+		err = q.UpdateUserFSRSWeights(ctx, database.UpdateUserFSRSWeightsParams{
+			FsrsWeights: weights,
+			UserID:      userID,
+		})
+		if err != nil {
+			return optimizer.Result{}, fmt.Errorf("failed to store fitted weights: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// OptimizePresetParams fits FSRS weights to the review log of every deck using
+// preset and stores them on the preset.
+func OptimizePresetParams(ctx context.Context, q *database.Queries, preset database.DeckPreset) (optimizer.Result, error) {
+	rows, err := q.ListReviewLogByPreset(ctx, sql.NullString{String: preset.ID, Valid: true})
+	if err != nil {
+		return optimizer.Result{}, fmt.Errorf("failed to list review log: %w", err)
+	}
+	var entries []reviewLogEntry
+	for _, r := range rows {
+		entries = append(entries, reviewLogEntry{CardID: r.CardID, ReviewTime: r.ReviewTime, RatingID: r.RatingID})
+	}
+
+	initial := algorithm.DefaultParams()
+	if preset.FsrsWeights.Valid {
+		initial, err = decodeFSRSWeights(preset.FsrsWeights)
+		if err != nil {
+			return optimizer.Result{}, err
+		}
+	} else {
+		setting, err := q.GetUserSetting(ctx, preset.OwnerID)
+		if err != nil {
+			return optimizer.Result{}, fmt.Errorf("failed to get user settings: %w", err)
+		}
+		initial, err = decodeFSRSWeights(setting.FsrsWeights)
+		if err != nil {
+			return optimizer.Result{}, err
+		}
+	}
+
+	day, err := userStudyDay(ctx, q, preset.OwnerID)
+	if err != nil {
+		return optimizer.Result{}, err
+	}
+
+	result, err := optimizer.Optimize(ctx, buildReviewHistories(entries, day), initial, optimizer.DefaultConfig())
+	if err != nil {
+		return optimizer.Result{}, err
+	}
+
+	weights, err := encodeFSRSWeights(result.Params)
+	if err != nil {
+		return optimizer.Result{}, err
+	}
+	err = q.UpdateDeckPresetFSRSWeights(ctx, database.UpdateDeckPresetFSRSWeightsParams{
+		FsrsWeights: weights,
+		ID:          preset.ID,
+	})
+	if err != nil {
+		return optimizer.Result{}, fmt.Errorf("failed to store fitted weights: %w", err)
+	}
+	return result, nil
+}
+
+// reviewLogEntry is one row of a review log, independent of the query it came from.
+type reviewLogEntry struct {
+	CardID     string
+	ReviewTime time.Time
+	RatingID   sql.NullString
+}
+
+// buildReviewHistories groups review log entries, ordered by card and review
+// time, into per-card histories with the study days elapsed between reviews.
+func buildReviewHistories(entries []reviewLogEntry, day algorithm.StudyDay) []optimizer.History {
+	var histories []optimizer.History
+	var current optimizer.History
+	var currentCard string
+	var lastReview time.Time
+
+	for _, e := range entries {
+		rating, ok := ratingFromID(e.RatingID)
+		if !ok {
+			continue
+		}
+		if e.CardID != currentCard {
+			if len(current) > 0 {
+				histories = append(histories, current)
+			}
+			current = nil
+			currentCard = e.CardID
+		}
+
+		var elapsed float64
+		if len(current) > 0 {
+			elapsed = day.Elapsed(lastReview, e.ReviewTime)
+		}
+		current = append(current, optimizer.Review{Rating: rating, ElapsedDays: elapsed})
+		lastReview = e.ReviewTime
+	}
+	if len(current) > 0 {
+		histories = append(histories, current)
+	}
+	return histories
+}
+
+// ratingFromID maps a review.rating_id to its FSRS grade. Rating rows use the
+// grade as their id (see 0004_fsrs_parameters.sql).
+func ratingFromID(id sql.NullString) (algorithm.Rating, bool) {
+	if !id.Valid {
+		return 0, false
+	}
+	n, err := strconv.Atoi(id.String)
+	if err != nil || n < int(algorithm.Again) || n > int(algorithm.Easy) {
+		return 0, false
+	}
+	return algorithm.Rating(n), true
+}
+
+// decodeFSRSWeights reads stored weights, falling back to the defaults when none are stored.
+func decodeFSRSWeights(stored sql.NullString) (algorithm.FSRSParams, error) {
+	if !stored.Valid || stored.String == "" {
+		return algorithm.DefaultParams(), nil
+	}
+	var weights []float64
+	if err := json.Unmarshal([]byte(stored.String), &weights); err != nil {
+		return algorithm.FSRSParams{}, fmt.Errorf("failed to decode fsrs weights: %w", err)
+	}
+	var params algorithm.FSRSParams
+	if len(weights) != len(params.W) {
+		return algorithm.FSRSParams{}, fmt.Errorf("expected %d fsrs weights, got %d", len(params.W), len(weights))
+	}
+	copy(params.W[:], weights)
+	return params, nil
+}
+
+// encodeFSRSWeights serializes weights for storage as a JSON array.
+func encodeFSRSWeights(params algorithm.FSRSParams) (sql.NullString, error) {
+	b, err := json.Marshal(params.W[:])
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode fsrs weights: %w", err)
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
@@ -0,0 +1,671 @@
+package server
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	algorithm "github.com/threeroundsoftware/voidabyss/algo"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+	"github.com/threeroundsoftware/voidabyss/optimizer"
+)
+
+// PresetResponse represents a deck preset. Null options inherit the user's defaults.
+type PresetResponse struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	FsrsWeights      []float64 `json:"fsrs_weights"`
+	DesiredRetention *float64  `json:"desired_retention"`
+	MaximumInterval  *int64    `json:"maximum_interval"`
+	NewCardsPerDay   *int64    `json:"new_cards_per_day"`
+	ReviewsPerDay    *int64    `json:"reviews_per_day"`
+	LearningSteps    *string   `json:"learning_steps"`
+	RelearningSteps  *string   `json:"relearning_steps"`
+	LeechThreshold   *int64    `json:"leech_threshold"`
+	LeechAction      *string   `json:"leech_action"`
+	BurySiblings     *bool     `json:"bury_siblings"`
+	// Scheduler is "fsrs" or "sm2"; the remaining options only apply to SM-2.
+	Scheduler          *string   `json:"scheduler"`
+	StartingEase       *float64  `json:"starting_ease"`
+	EasyBonus          *float64  `json:"easy_bonus"`
+	IntervalModifier   *float64  `json:"interval_modifier"`
+	GraduatingInterval *int64    `json:"graduating_interval"`
+	EasyInterval       *int64    `json:"easy_interval"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// PresetsListResponse encapsulates a list of PresetResponse.
+type PresetsListResponse struct {
+	Presets []PresetResponse `json:"presets"`
+}
+
+// PresetRequest creates or updates a preset. Omitted options inherit the user's defaults.
+type PresetRequest struct {
+	ID                 string    `param:"presetID"`
+	Name               string    `json:"name" validate:"required,max=64"`
+	FsrsWeights        []float64 `json:"fsrs_weights" validate:"omitempty,len=19"`
+	DesiredRetention   *float64  `json:"desired_retention" validate:"omitempty,gt=0,lt=1"`
+	MaximumInterval    *int64    `json:"maximum_interval" validate:"omitempty,min=1"`
+	NewCardsPerDay     *int64    `json:"new_cards_per_day" validate:"omitempty,min=0"`
+	ReviewsPerDay      *int64    `json:"reviews_per_day" validate:"omitempty,min=0"`
+	LearningSteps      *string   `json:"learning_steps"`
+	RelearningSteps    *string   `json:"relearning_steps"`
+	LeechThreshold     *int64    `json:"leech_threshold" validate:"omitempty,min=1"`
+	LeechAction        *string   `json:"leech_action" validate:"omitempty,oneof=tag suspend move"`
+	BurySiblings       *bool     `json:"bury_siblings"`
+	Scheduler          *string   `json:"scheduler" validate:"omitempty,oneof=fsrs sm2"`
+	StartingEase       *float64  `json:"starting_ease" validate:"omitempty,gte=1.3"`
+	EasyBonus          *float64  `json:"easy_bonus" validate:"omitempty,gte=1"`
+	IntervalModifier   *float64  `json:"interval_modifier" validate:"omitempty,gt=0"`
+	GraduatingInterval *int64    `json:"graduating_interval" validate:"omitempty,min=1"`
+	EasyInterval       *int64    `json:"easy_interval" validate:"omitempty,min=1"`
+}
+
+// PresetDetailRequest defines the structure for route parameters with validation
+type PresetDetailRequest struct {
+	ID string `param:"presetID" validate:"required,alphanum,len=10"`
+}
+
+// SetDeckPresetRequest assigns a preset to a deck; an empty preset_id reverts to the user's defaults.
+type SetDeckPresetRequest struct {
+	DeckID   string `param:"deckID" validate:"required,alphanum,len=10"`
+	PresetID string `json:"preset_id" validate:"omitempty,alphanum,len=10"`
+}
+
+// SchedulerSettingsResponse is a fully resolved set of scheduler options.
+type SchedulerSettingsResponse struct {
+	Scheduler        string              `json:"scheduler"`
+	FsrsWeights      []float64           `json:"fsrs_weights"`
+	DesiredRetention float64             `json:"desired_retention"`
+	MaximumInterval  int64               `json:"maximum_interval"`
+	NewCardsPerDay   int64               `json:"new_cards_per_day"`
+	ReviewsPerDay    int64               `json:"reviews_per_day"`
+	LearningSteps    string              `json:"learning_steps"`
+	RelearningSteps  string              `json:"relearning_steps"`
+	IntervalFuzz     bool                `json:"interval_fuzz"`
+	LoadBalance      bool                `json:"load_balance"`
+	LeechThreshold   int64               `json:"leech_threshold"`
+	LeechAction      string              `json:"leech_action"`
+	BurySiblings     bool                `json:"bury_siblings"`
+	Timezone         string              `json:"timezone"`
+	DayStartHour     int                 `json:"day_start_hour"`
+	SM2              SM2SettingsResponse `json:"sm2"`
+}
+
+// SM2SettingsResponse holds the options of the SM-2 scheduler.
+type SM2SettingsResponse struct {
+	StartingEase       float64 `json:"starting_ease"`
+	EasyBonus          float64 `json:"easy_bonus"`
+	IntervalModifier   float64 `json:"interval_modifier"`
+	HardInterval       float64 `json:"hard_interval"`
+	LapseInterval      float64 `json:"lapse_interval"`
+	GraduatingInterval int64   `json:"graduating_interval"`
+	EasyInterval       int64   `json:"easy_interval"`
+}
+
+// UpdateSchedulerSettingsRequest replaces the user's scheduler defaults.
+type UpdateSchedulerSettingsRequest struct {
+	DesiredRetention float64 `json:"desired_retention" validate:"gt=0,lt=1"`
+	MaximumInterval  int64   `json:"maximum_interval" validate:"min=1"`
+	NewCardsPerDay   int64   `json:"new_cards_per_day" validate:"min=0"`
+	ReviewsPerDay    int64   `json:"reviews_per_day" validate:"min=0"`
+	LearningSteps    string  `json:"learning_steps"`
+	RelearningSteps  string  `json:"relearning_steps"`
+	IntervalFuzz     bool    `json:"interval_fuzz"`
+	LoadBalance      bool    `json:"load_balance"`
+	// Timezone is an IANA zone name; empty means UTC.
+	Timezone string `json:"timezone" validate:"omitempty,timezone"`
+	// DayStartHour is the local hour at which a new study day begins.
+	DayStartHour int64 `json:"day_start_hour" validate:"min=0,max=23"`
+}
+
+// FuncGetPresetsHandler lists the user's deck presets.
+func FuncGetPresetsHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		presets, err := app.Queries.ListDeckPresetsByOwner(c.Request().Context(), user.ID)
+		if err != nil {
+			logging.SlogLogger.Error("Error retrieving deck presets", "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to retrieve presets",
+			})
+		}
+
+		response := PresetsListResponse{
+			Presets: make([]PresetResponse, 0, len(presets)),
+		}
+		for _, preset := range presets {
+			response.Presets = append(response.Presets, convertPresetToResponse(preset))
+		}
+		return c.JSON(http.StatusOK, response)
+	}
+}
+
+// FuncCreatePresetHandler creates a deck preset.
+func FuncCreatePresetHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req PresetRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating create preset request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		params, err := req.toParams()
+		if err != nil {
+			logging.SlogLogger.Error("Invalid preset options", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: invalidPresetMessage(err),
+			})
+		}
+
+		preset, err := app.Queries.CreateDeckPreset(c.Request().Context(), database.CreateDeckPresetParams{
+			OwnerID:            user.ID,
+			Name:               req.Name,
+			FsrsWeights:        params.FsrsWeights,
+			DesiredRetention:   params.DesiredRetention,
+			MaximumInterval:    params.MaximumInterval,
+			NewCardsPerDay:     params.NewCardsPerDay,
+			ReviewsPerDay:      params.ReviewsPerDay,
+			LearningSteps:      params.LearningSteps,
+			RelearningSteps:    params.RelearningSteps,
+			LeechThreshold:     params.LeechThreshold,
+			LeechAction:        params.LeechAction,
+			BurySiblings:       params.BurySiblings,
+			Scheduler:          params.Scheduler,
+			StartingEase:       params.StartingEase,
+			EasyBonus:          params.EasyBonus,
+			IntervalModifier:   params.IntervalModifier,
+			GraduatingInterval: params.GraduatingInterval,
+			EasyInterval:       params.EasyInterval,
+		})
+		if err != nil {
+			logging.SlogLogger.Error("Error creating deck preset", "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to create preset",
+			})
+		}
+
+		return c.JSON(http.StatusCreated, convertPresetToResponse(preset))
+	}
+}
+
+// FuncUpdatePresetHandler replaces all options of a deck preset.
+func FuncUpdatePresetHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req PresetRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating update preset request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		ctx := c.Request().Context()
+		existing, err := app.Queries.GetDeckPreset(ctx, req.ID)
+		if err != nil || existing.OwnerID != user.ID {
+			logging.SlogLogger.Error("Unauthorized access to preset", "user", user.ID, "preset", req.ID, "error", err)
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Preset not found",
+			})
+		}
+
+		params, err := req.toParams()
+		if err != nil {
+			logging.SlogLogger.Error("Invalid preset options", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: invalidPresetMessage(err),
+			})
+		}
+		params.Name = req.Name
+		params.ID = existing.ID
+
+		preset, err := app.Queries.UpdateDeckPreset(ctx, params)
+		if err != nil {
+			logging.SlogLogger.Error("Error updating deck preset", "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to update preset",
+			})
+		}
+
+		return c.JSON(http.StatusOK, convertPresetToResponse(preset))
+	}
+}
+
+// FuncDeletePresetHandler deletes a deck preset. Decks using it revert to the user's defaults.
+func FuncDeletePresetHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req PresetDetailRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating preset request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		ctx := c.Request().Context()
+		preset, err := app.Queries.GetDeckPreset(ctx, req.ID)
+		if err != nil || preset.OwnerID != user.ID {
+			logging.SlogLogger.Error("Unauthorized access to preset", "user", user.ID, "preset", req.ID, "error", err)
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Preset not found",
+			})
+		}
+
+		if err := app.Queries.DeleteDeckPreset(ctx, preset.ID); err != nil {
+			logging.SlogLogger.Error("Error deleting deck preset", "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to delete preset",
+			})
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+// FuncSetDeckPresetHandler assigns a preset to one of the user's decks.
+func FuncSetDeckPresetHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req SetDeckPresetRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating deck preset request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		ctx := c.Request().Context()
+		deck, err := app.Queries.GetDeck(ctx, req.DeckID)
+		if err != nil || deck.OwnerID != user.ID {
+			logging.SlogLogger.Error("Unauthorized access to deck", "user", user.ID, "deck", req.DeckID, "error", err)
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Deck not found",
+			})
+		}
+
+		var presetID sql.NullString
+		if req.PresetID != "" {
+			preset, err := app.Queries.GetDeckPreset(ctx, req.PresetID)
+			if err != nil || preset.OwnerID != user.ID {
+				logging.SlogLogger.Error("Unauthorized access to preset", "user", user.ID, "preset", req.PresetID, "error", err)
+				return c.JSON(http.StatusNotFound, ErrorResponse{
+					Error: "Preset not found",
+				})
+			}
+			presetID = sql.NullString{String: preset.ID, Valid: true}
+		}
+
+		deck, err = app.Queries.SetDeckPreset(ctx, database.SetDeckPresetParams{
+			PresetID: presetID,
+			ID:       deck.ID,
+		})
+		if err != nil {
+			logging.SlogLogger.Error("Error setting deck preset", "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to set deck preset",
+			})
+		}
+
+		return c.JSON(http.StatusOK, convertToDeckResponse(deck))
+	}
+}
+
+// FuncGetDeckSettingsHandler returns the effective scheduler settings of a deck.
+func FuncGetDeckSettingsHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req GetDeckRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating deckID from request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		ctx := c.Request().Context()
+		deck, err := app.Queries.GetDeck(ctx, req.ID)
+		if err != nil || deck.OwnerID != user.ID {
+			logging.SlogLogger.Error("Unauthorized access to deck", "user", user.ID, "deck", req.ID, "error", err)
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Deck not found",
+			})
+		}
+
+		settings, err := ResolveSchedulerSettings(ctx, app.Queries, user.ID, deck)
+		if err != nil {
+			logging.SlogLogger.Error("Error resolving scheduler settings", "deck", deck.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to resolve deck settings",
+			})
+		}
+
+		return c.JSON(http.StatusOK, convertSchedulerSettingsToResponse(settings))
+	}
+}
+
+// FuncGetSchedulerSettingsHandler returns the user's scheduler defaults.
+func FuncGetSchedulerSettingsHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		setting, err := app.Queries.GetUserSetting(c.Request().Context(), user.ID)
+		if err != nil {
+			logging.SlogLogger.Error("Error retrieving user settings", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to retrieve settings",
+			})
+		}
+
+		settings, err := userSchedulerSettings(setting)
+		if err != nil {
+			logging.SlogLogger.Error("Error reading scheduler settings", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to retrieve settings",
+			})
+		}
+
+		return c.JSON(http.StatusOK, convertSchedulerSettingsToResponse(settings))
+	}
+}
+
+// FuncUpdateSchedulerSettingsHandler replaces the user's scheduler defaults.
+func FuncUpdateSchedulerSettingsHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req UpdateSchedulerSettingsRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating scheduler settings request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		learning, err := parseSteps(req.LearningSteps)
+		if err != nil {
+			logging.SlogLogger.Error("Invalid learning steps", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Invalid learning steps",
+			})
+		}
+		relearning, err := parseSteps(req.RelearningSteps)
+		if err != nil {
+			logging.SlogLogger.Error("Invalid relearning steps", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Invalid relearning steps",
+			})
+		}
+
+		setting, err := app.Queries.UpdateUserSchedulerSetting(c.Request().Context(), database.UpdateUserSchedulerSettingParams{
+			DesiredRetention:   req.DesiredRetention,
+			MaximumInterval:    req.MaximumInterval,
+			DailyNewCardsLimit: req.NewCardsPerDay,
+			DailyReviewLimit:   req.ReviewsPerDay,
+			LearningSteps:      formatSteps(learning),
+			RelearningSteps:    formatSteps(relearning),
+			IntervalFuzz:       req.IntervalFuzz,
+			LoadBalance:        req.LoadBalance,
+			Timezone:           cmp.Or(req.Timezone, "UTC"),
+			DayStartHour:       req.DayStartHour,
+			UserID:             user.ID,
+		})
+		if err != nil {
+			logging.SlogLogger.Error("Error updating scheduler settings", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to update settings",
+			})
+		}
+
+		settings, err := userSchedulerSettings(setting)
+		if err != nil {
+			logging.SlogLogger.Error("Error reading scheduler settings", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to update settings",
+			})
+		}
+
+		return c.JSON(http.StatusOK, convertSchedulerSettingsToResponse(settings))
+	}
+}
+
+// invalidPresetMessage describes a preset option toParams rejected.
+func invalidPresetMessage(err error) string {
+	if errors.Is(err, optimizer.ErrInvalidWeights) {
+		return "FSRS weights out of range"
+	}
+	return "Invalid learning or relearning steps"
+}
+
+// toParams converts the request into nullable columns, normalizing step strings.
+func (req PresetRequest) toParams() (database.UpdateDeckPresetParams, error) {
+	var params database.UpdateDeckPresetParams
+
+	if len(req.FsrsWeights) > 0 {
+		var weights algorithm.FSRSParams
+		copy(weights.W[:], req.FsrsWeights)
+		if err := optimizer.CheckParams(weights); err != nil {
+			return params, err
+		}
+		encoded, err := encodeFSRSWeights(weights)
+		if err != nil {
+			return params, err
+		}
+		params.FsrsWeights = encoded
+	}
+	if req.DesiredRetention != nil {
+		params.DesiredRetention = sql.NullFloat64{Float64: *req.DesiredRetention, Valid: true}
+	}
+	if req.MaximumInterval != nil {
+		params.MaximumInterval = sql.NullInt64{Int64: *req.MaximumInterval, Valid: true}
+	}
+	if req.NewCardsPerDay != nil {
+		params.NewCardsPerDay = sql.NullInt64{Int64: *req.NewCardsPerDay, Valid: true}
+	}
+	if req.ReviewsPerDay != nil {
+		params.ReviewsPerDay = sql.NullInt64{Int64: *req.ReviewsPerDay, Valid: true}
+	}
+	if req.LearningSteps != nil {
+		steps, err := parseSteps(*req.LearningSteps)
+		if err != nil {
+			return params, err
+		}
+		params.LearningSteps = sql.NullString{String: formatSteps(steps), Valid: true}
+	}
+	if req.RelearningSteps != nil {
+		steps, err := parseSteps(*req.RelearningSteps)
+		if err != nil {
+			return params, err
+		}
+		params.RelearningSteps = sql.NullString{String: formatSteps(steps), Valid: true}
+	}
+	if req.LeechThreshold != nil {
+		params.LeechThreshold = sql.NullInt64{Int64: *req.LeechThreshold, Valid: true}
+	}
+	if req.LeechAction != nil {
+		params.LeechAction = sql.NullString{String: *req.LeechAction, Valid: true}
+	}
+	if req.BurySiblings != nil {
+		params.BurySiblings = sql.NullBool{Bool: *req.BurySiblings, Valid: true}
+	}
+	if req.Scheduler != nil {
+		params.Scheduler = sql.NullString{String: *req.Scheduler, Valid: true}
+	}
+	if req.StartingEase != nil {
+		params.StartingEase = sql.NullFloat64{Float64: *req.StartingEase, Valid: true}
+	}
+	if req.EasyBonus != nil {
+		params.EasyBonus = sql.NullFloat64{Float64: *req.EasyBonus, Valid: true}
+	}
+	if req.IntervalModifier != nil {
+		params.IntervalModifier = sql.NullFloat64{Float64: *req.IntervalModifier, Valid: true}
+	}
+	if req.GraduatingInterval != nil {
+		params.GraduatingInterval = sql.NullInt64{Int64: *req.GraduatingInterval, Valid: true}
+	}
+	if req.EasyInterval != nil {
+		params.EasyInterval = sql.NullInt64{Int64: *req.EasyInterval, Valid: true}
+	}
+	return params, nil
+}
+
+// convertPresetToResponse converts a database.DeckPreset to a PresetResponse.
+func convertPresetToResponse(preset database.DeckPreset) PresetResponse {
+	response := PresetResponse{
+		ID:        preset.ID,
+		Name:      preset.Name,
+		CreatedAt: preset.CreatedAt,
+		UpdatedAt: preset.UpdatedAt,
+	}
+	if preset.FsrsWeights.Valid {
+		if params, err := decodeFSRSWeights(preset.FsrsWeights); err == nil {
+			response.FsrsWeights = params.W[:]
+		}
+	}
+	if preset.DesiredRetention.Valid {
+		response.DesiredRetention = &preset.DesiredRetention.Float64
+	}
+	if preset.MaximumInterval.Valid {
+		response.MaximumInterval = &preset.MaximumInterval.Int64
+	}
+	if preset.NewCardsPerDay.Valid {
+		response.NewCardsPerDay = &preset.NewCardsPerDay.Int64
+	}
+	if preset.ReviewsPerDay.Valid {
+		response.ReviewsPerDay = &preset.ReviewsPerDay.Int64
+	}
+	if preset.LearningSteps.Valid {
+		response.LearningSteps = &preset.LearningSteps.String
+	}
+	if preset.RelearningSteps.Valid {
+		response.RelearningSteps = &preset.RelearningSteps.String
+	}
+	if preset.LeechThreshold.Valid {
+		response.LeechThreshold = &preset.LeechThreshold.Int64
+	}
+	if preset.LeechAction.Valid {
+		response.LeechAction = &preset.LeechAction.String
+	}
+	if preset.BurySiblings.Valid {
+		response.BurySiblings = &preset.BurySiblings.Bool
+	}
+	if preset.Scheduler.Valid {
+		response.Scheduler = &preset.Scheduler.String
+	}
+	if preset.StartingEase.Valid {
+		response.StartingEase = &preset.StartingEase.Float64
+	}
+	if preset.EasyBonus.Valid {
+		response.EasyBonus = &preset.EasyBonus.Float64
+	}
+	if preset.IntervalModifier.Valid {
+		response.IntervalModifier = &preset.IntervalModifier.Float64
+	}
+	if preset.GraduatingInterval.Valid {
+		response.GraduatingInterval = &preset.GraduatingInterval.Int64
+	}
+	if preset.EasyInterval.Valid {
+		response.EasyInterval = &preset.EasyInterval.Int64
+	}
+	return response
+}
+
+// convertSchedulerSettingsToResponse converts resolved settings to a SchedulerSettingsResponse.
+func convertSchedulerSettingsToResponse(settings SchedulerSettings) SchedulerSettingsResponse {
+	return SchedulerSettingsResponse{
+		Scheduler:        string(settings.Scheduler),
+		FsrsWeights:      settings.Params.W[:],
+		DesiredRetention: settings.DesiredRetention,
+		MaximumInterval:  settings.MaximumInterval,
+		NewCardsPerDay:   settings.NewCardsPerDay,
+		ReviewsPerDay:    settings.ReviewsPerDay,
+		LearningSteps:    formatSteps(settings.LearningSteps),
+		RelearningSteps:  formatSteps(settings.RelearningSteps),
+		IntervalFuzz:     settings.IntervalFuzz,
+		LoadBalance:      settings.LoadBalance,
+		LeechThreshold:   settings.LeechThreshold,
+		LeechAction:      settings.LeechAction,
+		BurySiblings:     settings.BurySiblings,
+		Timezone:         settings.Day.Location.String(),
+		DayStartHour:     settings.Day.StartHour,
+		SM2: SM2SettingsResponse{
+			StartingEase:       settings.SM2.StartingEase,
+			EasyBonus:          settings.SM2.EasyBonus,
+			IntervalModifier:   settings.SM2.IntervalModifier,
+			HardInterval:       settings.SM2.HardInterval,
+			LapseInterval:      settings.SM2.LapseInterval,
+			GraduatingInterval: settings.SM2.GraduatingInterval,
+			EasyInterval:       settings.SM2.EasyInterval,
+		},
+	}
+}
@@ -0,0 +1,433 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	algorithm "github.com/threeroundsoftware/voidabyss/algo"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+)
+
+// Queue sort orders for review and new cards. Learning cards are always
+// shown in due order.
+const (
+	QueueOrderDue            = "due"
+	QueueOrderRetrievability = "retrievability"
+	QueueOrderRandom         = "random"
+	QueueOrderDeck           = "deck"
+)
+
+// Kinds of cards in a queue.
+const (
+	QueueKindNew      = "new"
+	QueueKindLearning = "learning"
+	QueueKindReview   = "review"
+)
+
+// learnAheadLimit lets learning cards that come due shortly be studied now
+// instead of leaving the queue empty.
+const learnAheadLimit = 20 * time.Minute
+
+var ErrDeckNotFound = errors.New("deck not found")
+
+// QueueOptions selects and orders the cards of a queue.
+type QueueOptions struct {
+	// DeckIDs restricts the queue to these decks; empty means all of the user's decks.
+	DeckIDs []string
+	Order   string
+	// Limit caps the number of cards returned; 0 means no cap.
+	Limit int
+}
+
+// QueueCard is a card due for study.
+type QueueCard struct {
+	database.ListStudyCardsByOwnerRow
+	Kind           string
+	Retrievability float64
+}
+
+// Queue is today's study queue. The counts cover the whole queue, before Limit.
+type Queue struct {
+	Cards    []QueueCard
+	New      int
+	Learning int
+	Review   int
+}
+
+// QueueRequest defines the query parameters for GET /api/queue.
+type QueueRequest struct {
+	DeckIDs []string `query:"deck_id" validate:"dive,alphanum,len=10"`
+	Order   string   `query:"order" validate:"omitempty,oneof=due retrievability random deck"`
+	Limit   int      `query:"limit" validate:"min=0,max=1000"`
+}
+
+// QueueCardResponse is one card of the study queue.
+type QueueCardResponse struct {
+	CardStateResponse
+	DeckID         string  `json:"deck_id"`
+	DeckName       string  `json:"deck_name"`
+	Kind           string  `json:"kind"`
+	Retrievability float64 `json:"retrievability"`
+}
+
+// QueueResponse is today's study queue.
+type QueueResponse struct {
+	New      int                 `json:"new"`
+	Learning int                 `json:"learning"`
+	Review   int                 `json:"review"`
+	Cards    []QueueCardResponse `json:"cards"`
+}
+
+// FuncGetQueueHandler returns the cards the user should study now.
+func FuncGetQueueHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req QueueRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating queue request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		queue, err := BuildQueue(c.Request().Context(), app.Queries, user.ID, QueueOptions{
+			DeckIDs: req.DeckIDs,
+			Order:   req.Order,
+			Limit:   req.Limit,
+		}, time.Now().UTC())
+		if errors.Is(err, ErrDeckNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Deck not found",
+			})
+		}
+		if err != nil {
+			logging.SlogLogger.Error("Error building queue", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to build queue",
+			})
+		}
+
+		return c.JSON(http.StatusOK, convertQueueToResponse(queue))
+	}
+}
+
+// BuildQueue returns the user's study queue at now: learning cards due within
+// the learn-ahead limit, followed by today's reviews with new cards mixed in.
+// Reviews and new cards are capped by the per-deck and per-user daily limits,
+// less what was already studied today. Buried cards are left out, and in decks
+// that bury siblings only one new or review card of a note is queued.
+func BuildQueue(ctx context.Context, q *database.Queries, userID string, opts QueueOptions, now time.Time) (Queue, error) {
+	setting, err := q.GetUserSetting(ctx, userID)
+	if err != nil {
+		return Queue{}, fmt.Errorf("failed to get user settings: %w", err)
+	}
+	userSettings, err := userSchedulerSettings(setting)
+	if err != nil {
+		return Queue{}, err
+	}
+
+	decks, err := queueDecks(ctx, q, userID, opts.DeckIDs)
+	if err != nil {
+		return Queue{}, err
+	}
+
+	// Remaining daily limits, per deck and for the user as a whole.
+	today := userSettings.Day.Start(now)
+	newLeft := make(map[string]int64, len(decks))
+	reviewLeft := make(map[string]int64, len(decks))
+	burySiblings := make(map[string]bool, len(decks))
+	presets := make(map[string]SchedulerSettings)
+	for _, deck := range decks {
+		settings := userSettings
+		if deck.PresetID.Valid {
+			cached, ok := presets[deck.PresetID.String]
+			if !ok {
+				preset, err := q.GetDeckPreset(ctx, deck.PresetID.String)
+				if err != nil {
+					return Queue{}, fmt.Errorf("failed to get deck preset: %w", err)
+				}
+				cached, err = userSettings.withPreset(preset)
+				if err != nil {
+					return Queue{}, err
+				}
+				presets[deck.PresetID.String] = cached
+			}
+			settings = cached
+		}
+		newLeft[deck.ID] = settings.NewCardsPerDay
+		reviewLeft[deck.ID] = settings.ReviewsPerDay
+		burySiblings[deck.ID] = settings.BurySiblings
+	}
+	userNewLeft := userSettings.NewCardsPerDay
+	userReviewLeft := userSettings.ReviewsPerDay
+
+	newCounts, err := q.CountNewCardsStudiedSince(ctx, database.CountNewCardsStudiedSinceParams{
+		OwnerID: userID,
+		Since:   today,
+	})
+	if err != nil {
+		return Queue{}, fmt.Errorf("failed to count new cards studied today: %w", err)
+	}
+	for _, row := range newCounts {
+		newLeft[row.DeckID] -= row.NewCount
+		userNewLeft -= row.NewCount
+	}
+	reviewCounts, err := q.CountReviewCardsStudiedSince(ctx, database.CountReviewCardsStudiedSinceParams{
+		OwnerID: userID,
+		Since:   today,
+	})
+	if err != nil {
+		return Queue{}, fmt.Errorf("failed to count reviews studied today: %w", err)
+	}
+	for _, row := range reviewCounts {
+		reviewLeft[row.DeckID] -= row.ReviewCount
+		userReviewLeft -= row.ReviewCount
+	}
+
+	rows, err := q.ListStudyCardsByOwner(ctx, database.ListStudyCardsByOwnerParams{
+		OwnerID: userID,
+		Now:     sql.NullTime{Time: now, Valid: true},
+		DueDate: sql.NullTime{Time: userSettings.Day.AddDays(today, 1), Valid: true},
+	})
+	if err != nil {
+		return Queue{}, fmt.Errorf("failed to list cards: %w", err)
+	}
+
+	var learning, reviews, news []QueueCard
+	for _, row := range rows {
+		if _, ok := newLeft[row.DeckID]; !ok {
+			continue
+		}
+		card := QueueCard{ListStudyCardsByOwnerRow: row}
+		switch algorithm.State(convertNullString(row.Status)) {
+		case algorithm.StateLearning, algorithm.StateRelearning:
+			if row.DueDate.Valid && row.DueDate.Time.After(now.Add(learnAheadLimit)) {
+				continue
+			}
+			card.Kind = QueueKindLearning
+			card.Retrievability = queueRetrievability(row, now)
+			learning = append(learning, card)
+		case algorithm.StateReview:
+			card.Kind = QueueKindReview
+			card.Retrievability = queueRetrievability(row, now)
+			reviews = append(reviews, card)
+		default:
+			card.Kind = QueueKindNew
+			news = append(news, card)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(queueSeed(userID, today)))
+	sortQueueCards(reviews, opts.Order, rng)
+	sortQueueCards(news, opts.Order, rng)
+	queued := make(map[string]bool)
+	reviews = takeWithinLimits(withoutSiblings(reviews, queued, burySiblings), reviewLeft, userReviewLeft)
+	for _, card := range reviews {
+		queued[card.NoteID] = true
+	}
+	news = takeWithinLimits(withoutSiblings(news, queued, burySiblings), newLeft, userNewLeft)
+
+	queue := Queue{
+		New:      len(news),
+		Learning: len(learning),
+		Review:   len(reviews),
+	}
+	queue.Cards = append(learning, interleave(reviews, news)...)
+	if opts.Limit > 0 && len(queue.Cards) > opts.Limit {
+		queue.Cards = queue.Cards[:opts.Limit]
+	}
+	return queue, nil
+}
+
+// queueDecks returns the decks a queue is built from, checking that the user owns them.
+func queueDecks(ctx context.Context, q *database.Queries, userID string, deckIDs []string) ([]database.Deck, error) {
+	if len(deckIDs) == 0 {
+		decks, err := q.ListDecksByOwnerId(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list decks: %w", err)
+		}
+		return decks, nil
+	}
+
+	decks := make([]database.Deck, 0, len(deckIDs))
+	for _, id := range uniqueStringSlice(deckIDs) {
+		deck, err := q.GetDeck(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && deck.OwnerID != userID) {
+			return nil, ErrDeckNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get deck: %w", err)
+		}
+		decks = append(decks, deck)
+	}
+	return decks, nil
+}
+
+// queueRetrievability estimates a card's current retrievability. The last
+// review is taken to be interval days before the due date, which holds for
+// review cards; (re)learning cards were last reviewed at their last update.
+func queueRetrievability(row database.ListStudyCardsByOwnerRow, now time.Time) float64 {
+	if !row.Stability.Valid || row.Stability.Float64 <= 0 {
+		return 0
+	}
+	last := row.UpdatedAt
+	if algorithm.State(convertNullString(row.Status)) == algorithm.StateReview && row.DueDate.Valid {
+		last = row.DueDate.Time.AddDate(0, 0, -int(convertNullInt64(row.Interval)))
+	}
+	return algorithm.ForgettingCurve(now.Sub(last).Hours()/24, row.Stability.Float64)
+}
+
+// sortQueueCards orders cards in place. Cards without a due date (new cards)
+// fall back to creation order.
+func sortQueueCards(cards []QueueCard, order string, rng *rand.Rand) {
+	byDue := func(a, b QueueCard) bool {
+		if a.DueDate.Valid != b.DueDate.Valid {
+			return a.DueDate.Valid
+		}
+		if a.DueDate.Valid && !a.DueDate.Time.Equal(b.DueDate.Time) {
+			return a.DueDate.Time.Before(b.DueDate.Time)
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+
+	switch order {
+	case QueueOrderRandom:
+		rng.Shuffle(len(cards), func(i, j int) {
+			cards[i], cards[j] = cards[j], cards[i]
+		})
+	case QueueOrderRetrievability:
+		sort.SliceStable(cards, func(i, j int) bool {
+			if cards[i].Retrievability != cards[j].Retrievability {
+				return cards[i].Retrievability < cards[j].Retrievability
+			}
+			return byDue(cards[i], cards[j])
+		})
+	case QueueOrderDeck:
+		sort.SliceStable(cards, func(i, j int) bool {
+			if cards[i].DeckName != cards[j].DeckName {
+				return cards[i].DeckName < cards[j].DeckName
+			}
+			if cards[i].DeckID != cards[j].DeckID {
+				return cards[i].DeckID < cards[j].DeckID
+			}
+			return byDue(cards[i], cards[j])
+		})
+	default:
+		sort.SliceStable(cards, func(i, j int) bool {
+			return byDue(cards[i], cards[j])
+		})
+	}
+}
+
+// takeWithinLimits keeps cards, in order, while both their deck's remaining
+// limit and the user's remaining limit allow.
+func takeWithinLimits(cards []QueueCard, deckLeft map[string]int64, userLeft int64) []QueueCard {
+	left := make(map[string]int64, len(deckLeft))
+	for id, n := range deckLeft {
+		left[id] = n
+	}
+
+	var kept []QueueCard
+	for _, card := range cards {
+		if userLeft <= 0 {
+			break
+		}
+		if left[card.DeckID] <= 0 {
+			continue
+		}
+		left[card.DeckID]--
+		userLeft--
+		kept = append(kept, card)
+	}
+	return kept
+}
+
+// interleave spreads news evenly between reviews.
+func interleave(reviews, news []QueueCard) []QueueCard {
+	out := make([]QueueCard, 0, len(reviews)+len(news))
+	if len(news) == 0 {
+		return append(out, reviews...)
+	}
+	every := (len(reviews) + len(news)) / len(news)
+	r, n := 0, 0
+	for i := 0; r < len(reviews) || n < len(news); i++ {
+		if n < len(news) && (r >= len(reviews) || (i+1)%every == 0) {
+			out = append(out, news[n])
+			n++
+		} else {
+			out = append(out, reviews[r])
+			r++
+		}
+	}
+	return out
+}
+
+// withoutSiblings drops the cards of decks that bury siblings whose note is
+// already queued or has an earlier card in cards.
+func withoutSiblings(cards []QueueCard, queued map[string]bool, bury map[string]bool) []QueueCard {
+	kept := make([]QueueCard, 0, len(cards))
+	seen := make(map[string]bool)
+	for _, card := range cards {
+		if bury[card.DeckID] && (queued[card.NoteID] || seen[card.NoteID]) {
+			continue
+		}
+		seen[card.NoteID] = true
+		kept = append(kept, card)
+	}
+	return kept
+}
+
+// queueSeed keeps the random order stable for a user throughout a day.
+func queueSeed(userID string, day time.Time) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(userID))
+	return int64(h.Sum64()) ^ day.Unix()
+}
+
+func convertQueueToResponse(queue Queue) QueueResponse {
+	cards := make([]QueueCardResponse, 0, len(queue.Cards))
+	for _, card := range queue.Cards {
+		cards = append(cards, QueueCardResponse{
+			CardStateResponse: CardStateResponse{
+				ID:         card.ID,
+				NoteID:     card.NoteID,
+				DueDate:    convertNullTime(card.DueDate),
+				Stability:  convertNullFloat64(card.Stability),
+				Difficulty: convertNullFloat64(card.Difficulty),
+				Interval:   convertNullInt64(card.Interval),
+				Status:     convertNullString(card.Status),
+				Step:       card.Step,
+				Reps:       convertNullInt64(card.Reps),
+				Lapses:     convertNullInt64(card.Lapses),
+			},
+			DeckID:         card.DeckID,
+			DeckName:       card.DeckName,
+			Kind:           card.Kind,
+			Retrievability: card.Retrievability,
+		})
+	}
+	return QueueResponse{
+		New:      queue.New,
+		Learning: queue.Learning,
+		Review:   queue.Review,
+		Cards:    cards,
+	}
+}
@@ -0,0 +1,297 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	algorithm "github.com/threeroundsoftware/voidabyss/algo"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+)
+
+// defaultRescheduleBatchSize is how many cards are replayed per transaction.
+const defaultRescheduleBatchSize = 500
+
+// RescheduleRequest optionally restricts a reschedule to some decks.
+type RescheduleRequest struct {
+	DeckIDs   []string `json:"deck_ids" validate:"max=100,dive,alphanum,len=10"`
+	DryRun    bool     `json:"dry_run"`
+	BatchSize int      `json:"batch_size" validate:"min=0,max=5000"`
+}
+
+// RescheduleChangeResponse is how replaying a card's reviews changed it.
+type RescheduleChangeResponse struct {
+	CardID        string  `json:"card_id"`
+	OldDueDate    string  `json:"old_due_date"`
+	NewDueDate    string  `json:"new_due_date"`
+	DaysMoved     int64   `json:"days_moved"`
+	OldStatus     string  `json:"old_status"`
+	NewStatus     string  `json:"new_status"`
+	OldStability  float64 `json:"old_stability"`
+	NewStability  float64 `json:"new_stability"`
+	OldDifficulty float64 `json:"old_difficulty"`
+	NewDifficulty float64 `json:"new_difficulty"`
+}
+
+// RescheduleResponse summarizes a reschedule; in a dry run nothing was written.
+type RescheduleResponse struct {
+	DryRun  bool                       `json:"dry_run"`
+	Cards   int                        `json:"cards"`
+	Changed int                        `json:"changed"`
+	Changes []RescheduleChangeResponse `json:"changes"`
+	// Skipped lists the cards an event changed after their last review.
+	Skipped []string `json:"skipped"`
+}
+
+// RescheduleOptions control RescheduleCards.
+type RescheduleOptions struct {
+	// DeckIDs limits the reschedule to these decks, all of the user's when empty.
+	DeckIDs   []string
+	DryRun    bool
+	BatchSize int
+}
+
+// CardReschedule is a card before and after replaying its reviews.
+type CardReschedule struct {
+	Old database.Card
+	New database.Card
+}
+
+// RescheduleResult lists the cards a reschedule changed, and those it left
+// alone because a vacation or amnesia event changed them after their last
+// review, which replaying their reviews would undo.
+type RescheduleResult struct {
+	Cards   int
+	Changes []CardReschedule
+	Skipped []string
+}
+
+// FuncRescheduleHandler recomputes the user's cards from their review logs
+// under the current parameters. A dry run only reports the changes.
+func FuncRescheduleHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req RescheduleRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating reschedule request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		result, err := RescheduleCards(c.Request().Context(), app, user.ID, RescheduleOptions{
+			DeckIDs:   req.DeckIDs,
+			DryRun:    req.DryRun,
+			BatchSize: req.BatchSize,
+		})
+		if errors.Is(err, ErrDeckNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Deck not found",
+			})
+		}
+		if err != nil {
+			logging.SlogLogger.Error("Error rescheduling cards", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to reschedule cards",
+			})
+		}
+
+		return c.JSON(http.StatusOK, convertRescheduleToResponse(result, req.DryRun))
+	}
+}
+
+// RescheduleCards replays each card's scheduled reviews in review_time order
+// under the current parameters and rewrites the card's scheduling columns.
+// Cards are replayed in batches, each read and written in one transaction; a
+// dry run rolls every batch back. Load balancing is left out of the replay so
+// that it is reproducible. Cards without reviews are left as they are, as are
+// cards an applied event changed after their last review; reviews after an
+// amnesia event are replayed from the card the event left.
+func RescheduleCards(ctx context.Context, app *app.App, userID string, opts RescheduleOptions) (RescheduleResult, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultRescheduleBatchSize
+	}
+	decks, err := queueDecks(ctx, app.Queries, userID, opts.DeckIDs)
+	if err != nil {
+		return RescheduleResult{}, err
+	}
+
+	var result RescheduleResult
+	for _, deck := range decks {
+		settings, err := ResolveSchedulerSettings(ctx, app.Queries, userID, deck)
+		if err != nil {
+			return RescheduleResult{}, err
+		}
+		cfg := settings.Config(ctx, app.Queries, userID)
+		cfg.LoadBalancer = nil
+
+		cards, err := app.Queries.ListCardsByDeck(ctx, deck.ID)
+		if err != nil {
+			return RescheduleResult{}, fmt.Errorf("failed to list deck cards: %w", err)
+		}
+		for start := 0; start < len(cards); start += opts.BatchSize {
+			batch := cards[start:min(start+opts.BatchSize, len(cards))]
+			changes, skipped, err := rescheduleBatch(ctx, app, batch, cfg, opts.DryRun)
+			if err != nil {
+				return RescheduleResult{}, err
+			}
+			result.Cards += len(batch)
+			result.Changes = append(result.Changes, changes...)
+			result.Skipped = append(result.Skipped, skipped...)
+		}
+	}
+	return result, nil
+}
+
+// rescheduleBatch replays cards in one transaction, committing unless dryRun.
+// It returns the changes made and the cards skipped for an event change.
+func rescheduleBatch(ctx context.Context, app *app.App, cards []database.Card, cfg algorithm.Config, dryRun bool) ([]CardReschedule, []string, error) {
+	tx, err := app.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := app.Queries.WithTx(tx)
+
+	var changes []CardReschedule
+	var skipped []string
+	for _, card := range cards {
+		// re-read inside the transaction so a review in between is not lost
+		card, err := qtx.GetCard(ctx, card.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get card: %w", err)
+		}
+		reviews, err := qtx.ListScheduledReviewsByCard(ctx, card.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list reviews: %w", err)
+		}
+		if len(reviews) == 0 {
+			continue
+		}
+		// the review log knows nothing of vacations and amnesia: a card an
+		// event changed since it was last reviewed would be reverted, and
+		// the reviews after an amnesia event start from the card it left
+		events, err := qtx.ListAppliedUserEventChangesByCard(ctx, card.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list event changes: %w", err)
+		}
+		if len(events) > 0 && !reviews[len(reviews)-1].ReviewTime.After(events[len(events)-1].CreatedAt) {
+			skipped = append(skipped, card.ID)
+			continue
+		}
+
+		update := cardUpdateFromState(card.ID, replayReviews(card, reviews, latestAmnesia(events), cfg))
+		if !cardUpdateChanges(card, update) {
+			continue
+		}
+		updated, err := qtx.UpdateCardScheduling(ctx, update)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to update card: %w", err)
+		}
+		changes = append(changes, CardReschedule{Old: card, New: updated})
+	}
+
+	if dryRun {
+		return changes, skipped, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit reschedule: %w", err)
+	}
+	return changes, skipped, nil
+}
+
+// replayReviews runs a card's reviews through the scheduler as algorithm.Replay
+// does, from the card amnesia left for the reviews after it.
+func replayReviews(card database.Card, reviews []database.Review, amnesia *algorithm.Amnesia, cfg algorithm.Config) algorithm.CardState {
+	logged := make([]algorithm.LoggedReview, 0, len(reviews))
+	for _, review := range reviews {
+		grade, ok := ratingFromID(review.RatingID)
+		if !ok {
+			continue
+		}
+		logged = append(logged, algorithm.LoggedReview{Grade: grade, Time: review.ReviewTime})
+	}
+	return algorithm.Replay(card.ID, logged, amnesia, cfg)
+}
+
+// latestAmnesia is what the latest amnesia event among a card's changes did to
+// it, nil if no amnesia event changed the card. Vacations only move due dates,
+// which the next review replaces.
+func latestAmnesia(changes []database.ListAppliedUserEventChangesByCardRow) *algorithm.Amnesia {
+	for i := len(changes) - 1; i >= 0; i-- {
+		change := changes[i]
+		if convertNullString(change.EventType) != UserEventAmnesia {
+			continue
+		}
+		return &algorithm.Amnesia{
+			At:    change.CreatedAt,
+			Reset: convertNullString(change.AmnesiaMode) == AmnesiaReset,
+			State: algorithm.CardState{
+				Stability:  convertNullFloat64(change.NewStability),
+				Difficulty: convertNullFloat64(change.NewDifficulty),
+				Interval:   convertNullInt64(change.NewInterval),
+				Due:        change.NewDueDate.Time,
+			},
+		}
+	}
+	return nil
+}
+
+// cardUpdateChanges reports whether update would change card's scheduling.
+// Due dates are compared to the second, the precision of older review times.
+func cardUpdateChanges(card database.Card, update database.UpdateCardSchedulingParams) bool {
+	sameFloat := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	return card.DueDate.Valid != update.DueDate.Valid ||
+		(card.DueDate.Valid && !card.DueDate.Time.Truncate(time.Second).Equal(update.DueDate.Time.Truncate(time.Second))) ||
+		!sameFloat(convertNullFloat64(card.Stability), convertNullFloat64(update.Stability)) ||
+		!sameFloat(convertNullFloat64(card.Difficulty), convertNullFloat64(update.Difficulty)) ||
+		!sameFloat(convertNullFloat64(card.Ease), convertNullFloat64(update.Ease)) ||
+		convertNullInt64(card.Interval) != convertNullInt64(update.Interval) ||
+		cardSchedulingStatus(card.Status, card.SuspendedFrom) != convertNullString(update.Status) ||
+		convertNullInt64(card.Reps) != convertNullInt64(update.Reps) ||
+		convertNullInt64(card.Lapses) != convertNullInt64(update.Lapses) ||
+		card.Step != update.Step
+}
+
+func convertRescheduleToResponse(result RescheduleResult, dryRun bool) RescheduleResponse {
+	response := RescheduleResponse{
+		DryRun:  dryRun,
+		Cards:   result.Cards,
+		Changed: len(result.Changes),
+		Changes: make([]RescheduleChangeResponse, 0, len(result.Changes)),
+		Skipped: append([]string{}, result.Skipped...),
+	}
+	for _, change := range result.Changes {
+		var moved int64
+		if change.Old.DueDate.Valid && change.New.DueDate.Valid {
+			moved = int64(algorithm.ElapsedDays(change.Old.DueDate.Time, change.New.DueDate.Time))
+		}
+		response.Changes = append(response.Changes, RescheduleChangeResponse{
+			CardID:        change.New.ID,
+			OldDueDate:    convertNullTime(change.Old.DueDate),
+			NewDueDate:    convertNullTime(change.New.DueDate),
+			DaysMoved:     moved,
+			OldStatus:     convertNullString(change.Old.Status),
+			NewStatus:     convertNullString(change.New.Status),
+			OldStability:  convertNullFloat64(change.Old.Stability),
+			NewStability:  convertNullFloat64(change.New.Stability),
+			OldDifficulty: convertNullFloat64(change.Old.Difficulty),
+			NewDifficulty: convertNullFloat64(change.New.Difficulty),
+		})
+	}
+	return response
+}
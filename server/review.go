@@ -0,0 +1,427 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	algorithm "github.com/threeroundsoftware/voidabyss/algo"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+)
+
+// SubmitReviewRequest answers a card with an FSRS rating (1 again, 2 hard, 3 good, 4 easy).
+// TypedAnswer is the answer typed for a card that asks for one with
+// {{type:Field}}; it is compared with the field and kept on the review, and
+// without a rating the review takes the rating the comparison suggests.
+type SubmitReviewRequest struct {
+	CardID        string  `param:"cardID" validate:"required,alphanum,len=10"`
+	Rating        int64   `json:"rating" validate:"required_without=TypedAnswer,omitempty,min=1,max=4"`
+	ReviewSeconds int64   `json:"review_seconds" validate:"min=0"`
+	TypedAnswer   *string `json:"typed_answer" validate:"omitempty,max=1024"`
+}
+
+// CardStateResponse is a card's scheduling state.
+type CardStateResponse struct {
+	ID         string  `json:"id"`
+	NoteID     string  `json:"note_id"`
+	Ordinal    int64   `json:"ordinal"`
+	DueDate    string  `json:"due_date"`
+	Stability  float64 `json:"stability"`
+	Difficulty float64 `json:"difficulty"`
+	Interval   int64   `json:"interval"`
+	Status     string  `json:"status"`
+	Step       int64   `json:"step"`
+	Reps       int64   `json:"reps"`
+	Lapses     int64   `json:"lapses"`
+	Ease       float64 `json:"ease"`
+	// BuriedUntil is set while the card is buried, by BuryKind.
+	BuriedUntil string `json:"buried_until"`
+	BuryKind    string `json:"bury_kind"`
+	// OrphanedAt is set when the card's template stopped making it for its
+	// note and suspended it.
+	OrphanedAt string `json:"orphaned_at"`
+}
+
+// ReviewResponse reports the stored review and the card's new state.
+type ReviewResponse struct {
+	ReviewID       string  `json:"review_id"`
+	Rating         int64   `json:"rating"`
+	Retrievability float64 `json:"retrievability"`
+	// Leech is set when this review made the card a leech.
+	Leech bool              `json:"leech"`
+	Card  CardStateResponse `json:"card"`
+	// Answer is set when an answer was typed.
+	Answer *TypedAnswerResponse `json:"answer,omitempty"`
+}
+
+// ReviewInput is one answer to a card.
+type ReviewInput struct {
+	Grade         algorithm.Rating
+	ReviewSeconds int64
+	SessionID     sql.NullString
+	// SessionCard is the session card answered, kept on the review so that
+	// undoing it restores the card's progress through the session.
+	SessionCard *database.SessionCard
+	// TypedAnswer is the answer typed for the card, if any. Grade may be 0
+	// when there is one, to take the rating its check suggests.
+	TypedAnswer sql.NullString
+	// Answer is TypedAnswer checked, once it has been.
+	Answer *AnswerCheck
+}
+
+// ReviewResult is the stored review together with the updated card.
+type ReviewResult struct {
+	Review         database.Review
+	Card           database.Card
+	Retrievability float64
+	Leech          bool
+	Answer         *AnswerCheck
+}
+
+// CardStatusSuspended is the status of a card taken out of study. The state
+// it was in is kept in suspended_from.
+const CardStatusSuspended = "suspended"
+
+var (
+	ErrCardNotFound  = errors.New("card not found")
+	ErrCardSuspended = errors.New("card is suspended")
+)
+
+func FuncSubmitReviewHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req SubmitReviewRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating review request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		result, err := SubmitReview(c.Request().Context(), app, user.ID, req.CardID, ReviewInput{
+			Grade:         algorithm.Rating(req.Rating),
+			ReviewSeconds: req.ReviewSeconds,
+			TypedAnswer:   typedAnswer(req.TypedAnswer),
+		})
+		if errors.Is(err, ErrCardNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Card not found",
+			})
+		}
+		if errors.Is(err, ErrCardSuspended) {
+			return c.JSON(http.StatusConflict, ErrorResponse{
+				Error: "Card is suspended",
+			})
+		}
+		if errors.Is(err, ErrNoTypedAnswer) {
+			return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+				Error: "Card has no answer to type",
+			})
+		}
+		if err != nil {
+			logging.SlogLogger.Error("Error submitting review", "user", user.ID, "card", req.CardID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to submit review",
+			})
+		}
+
+		return c.JSON(http.StatusOK, convertReviewResultToResponse(result))
+	}
+}
+
+// SubmitReview schedules the user's answer to a card, then stores the review
+// and the card's new state in a single transaction.
+func SubmitReview(ctx context.Context, app *app.App, userID, cardID string, input ReviewInput) (ReviewResult, error) {
+	return submitReview(ctx, app, userID, cardID, input, nil)
+}
+
+// submitReview is SubmitReview with a hook that runs inside the review's
+// transaction, after the card is updated, for callers that keep their own
+// state in step with the review.
+func submitReview(
+	ctx context.Context,
+	app *app.App,
+	userID, cardID string,
+	input ReviewInput,
+	afterReview func(qtx *database.Queries, result ReviewResult) error,
+) (ReviewResult, error) {
+	card, note, err := getOwnedCard(ctx, app.Queries, userID, cardID)
+	if err != nil {
+		return ReviewResult{}, err
+	}
+	if convertNullString(card.Status) == CardStatusSuspended {
+		return ReviewResult{}, ErrCardSuspended
+	}
+	if err := checkReviewAnswer(ctx, app.Queries, card, note, &input); err != nil {
+		return ReviewResult{}, err
+	}
+	deck, err := app.Queries.GetDeck(ctx, note.DeckID)
+	if err != nil {
+		return ReviewResult{}, fmt.Errorf("failed to get deck: %w", err)
+	}
+	settings, err := ResolveSchedulerSettings(ctx, app.Queries, userID, deck)
+	if err != nil {
+		return ReviewResult{}, err
+	}
+
+	tx, err := app.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return ReviewResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := app.Queries.WithTx(tx)
+
+	// the card is read again inside the transaction, so that an answer given
+	// at the same time is neither overwritten nor recorded as the prior state
+	card, err = qtx.GetCard(ctx, card.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ReviewResult{}, ErrCardNotFound
+	}
+	if err != nil {
+		return ReviewResult{}, fmt.Errorf("failed to get card: %w", err)
+	}
+	if convertNullString(card.Status) == CardStatusSuspended {
+		return ReviewResult{}, ErrCardSuspended
+	}
+
+	var last *database.Review
+	latest, err := qtx.GetLatestReviewByCard(ctx, card.ID)
+	if err == nil {
+		last = &latest
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return ReviewResult{}, fmt.Errorf("failed to get last review: %w", err)
+	}
+
+	cfg := settings.Config(ctx, qtx, userID)
+	// the review is stored at the instant it was scheduled from so that a
+	// reschedule replaying the log reproduces the same due dates
+	now := time.Now().UTC()
+	update, R := scheduleReview(card, last, cfg, input.Grade, now)
+	lapse := convertNullInt64(update.Lapses) > convertNullInt64(card.Lapses)
+
+	review, err := qtx.CreateReview(ctx, withPriorState(database.CreateReviewParams{
+		CardID:        card.ID,
+		ReviewTime:    now,
+		RatingID:      sql.NullString{String: strconv.Itoa(int(input.Grade)), Valid: true},
+		ReviewSeconds: sql.NullInt64{Int64: input.ReviewSeconds, Valid: true},
+		NewInterval:   update.Interval,
+		NewStability:  update.Stability,
+		NewDifficulty: update.Difficulty,
+		NewDueDate:    update.DueDate,
+		SessionID:     input.SessionID,
+		Scheduled:     true,
+		Lapse:         lapse,
+		TypedAnswer:   input.TypedAnswer,
+	}, &card, input.SessionCard))
+	if err != nil {
+		return ReviewResult{}, fmt.Errorf("failed to create review: %w", err)
+	}
+
+	card, err = qtx.UpdateCardScheduling(ctx, update)
+	if err != nil {
+		return ReviewResult{}, fmt.Errorf("failed to update card: %w", err)
+	}
+
+	if settings.BurySiblings {
+		if err := burySiblings(ctx, qtx, card, settings.Day, now); err != nil {
+			return ReviewResult{}, err
+		}
+	}
+
+	result := ReviewResult{Review: review, Card: card, Retrievability: R, Answer: input.Answer}
+	if lapse {
+		result.Card, result.Leech, err = handleLeech(ctx, qtx, userID, note, card, settings, now)
+		if err != nil {
+			return ReviewResult{}, err
+		}
+	}
+	if afterReview != nil {
+		if err := afterReview(qtx, result); err != nil {
+			return ReviewResult{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ReviewResult{}, fmt.Errorf("failed to commit review: %w", err)
+	}
+	return result, nil
+}
+
+// withPriorState records on a new review the state it replaces, so that it
+// can be undone: card's scheduling, unless the review leaves the card as it
+// is, and the session card's progress when it is answered in a session.
+func withPriorState(params database.CreateReviewParams, card *database.Card, sessionCard *database.SessionCard) database.CreateReviewParams {
+	if card != nil {
+		params.PriorStatus = sql.NullString{String: cmp.Or(convertNullString(card.Status), string(algorithm.StateNew)), Valid: true}
+		params.PriorDueDate = card.DueDate
+		params.PriorStability = card.Stability
+		params.PriorDifficulty = card.Difficulty
+		params.PriorInterval = card.Interval
+		params.PriorReps = card.Reps
+		params.PriorLapses = card.Lapses
+		params.PriorStep = sql.NullInt64{Int64: card.Step, Valid: true}
+		params.PriorEase = card.Ease
+		params.PriorLeechedAt = card.LeechedAt
+	}
+	if sessionCard != nil {
+		params.PriorSessionCardStatus = sessionCard.Status
+		params.PriorCramStep = sql.NullInt64{Int64: sessionCard.CramStep, Valid: true}
+		params.PriorNextCramDue = sessionCard.NextCramDue
+	}
+	return params
+}
+
+// getOwnedCard loads a card and its note, returning ErrCardNotFound unless the note belongs to userID.
+func getOwnedCard(ctx context.Context, q *database.Queries, userID, cardID string) (database.Card, database.Note, error) {
+	card, err := q.GetCard(ctx, cardID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Card{}, database.Note{}, ErrCardNotFound
+	}
+	if err != nil {
+		return database.Card{}, database.Note{}, fmt.Errorf("failed to get card: %w", err)
+	}
+	note, err := q.GetNote(ctx, card.NoteID)
+	if err != nil {
+		return database.Card{}, database.Note{}, fmt.Errorf("failed to get note: %w", err)
+	}
+	if note.OwnerID != userID {
+		return database.Card{}, database.Note{}, ErrCardNotFound
+	}
+	return card, note, nil
+}
+
+// scheduleReview computes a card's state after being answered with grade at
+// now. last is the card's previous review, or nil if it has never been
+// reviewed. It returns the card update and the card's retrievability at the
+// time of the review.
+func scheduleReview(
+	card database.Card,
+	last *database.Review,
+	cfg algorithm.Config,
+	grade algorithm.Rating,
+	now time.Time,
+) (database.UpdateCardSchedulingParams, float64) {
+	state := cardStateFromCard(card)
+	if last != nil {
+		state.LastReview = last.ReviewTime
+	}
+	next, R := algorithm.NewScheduler(cfg).Next(state, grade, now)
+	return cardUpdateFromState(card.ID, next), R
+}
+
+// cardStateFromCard reads a card row into the scheduler's card state.
+func cardStateFromCard(card database.Card) algorithm.CardState {
+	state := algorithm.CardState{
+		State:      algorithm.State(cardSchedulingStatus(card.Status, card.SuspendedFrom)),
+		Step:       int(card.Step),
+		Stability:  convertNullFloat64(card.Stability),
+		Difficulty: convertNullFloat64(card.Difficulty),
+		Interval:   convertNullInt64(card.Interval),
+		Reps:       convertNullInt64(card.Reps),
+		Lapses:     convertNullInt64(card.Lapses),
+		Ease:       convertNullFloat64(card.Ease),
+		Seed:       algorithm.FuzzSeed(card.ID, convertNullInt64(card.Reps)),
+	}
+	if card.DueDate.Valid {
+		state.Due = card.DueDate.Time
+	}
+	if state.State == "" {
+		state.State = algorithm.StateNew
+	}
+	return state
+}
+
+// cardSchedulingStatus is the state a card is scheduled from: its status, or
+// for a suspended card the state it was suspended in.
+func cardSchedulingStatus(status, suspendedFrom sql.NullString) string {
+	if convertNullString(status) == CardStatusSuspended {
+		return convertNullString(suspendedFrom)
+	}
+	return convertNullString(status)
+}
+
+// cardUpdateFromState writes a scheduler card state back as a card update.
+func cardUpdateFromState(cardID string, state algorithm.CardState) database.UpdateCardSchedulingParams {
+	update := database.UpdateCardSchedulingParams{
+		DueDate:    sql.NullTime{Time: state.Due, Valid: true},
+		Stability:  sql.NullFloat64{Float64: state.Stability, Valid: true},
+		Difficulty: sql.NullFloat64{Float64: state.Difficulty, Valid: true},
+		Interval:   sql.NullInt64{Int64: state.Interval, Valid: true},
+		Status:     sql.NullString{String: string(state.State), Valid: true},
+		Reps:       sql.NullInt64{Int64: state.Reps, Valid: true},
+		Lapses:     sql.NullInt64{Int64: state.Lapses, Valid: true},
+		Step:       int64(state.Step),
+		Ease:       sql.NullFloat64{Float64: state.Ease, Valid: state.Ease > 0},
+		ID:         cardID,
+	}
+	if state.State == algorithm.StateNew {
+		// new cards have no due date yet
+		update.DueDate = sql.NullTime{}
+	}
+	if state.State == algorithm.StateNew || state.Stability <= 0 {
+		// no memory state yet, or ever under SM-2
+		update.Stability = sql.NullFloat64{}
+		update.Difficulty = sql.NullFloat64{}
+	}
+	return update
+}
+
+func convertCardToStateResponse(card database.Card) CardStateResponse {
+	return CardStateResponse{
+		ID:          card.ID,
+		NoteID:      card.NoteID,
+		Ordinal:     card.Ordinal,
+		DueDate:     convertNullTime(card.DueDate),
+		Stability:   convertNullFloat64(card.Stability),
+		Difficulty:  convertNullFloat64(card.Difficulty),
+		Interval:    convertNullInt64(card.Interval),
+		Status:      convertNullString(card.Status),
+		Step:        card.Step,
+		Reps:        convertNullInt64(card.Reps),
+		Lapses:      convertNullInt64(card.Lapses),
+		Ease:        convertNullFloat64(card.Ease),
+		BuriedUntil: convertNullTime(card.BuriedUntil),
+		BuryKind:    convertNullString(card.BuryKind),
+		OrphanedAt:  convertNullTime(card.OrphanedAt),
+	}
+}
+
+// typedAnswer is a request's typed answer as it is stored.
+func typedAnswer(typed *string) sql.NullString {
+	if typed == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *typed, Valid: true}
+}
+
+func convertReviewResultToResponse(result ReviewResult) ReviewResponse {
+	rating, _ := ratingFromID(result.Review.RatingID)
+	response := ReviewResponse{
+		ReviewID:       result.Review.ID,
+		Rating:         int64(rating),
+		Retrievability: result.Retrievability,
+		Leech:          result.Leech,
+		Card:           convertCardToStateResponse(result.Card),
+	}
+	if result.Answer != nil {
+		answer := convertAnswerCheckToResponse(*result.Answer)
+		response.Answer = &answer
+	}
+	return response
+}
@@ -0,0 +1,292 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	algorithm "github.com/threeroundsoftware/voidabyss/algo"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+)
+
+// SchedulerSettings are the effective scheduler options for a deck: the
+// user's defaults with the deck preset's overrides applied on top.
+type SchedulerSettings struct {
+	Scheduler        algorithm.SchedulerKind
+	Params           algorithm.FSRSParams
+	SM2              algorithm.SM2Params
+	DesiredRetention float64
+	MaximumInterval  int64
+	NewCardsPerDay   int64
+	ReviewsPerDay    int64
+	LearningSteps    []time.Duration
+	RelearningSteps  []time.Duration
+	IntervalFuzz     bool
+	LoadBalance      bool
+	// LeechThreshold is the number of lapses that makes a card a leech, and
+	// LeechAction what is done to it then.
+	LeechThreshold int64
+	LeechAction    string
+	// BurySiblings holds a note's other new and review cards until the next
+	// day once one of its cards is studied.
+	BurySiblings bool
+	// Day is the user's study day; presets cannot change it.
+	Day algorithm.StudyDay
+}
+
+// ResolveSchedulerSettings returns the settings userID studies deck with.
+func ResolveSchedulerSettings(ctx context.Context, q *database.Queries, userID string, deck database.Deck) (SchedulerSettings, error) {
+	setting, err := q.GetUserSetting(ctx, userID)
+	if err != nil {
+		return SchedulerSettings{}, fmt.Errorf("failed to get user settings: %w", err)
+	}
+	settings, err := userSchedulerSettings(setting)
+	if err != nil {
+		return SchedulerSettings{}, err
+	}
+
+	if !deck.PresetID.Valid {
+		return settings, nil
+	}
+	preset, err := q.GetDeckPreset(ctx, deck.PresetID.String)
+	if err != nil {
+		return SchedulerSettings{}, fmt.Errorf("failed to get deck preset: %w", err)
+	}
+	return settings.withPreset(preset)
+}
+
+// userSchedulerSettings builds settings from the user's defaults.
+func userSchedulerSettings(setting database.UserSetting) (SchedulerSettings, error) {
+	params, err := decodeFSRSWeights(setting.FsrsWeights)
+	if err != nil {
+		return SchedulerSettings{}, err
+	}
+	learning, err := parseSteps(setting.LearningSteps)
+	if err != nil {
+		return SchedulerSettings{}, fmt.Errorf("invalid learning steps: %w", err)
+	}
+	relearning, err := parseSteps(setting.RelearningSteps)
+	if err != nil {
+		return SchedulerSettings{}, fmt.Errorf("invalid relearning steps: %w", err)
+	}
+	day, err := studyDay(setting)
+	if err != nil {
+		return SchedulerSettings{}, err
+	}
+	return SchedulerSettings{
+		Scheduler:        algorithm.SchedulerFSRS,
+		Params:           params,
+		SM2:              algorithm.DefaultSM2Params(),
+		DesiredRetention: setting.DesiredRetention,
+		MaximumInterval:  setting.MaximumInterval,
+		NewCardsPerDay:   setting.DailyNewCardsLimit,
+		ReviewsPerDay:    setting.DailyReviewLimit,
+		LearningSteps:    learning,
+		RelearningSteps:  relearning,
+		IntervalFuzz:     setting.IntervalFuzz,
+		LoadBalance:      setting.LoadBalance,
+		LeechThreshold:   defaultLeechThreshold,
+		LeechAction:      LeechActionTag,
+		BurySiblings:     true,
+		Day:              day,
+	}, nil
+}
+
+// studyDay reads the user's study day from their settings.
+func studyDay(setting database.UserSetting) (algorithm.StudyDay, error) {
+	loc, err := time.LoadLocation(setting.Timezone)
+	if err != nil {
+		return algorithm.StudyDay{}, fmt.Errorf("invalid timezone: %w", err)
+	}
+	return algorithm.StudyDay{Location: loc, StartHour: int(setting.DayStartHour)}, nil
+}
+
+// userStudyDay returns the study day of userID.
+func userStudyDay(ctx context.Context, q *database.Queries, userID string) (algorithm.StudyDay, error) {
+	setting, err := q.GetUserSetting(ctx, userID)
+	if err != nil {
+		return algorithm.StudyDay{}, fmt.Errorf("failed to get user settings: %w", err)
+	}
+	return studyDay(setting)
+}
+
+// withPreset overrides every option the preset sets; NULL columns inherit.
+func (s SchedulerSettings) withPreset(preset database.DeckPreset) (SchedulerSettings, error) {
+	if preset.Scheduler.Valid {
+		s.Scheduler = algorithm.SchedulerKind(preset.Scheduler.String)
+	}
+	if preset.FsrsWeights.Valid {
+		params, err := decodeFSRSWeights(preset.FsrsWeights)
+		if err != nil {
+			return SchedulerSettings{}, err
+		}
+		s.Params = params
+	}
+	if preset.DesiredRetention.Valid {
+		s.DesiredRetention = preset.DesiredRetention.Float64
+	}
+	if preset.MaximumInterval.Valid {
+		s.MaximumInterval = preset.MaximumInterval.Int64
+	}
+	if preset.NewCardsPerDay.Valid {
+		s.NewCardsPerDay = preset.NewCardsPerDay.Int64
+	}
+	if preset.ReviewsPerDay.Valid {
+		s.ReviewsPerDay = preset.ReviewsPerDay.Int64
+	}
+	if preset.LearningSteps.Valid {
+		steps, err := parseSteps(preset.LearningSteps.String)
+		if err != nil {
+			return SchedulerSettings{}, fmt.Errorf("invalid learning steps: %w", err)
+		}
+		s.LearningSteps = steps
+	}
+	if preset.RelearningSteps.Valid {
+		steps, err := parseSteps(preset.RelearningSteps.String)
+		if err != nil {
+			return SchedulerSettings{}, fmt.Errorf("invalid relearning steps: %w", err)
+		}
+		s.RelearningSteps = steps
+	}
+	if preset.StartingEase.Valid {
+		s.SM2.StartingEase = preset.StartingEase.Float64
+	}
+	if preset.EasyBonus.Valid {
+		s.SM2.EasyBonus = preset.EasyBonus.Float64
+	}
+	if preset.IntervalModifier.Valid {
+		s.SM2.IntervalModifier = preset.IntervalModifier.Float64
+	}
+	if preset.GraduatingInterval.Valid {
+		s.SM2.GraduatingInterval = preset.GraduatingInterval.Int64
+	}
+	if preset.EasyInterval.Valid {
+		s.SM2.EasyInterval = preset.EasyInterval.Int64
+	}
+	if preset.LeechThreshold.Valid {
+		s.LeechThreshold = preset.LeechThreshold.Int64
+	}
+	if preset.LeechAction.Valid {
+		s.LeechAction = preset.LeechAction.String
+	}
+	if preset.BurySiblings.Valid {
+		s.BurySiblings = preset.BurySiblings.Bool
+	}
+	return s, nil
+}
+
+// Config returns the state machine options for these settings. When load
+// balancing is on, due counts for userID are read through q.
+func (s SchedulerSettings) Config(ctx context.Context, q *database.Queries, userID string) algorithm.Config {
+	cfg := algorithm.Config{
+		Scheduler:        s.Scheduler,
+		Params:           s.Params,
+		SM2:              s.SM2,
+		DesiredRetention: s.DesiredRetention,
+		MaximumInterval:  s.MaximumInterval,
+		LearningSteps:    s.LearningSteps,
+		RelearningSteps:  s.RelearningSteps,
+		Fuzz:             s.IntervalFuzz,
+		Day:              s.Day,
+	}
+	if s.LoadBalance {
+		cfg.LoadBalancer = dueLoad{ctx: ctx, q: q, userID: userID, day: s.Day}
+	}
+	return cfg
+}
+
+// dueLoad balances reviews against the cards a user already has due, counted
+// by the user's study day.
+type dueLoad struct {
+	ctx    context.Context
+	q      *database.Queries
+	userID string
+	day    algorithm.StudyDay
+}
+
+// DueCounts implements algorithm.LoadBalancer. On error it returns nil, which
+// falls back to plain fuzzing.
+func (l dueLoad) DueCounts(now time.Time, minDays, maxDays int64) []int64 {
+	today := l.day.Start(now)
+	first := l.day.AddDays(today, int(minDays))
+	end := l.day.AddDays(today, int(maxDays)+1)
+	// shift due dates so that SQLite's DATE gives the study day they fall on
+	_, offset := first.In(cmp.Or(l.day.Location, time.UTC)).Zone()
+	shift := fmt.Sprintf("%+d seconds", offset-l.day.StartHour*3600)
+	rows, err := l.q.CountDueCardsByDay(l.ctx, database.CountDueCardsByDayParams{
+		DayShift:  shift,
+		OwnerID:   l.userID,
+		DueDate:   sql.NullTime{Time: first, Valid: true},
+		DueDate_2: sql.NullTime{Time: end, Valid: true},
+	})
+	if err != nil {
+		logging.SlogLogger.Error("Error counting due cards", "user", l.userID, "error", err)
+		return nil
+	}
+
+	counts := make([]int64, maxDays-minDays+1)
+	for _, row := range rows {
+		day, err := time.Parse(time.DateOnly, row.DueDay)
+		if err != nil {
+			continue
+		}
+		i := int64(day.Sub(l.day.Date(first)) / (24 * time.Hour))
+		if i >= 0 && i < int64(len(counts)) {
+			counts[i] = row.DueCount
+		}
+	}
+	return counts
+}
+
+// parseSteps parses space separated step durations such as "1m 10m 1h 2d".
+// An empty string means no steps.
+func parseSteps(steps string) ([]time.Duration, error) {
+	var out []time.Duration
+	for _, step := range strings.Fields(steps) {
+		if len(step) < 2 {
+			return nil, fmt.Errorf("invalid step %q", step)
+		}
+		n, err := strconv.Atoi(step[:len(step)-1])
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid step %q", step)
+		}
+		var unit time.Duration
+		switch step[len(step)-1] {
+		case 's':
+			unit = time.Second
+		case 'm':
+			unit = time.Minute
+		case 'h':
+			unit = time.Hour
+		case 'd':
+			unit = 24 * time.Hour
+		default:
+			return nil, fmt.Errorf("invalid step unit in %q", step)
+		}
+		out = append(out, time.Duration(n)*unit)
+	}
+	return out, nil
+}
+
+// formatSteps is the inverse of parseSteps, using the largest whole unit per step.
+func formatSteps(steps []time.Duration) string {
+	parts := make([]string, 0, len(steps))
+	for _, step := range steps {
+		switch {
+		case step%(24*time.Hour) == 0:
+			parts = append(parts, fmt.Sprintf("%dd", step/(24*time.Hour)))
+		case step%time.Hour == 0:
+			parts = append(parts, fmt.Sprintf("%dh", step/time.Hour))
+		case step%time.Minute == 0:
+			parts = append(parts, fmt.Sprintf("%dm", step/time.Minute))
+		default:
+			parts = append(parts, fmt.Sprintf("%ds", step/time.Second))
+		}
+	}
+	return strings.Join(parts, " ")
+}
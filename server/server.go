@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"embed"
 	"fmt"
 
@@ -78,12 +79,68 @@ func StartServer(appInstance *app.App, staticFiles embed.FS, config *config.Conf
 	api.GET("/decks", FuncGetDecksHandler(appInstance))
 	api.POST("/decks", FuncCreateDeckHandler(appInstance))
 	api.GET("/decks/:deckID/cards", FuncUserCardsByDeck(appInstance))
+	api.GET("/cards/:cardID/render", FuncRenderCardHandler(appInstance))
+	api.POST("/cards/:cardID/review", FuncSubmitReviewHandler(appInstance))
+	api.POST("/cards/:cardID/answer", FuncCheckAnswerHandler(appInstance))
+	api.POST("/cards/:cardID/suspend", FuncSuspendCardHandler(appInstance))
+	api.POST("/cards/:cardID/unsuspend", FuncUnsuspendCardHandler(appInstance))
+	api.POST("/cards/:cardID/bury", FuncBuryCardHandler(appInstance))
+	api.POST("/cards/:cardID/unbury", FuncUnburyCardHandler(appInstance))
+	api.GET("/queue", FuncGetQueueHandler(appInstance))
+	api.GET("/leeches", FuncGetLeechesHandler(appInstance))
+	api.GET("/tags", FuncGetTagsHandler(appInstance))
+	api.GET("/events", FuncGetUserEventsHandler(appInstance))
+	api.POST("/events", FuncCreateUserEventHandler(appInstance))
+	api.GET("/events/:eventID", FuncGetUserEventHandler(appInstance))
+	api.DELETE("/events/:eventID", FuncCancelUserEventHandler(appInstance))
+	api.GET("/notes", FuncGetNotesHandler(appInstance))
+	api.POST("/notes", FuncCreateNoteHandler(appInstance))
+	api.GET("/notes/:noteID", FuncGetNoteHandler(appInstance))
+	api.PATCH("/notes/:noteID", FuncUpdateNoteHandler(appInstance))
+	api.DELETE("/notes/:noteID", FuncDeleteNoteHandler(appInstance))
+	api.PUT("/notes/:noteID/tags", FuncSetNoteTagsHandler(appInstance))
+	api.GET("/sessions", FuncListSessionsHandler(appInstance))
+	api.POST("/sessions", FuncStartSessionHandler(appInstance))
+	api.POST("/sessions/cram", FuncStartCramSessionHandler(appInstance))
+	api.GET("/sessions/:sessionID", FuncGetSessionHandler(appInstance))
+	api.GET("/sessions/:sessionID/next", FuncNextSessionCardHandler(appInstance))
+	api.POST("/sessions/:sessionID/answer", FuncAnswerSessionCardHandler(appInstance))
+	api.POST("/sessions/:sessionID/undo", FuncUndoSessionReviewHandler(appInstance))
+	api.POST("/sessions/:sessionID/pause", FuncPauseSessionHandler(appInstance))
+	api.POST("/sessions/:sessionID/resume", FuncResumeSessionHandler(appInstance))
+	api.POST("/sessions/:sessionID/end", FuncEndSessionHandler(appInstance))
 	api.GET("/resource", FuncUserResources(appInstance))
 	api.GET("/teams", FuncUserTeams(appInstance))
+	api.GET("/notetypes", FuncGetNoteTypesHandler(appInstance))
+	api.GET("/notetypes/:noteTypeID", FuncGetNoteTypeHandler(appInstance))
+	api.PUT("/notetypes/:noteTypeID/fields", FuncSetNoteTypeFieldsHandler(appInstance))
+	api.GET("/functions", FuncGetFunctionsHandler(appInstance))
+	api.POST("/functions", FuncCreateFunctionHandler(appInstance))
+	api.PUT("/functions/:functionID", FuncUpdateFunctionHandler(appInstance))
+	api.DELETE("/functions/:functionID", FuncDeleteFunctionHandler(appInstance))
 	api.GET("/templates", FuncGetTemplatesHandler(appInstance))
 	api.GET("/templates/:templateID", FuncGetTemplateHandler(appInstance))
 	api.POST("/templates", FuncCreateTemplateHandler(appInstance))
+	api.PUT("/templates/:templateID", FuncUpdateTemplateHandler(appInstance))
+	api.DELETE("/templates/:templateID", FuncDeleteTemplateHandler(appInstance))
+	api.POST("/templates/:templateID/preview", FuncPreviewTemplateHandler(appInstance))
+	api.GET("/templates/:templateID/versions", FuncGetTemplateVersionsHandler(appInstance))
+	api.POST("/templates/:templateID/versions/:version/rollback", FuncRollbackTemplateHandler(appInstance))
 	api.POST("/teams", FuncCreateTeam(appInstance))
+	api.POST("/scheduler/optimize", FuncOptimizeParamsHandler(appInstance))
+	api.POST("/scheduler/reschedule", FuncRescheduleHandler(appInstance))
+	api.POST("/scheduler/simulate", FuncSimulateHandler(appInstance))
+	api.GET("/settings/scheduler", FuncGetSchedulerSettingsHandler(appInstance))
+	api.PUT("/settings/scheduler", FuncUpdateSchedulerSettingsHandler(appInstance))
+	api.GET("/presets", FuncGetPresetsHandler(appInstance))
+	api.POST("/presets", FuncCreatePresetHandler(appInstance))
+	api.PUT("/presets/:presetID", FuncUpdatePresetHandler(appInstance))
+	api.DELETE("/presets/:presetID", FuncDeletePresetHandler(appInstance))
+	api.PUT("/decks/:deckID/preset", FuncSetDeckPresetHandler(appInstance))
+	api.GET("/decks/:deckID/settings", FuncGetDeckSettingsHandler(appInstance))
+
+	// apply vacation and amnesia events as they start
+	go RunUserEventJob(context.Background(), appInstance, userEventJobInterval)
 
 	// start app
 	e.Logger.Fatal(e.Start(fmt.Sprintf(":%s", config.Port)))
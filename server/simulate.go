@@ -0,0 +1,283 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	algorithm "github.com/threeroundsoftware/voidabyss/algo"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+)
+
+const (
+	defaultSimulationDays = 30
+	defaultSimulationRuns = 5
+	// defaultReviewSeconds is assumed when the user has no timed reviews yet.
+	defaultReviewSeconds = 10
+	// maxSimulationWork bounds the card-days simulated over every run and
+	// retention, which keeps a simulation to a few seconds.
+	maxSimulationWork = 50_000_000
+)
+
+// ErrSimulationTooLarge is returned for a simulation over maxSimulationWork.
+var ErrSimulationTooLarge = errors.New("simulation too large")
+
+// defaultSimulatedRetentions are compared when the request names none.
+var defaultSimulatedRetentions = []float64{0.7, 0.75, 0.8, 0.85, 0.9, 0.95}
+
+// SimulateRequest asks for the workload of the user's decks, or some of them,
+// over the coming days at each desired retention.
+type SimulateRequest struct {
+	DeckIDs           []string  `json:"deck_ids" validate:"max=100,dive,alphanum,len=10"`
+	Days              int       `json:"days" validate:"min=0,max=365"`
+	Runs              int       `json:"runs" validate:"min=0,max=100"`
+	Seed              *int64    `json:"seed"`
+	DesiredRetentions []float64 `json:"desired_retentions" validate:"max=20,dive,gt=0,lt=1"`
+}
+
+// RetentionSimulationResponse is the expected workload at one desired retention.
+type RetentionSimulationResponse struct {
+	DesiredRetention     float64   `json:"desired_retention"`
+	ReviewsPerDay        []float64 `json:"reviews_per_day"`
+	MinutesPerDay        []float64 `json:"minutes_per_day"`
+	MemorizedPerDay      []float64 `json:"memorized_per_day"`
+	AverageReviewsPerDay float64   `json:"average_reviews_per_day"`
+	AverageMinutesPerDay float64   `json:"average_minutes_per_day"`
+	// Memorized is the expected number of cards recalled after the last day.
+	Memorized float64 `json:"memorized"`
+}
+
+// SimulationResponse reports a simulation; rerunning it with the same seed
+// gives the same results.
+type SimulationResponse struct {
+	Days             int                           `json:"days"`
+	Runs             int                           `json:"runs"`
+	Seed             int64                         `json:"seed"`
+	Cards            int                           `json:"cards"`
+	SecondsPerReview float64                       `json:"seconds_per_review"`
+	Results          []RetentionSimulationResponse `json:"results"`
+}
+
+// WorkloadOptions control SimulateWorkload.
+type WorkloadOptions struct {
+	// DeckIDs limits the simulation to these decks, all of the user's when empty.
+	DeckIDs           []string
+	Days              int
+	Runs              int
+	Seed              int64
+	DesiredRetentions []float64
+}
+
+// WorkloadSimulation is the outcome of SimulateWorkload, with one result per
+// desired retention.
+type WorkloadSimulation struct {
+	Cards            int
+	SecondsPerReview float64
+	Results          []algorithm.SimulationResult
+}
+
+// FuncSimulateHandler simulates the user's study workload at a range of
+// desired retentions.
+func FuncSimulateHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req SimulateRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating simulate request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		opts := WorkloadOptions{
+			DeckIDs:           req.DeckIDs,
+			Days:              req.Days,
+			Runs:              req.Runs,
+			Seed:              time.Now().UnixNano(),
+			DesiredRetentions: req.DesiredRetentions,
+		}
+		if opts.Days == 0 {
+			opts.Days = defaultSimulationDays
+		}
+		if opts.Runs == 0 {
+			opts.Runs = defaultSimulationRuns
+		}
+		if req.Seed != nil {
+			opts.Seed = *req.Seed
+		}
+		if len(opts.DesiredRetentions) == 0 {
+			opts.DesiredRetentions = defaultSimulatedRetentions
+		}
+
+		simulation, err := SimulateWorkload(c.Request().Context(), app.Queries, user.ID, time.Now().UTC(), opts)
+		if errors.Is(err, ErrDeckNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Deck not found",
+			})
+		}
+		if errors.Is(err, ErrSimulationTooLarge) {
+			return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+				Error: "Simulation too large; simulate fewer days, runs, retentions or decks",
+			})
+		}
+		if err != nil {
+			logging.SlogLogger.Error("Error simulating workload", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to run simulation",
+			})
+		}
+
+		return c.JSON(http.StatusOK, convertSimulationToResponse(simulation, opts))
+	}
+}
+
+// SimulateWorkload simulates studying the user's cards from now under each
+// deck's scheduler settings, once for every desired retention in opts, which
+// replaces the decks' own. Every retention is simulated with the same seed so
+// that the results differ only by the retention. Study time is estimated from
+// the user's average review time. Simulations of more than maxSimulationWork
+// card-days are refused with ErrSimulationTooLarge.
+func SimulateWorkload(ctx context.Context, q *database.Queries, userID string, now time.Time, opts WorkloadOptions) (WorkloadSimulation, error) {
+	decks, err := queueDecks(ctx, q, userID, opts.DeckIDs)
+	if err != nil {
+		return WorkloadSimulation{}, err
+	}
+
+	seconds, err := q.AverageReviewSecondsByOwner(ctx, userID)
+	if err != nil {
+		return WorkloadSimulation{}, fmt.Errorf("failed to get average review time: %w", err)
+	}
+	if seconds <= 0 {
+		seconds = defaultReviewSeconds
+	}
+
+	day, err := userStudyDay(ctx, q, userID)
+	if err != nil {
+		return WorkloadSimulation{}, err
+	}
+
+	simulation := WorkloadSimulation{SecondsPerReview: seconds}
+	simDecks := make([]algorithm.SimulationDeck, 0, len(decks))
+	for _, deck := range decks {
+		settings, err := ResolveSchedulerSettings(ctx, q, userID, deck)
+		if err != nil {
+			return WorkloadSimulation{}, err
+		}
+		cfg := settings.Config(ctx, q, userID)
+		cfg.LoadBalancer = nil
+
+		cards, err := q.ListCardsByDeck(ctx, deck.ID)
+		if err != nil {
+			return WorkloadSimulation{}, fmt.Errorf("failed to list deck cards: %w", err)
+		}
+		sortSimulationCards(cards)
+		states := make([]algorithm.CardState, 0, len(cards))
+		for _, card := range cards {
+			if convertNullString(card.Status) == CardStatusSuspended {
+				continue
+			}
+			states = append(states, cardStateFromCard(card))
+		}
+		simulation.Cards += len(states)
+
+		simDecks = append(simDecks, algorithm.SimulationDeck{
+			Config:         cfg,
+			Cards:          states,
+			NewCardsPerDay: settings.NewCardsPerDay,
+			ReviewsPerDay:  settings.ReviewsPerDay,
+		})
+	}
+
+	work := max(opts.Runs, 1) * opts.Days * len(opts.DesiredRetentions) * simulation.Cards
+	if work > maxSimulationWork {
+		return WorkloadSimulation{}, fmt.Errorf("%w: %d card-days", ErrSimulationTooLarge, work)
+	}
+
+	for _, retention := range opts.DesiredRetentions {
+		for i := range simDecks {
+			simDecks[i].Config.DesiredRetention = retention
+		}
+		result, err := algorithm.Simulate(ctx, simDecks, now, algorithm.SimulationOptions{
+			Days:             opts.Days,
+			Runs:             opts.Runs,
+			Seed:             opts.Seed,
+			SecondsPerReview: seconds,
+			Day:              day,
+		})
+		if err != nil {
+			return WorkloadSimulation{}, fmt.Errorf("failed to simulate: %w", err)
+		}
+		simulation.Results = append(simulation.Results, result)
+	}
+	return simulation, nil
+}
+
+// sortSimulationCards orders cards as they are studied: due cards by due
+// date, then new cards in creation order.
+func sortSimulationCards(cards []database.Card) {
+	slices.SortStableFunc(cards, func(a, b database.Card) int {
+		switch {
+		case a.DueDate.Valid != b.DueDate.Valid:
+			if a.DueDate.Valid {
+				return -1
+			}
+			return 1
+		case a.DueDate.Valid:
+			return a.DueDate.Time.Compare(b.DueDate.Time)
+		default:
+			return a.CreatedAt.Compare(b.CreatedAt)
+		}
+	})
+}
+
+func convertSimulationToResponse(simulation WorkloadSimulation, opts WorkloadOptions) SimulationResponse {
+	average := func(days []float64) float64 {
+		if len(days) == 0 {
+			return 0
+		}
+		var sum float64
+		for _, n := range days {
+			sum += n
+		}
+		return sum / float64(len(days))
+	}
+
+	response := SimulationResponse{
+		Days:             opts.Days,
+		Runs:             opts.Runs,
+		Seed:             opts.Seed,
+		Cards:            simulation.Cards,
+		SecondsPerReview: simulation.SecondsPerReview,
+		Results:          make([]RetentionSimulationResponse, 0, len(simulation.Results)),
+	}
+	for i, result := range simulation.Results {
+		var memorized float64
+		if n := len(result.MemorizedPerDay); n > 0 {
+			memorized = result.MemorizedPerDay[n-1]
+		}
+		response.Results = append(response.Results, RetentionSimulationResponse{
+			DesiredRetention:     opts.DesiredRetentions[i],
+			ReviewsPerDay:        result.ReviewsPerDay,
+			MinutesPerDay:        result.MinutesPerDay,
+			MemorizedPerDay:      result.MemorizedPerDay,
+			AverageReviewsPerDay: average(result.ReviewsPerDay),
+			AverageMinutesPerDay: average(result.MinutesPerDay),
+			Memorized:            memorized,
+		})
+	}
+	return response
+}
@@ -0,0 +1,800 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	algorithm "github.com/threeroundsoftware/voidabyss/algo"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+)
+
+// Study session modes and session card statuses.
+const (
+	SessionModeNormal = "normal"
+	SessionModeCram   = "cram"
+
+	SessionCardPending    = "pending"
+	SessionCardInProgress = "in-progress"
+	SessionCardDone       = "done"
+)
+
+var (
+	ErrSessionNotFound  = errors.New("session not found")
+	ErrSessionEnded     = errors.New("session has ended")
+	ErrSessionPaused    = errors.New("session is paused")
+	ErrSessionNotPaused = errors.New("session is not paused")
+	ErrCardNotInSession = errors.New("card is not pending in session")
+	ErrNothingToUndo    = errors.New("no review to undo")
+	ErrCannotUndo       = errors.New("review cannot be undone")
+)
+
+// StartSessionRequest starts a study session over one or more decks.
+type StartSessionRequest struct {
+	Name    string   `json:"name" validate:"max=128"`
+	DeckIDs []string `json:"deck_ids" validate:"required,min=1,dive,alphanum,len=10"`
+	Order   string   `json:"order" validate:"omitempty,oneof=due retrievability random deck"`
+	Limit   int      `json:"limit" validate:"min=0,max=1000"`
+}
+
+// SessionDetailRequest defines the structure for route parameters with validation
+type SessionDetailRequest struct {
+	ID string `param:"sessionID" validate:"required,alphanum,len=10"`
+}
+
+// AnswerSessionCardRequest answers a card of a session, with a rating, a
+// typed answer or both, as SubmitReviewRequest does.
+type AnswerSessionCardRequest struct {
+	ID            string  `param:"sessionID" validate:"required,alphanum,len=10"`
+	CardID        string  `json:"card_id" validate:"required,alphanum,len=10"`
+	Rating        int64   `json:"rating" validate:"required_without=TypedAnswer,omitempty,min=1,max=4"`
+	ReviewSeconds int64   `json:"review_seconds" validate:"min=0"`
+	TypedAnswer   *string `json:"typed_answer" validate:"omitempty,max=1024"`
+}
+
+// SessionSummaryResponse totals the reviews of a session.
+type SessionSummaryResponse struct {
+	Reviews       int64   `json:"reviews"`
+	Again         int64   `json:"again"`
+	Hard          int64   `json:"hard"`
+	Good          int64   `json:"good"`
+	Easy          int64   `json:"easy"`
+	ReviewSeconds int64   `json:"review_seconds"`
+	ActiveSeconds int64   `json:"active_seconds"`
+	Accuracy      float64 `json:"accuracy"`
+	Remaining     int     `json:"remaining"`
+}
+
+// SessionResponse represents a study session.
+type SessionResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Mode string `json:"mode"`
+	// Reschedule reports whether a cram session updates card scheduling.
+	Reschedule bool                   `json:"reschedule"`
+	DeckIDs    []string               `json:"deck_ids"`
+	StartTime  time.Time              `json:"start_time"`
+	EndTime    string                 `json:"end_time"`
+	IsActive   bool                   `json:"is_active"`
+	Paused     bool                   `json:"paused"`
+	Summary    SessionSummaryResponse `json:"summary"`
+}
+
+// SessionsListResponse encapsulates a list of SessionResponse.
+type SessionsListResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
+
+// NextSessionCardResponse is the next card to study. Card is null when no
+// card is due yet (NextDueAt says when one will be) or the session is finished.
+type NextSessionCardResponse struct {
+	Card      *CardStateResponse `json:"card"`
+	Remaining int                `json:"remaining"`
+	NextDueAt string             `json:"next_due_at"`
+	Finished  bool               `json:"finished"`
+}
+
+// SessionOptions describe a session to start.
+type SessionOptions struct {
+	Mode       string
+	Name       string
+	Reschedule bool
+}
+
+// SessionSummary totals the reviews of a session.
+type SessionSummary struct {
+	Counts        map[algorithm.Rating]int64
+	Reviews       int64
+	ReviewSeconds int64
+	ActiveSeconds int64
+	Remaining     int
+}
+
+// SessionNext is the outcome of asking a session for its next card.
+type SessionNext struct {
+	Card      *database.Card
+	Remaining int
+	NextDueAt sql.NullTime
+}
+
+// FuncStartSessionHandler starts a study session over the requested decks,
+// filling it with the decks' current study queue.
+func FuncStartSessionHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req StartSessionRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating start session request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		ctx := c.Request().Context()
+		now := time.Now().UTC()
+		queue, err := BuildQueue(ctx, app.Queries, user.ID, QueueOptions{
+			DeckIDs: req.DeckIDs,
+			Order:   req.Order,
+			Limit:   req.Limit,
+		}, now)
+		if errors.Is(err, ErrDeckNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Deck not found",
+			})
+		}
+		if err != nil {
+			logging.SlogLogger.Error("Error building session queue", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to start session",
+			})
+		}
+
+		cardIDs := make([]string, 0, len(queue.Cards))
+		for _, card := range queue.Cards {
+			cardIDs = append(cardIDs, card.ID)
+		}
+		session, err := StartStudySession(ctx, app, user.ID, SessionOptions{
+			Mode: SessionModeNormal,
+			Name: req.Name,
+		}, uniqueStringSlice(req.DeckIDs), cardIDs)
+		if err != nil {
+			logging.SlogLogger.Error("Error starting session", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to start session",
+			})
+		}
+
+		return respondWithSession(c, app.Queries, http.StatusCreated, session, now)
+	}
+}
+
+// FuncListSessionsHandler lists the user's study sessions, newest first.
+func FuncListSessionsHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		sessions, err := app.Queries.ListSessionsByUser(c.Request().Context(), user.ID)
+		if err != nil {
+			logging.SlogLogger.Error("Error listing sessions", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to retrieve sessions",
+			})
+		}
+
+		now := time.Now().UTC()
+		response := SessionsListResponse{Sessions: make([]SessionResponse, 0, len(sessions))}
+		for _, session := range sessions {
+			sessionResponse, err := buildSessionResponse(c.Request().Context(), app.Queries, session, now)
+			if err != nil {
+				logging.SlogLogger.Error("Error building session response", "session", session.ID, "error", err)
+				return c.JSON(http.StatusInternalServerError, ErrorResponse{
+					Error: "Failed to retrieve sessions",
+				})
+			}
+			response.Sessions = append(response.Sessions, sessionResponse)
+		}
+		return c.JSON(http.StatusOK, response)
+	}
+}
+
+// FuncGetSessionHandler returns a session with its summary.
+func FuncGetSessionHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req SessionDetailRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating session request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		session, err := getOwnedSession(c.Request().Context(), app.Queries, user.ID, req.ID)
+		if err != nil {
+			return respondSessionError(c, err, "retrieve session")
+		}
+		return respondWithSession(c, app.Queries, http.StatusOK, session, time.Now().UTC())
+	}
+}
+
+// FuncNextSessionCardHandler returns the next card to study in a session.
+func FuncNextSessionCardHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req SessionDetailRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating session request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		next, err := NextSessionCard(c.Request().Context(), app.Queries, user.ID, req.ID, time.Now().UTC())
+		if err != nil {
+			return respondSessionError(c, err, "get next card")
+		}
+
+		response := NextSessionCardResponse{
+			Remaining: next.Remaining,
+			NextDueAt: convertNullTime(next.NextDueAt),
+			Finished:  next.Remaining == 0,
+		}
+		if next.Card != nil {
+			card := convertCardToStateResponse(*next.Card)
+			response.Card = &card
+		}
+		return c.JSON(http.StatusOK, response)
+	}
+}
+
+// FuncAnswerSessionCardHandler records the answer to a card of a session.
+func FuncAnswerSessionCardHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req AnswerSessionCardRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating answer request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		result, err := AnswerSessionCard(c.Request().Context(), app, user.ID, req.ID, req.CardID, ReviewInput{
+			Grade:         algorithm.Rating(req.Rating),
+			ReviewSeconds: req.ReviewSeconds,
+			TypedAnswer:   typedAnswer(req.TypedAnswer),
+		})
+		if err != nil {
+			return respondSessionError(c, err, "answer card")
+		}
+		return c.JSON(http.StatusOK, convertReviewResultToResponse(result))
+	}
+}
+
+// FuncPauseSessionHandler pauses a session; paused time does not count as time spent.
+func FuncPauseSessionHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req SessionDetailRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating session request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		ctx := c.Request().Context()
+		now := time.Now().UTC()
+		session, err := PauseStudySession(ctx, app.Queries, user.ID, req.ID, now)
+		if err != nil {
+			return respondSessionError(c, err, "pause session")
+		}
+		return respondWithSession(c, app.Queries, http.StatusOK, session, now)
+	}
+}
+
+// FuncResumeSessionHandler resumes a paused session.
+func FuncResumeSessionHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req SessionDetailRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating session request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		ctx := c.Request().Context()
+		now := time.Now().UTC()
+		session, err := ResumeStudySession(ctx, app.Queries, user.ID, req.ID, now)
+		if err != nil {
+			return respondSessionError(c, err, "resume session")
+		}
+		return respondWithSession(c, app.Queries, http.StatusOK, session, now)
+	}
+}
+
+// FuncEndSessionHandler ends a session and returns its summary.
+func FuncEndSessionHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req SessionDetailRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating session request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		ctx := c.Request().Context()
+		now := time.Now().UTC()
+		session, err := EndStudySession(ctx, app.Queries, user.ID, req.ID, now)
+		if err != nil {
+			return respondSessionError(c, err, "end session")
+		}
+		return respondWithSession(c, app.Queries, http.StatusOK, session, now)
+	}
+}
+
+// StartStudySession creates a session over deckIDs holding cardIDs, in order, in one transaction.
+func StartStudySession(ctx context.Context, app *app.App, userID string, opts SessionOptions, deckIDs, cardIDs []string) (database.Session, error) {
+	tx, err := app.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Session{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := app.Queries.WithTx(tx)
+
+	session, err := qtx.CreateSession(ctx, database.CreateSessionParams{
+		UserID:     userID,
+		Mode:       sql.NullString{String: opts.Mode, Valid: true},
+		Name:       sql.NullString{String: opts.Name, Valid: opts.Name != ""},
+		Reschedule: opts.Reschedule,
+	})
+	if err != nil {
+		return database.Session{}, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	for _, deckID := range deckIDs {
+		_, err := qtx.AddDeckToSession(ctx, database.AddDeckToSessionParams{
+			SessionID: session.ID,
+			DeckID:    deckID,
+		})
+		if err != nil {
+			return database.Session{}, fmt.Errorf("failed to add deck to session: %w", err)
+		}
+	}
+
+	for i, cardID := range cardIDs {
+		_, err := qtx.AddCardToSession(ctx, database.AddCardToSessionParams{
+			SessionID: session.ID,
+			CardID:    cardID,
+			Status:    sql.NullString{String: SessionCardPending, Valid: true},
+			Position:  int64(i),
+		})
+		if err != nil {
+			return database.Session{}, fmt.Errorf("failed to add card to session: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.Session{}, fmt.Errorf("failed to commit session: %w", err)
+	}
+	return session, nil
+}
+
+// NextSessionCard picks the session's next card and marks it in progress.
+// Waiting cards that are due come first, then the remaining cards in session
+// order, then waiting cards due within the learn-ahead limit. Waiting cards are
+// (re)learning cards, or in cram sessions cards repeating a cram step.
+func NextSessionCard(ctx context.Context, q *database.Queries, userID, sessionID string, now time.Time) (SessionNext, error) {
+	session, err := getActiveSession(ctx, q, userID, sessionID)
+	if err != nil {
+		return SessionNext{}, err
+	}
+
+	rows, err := q.ListSessionQueue(ctx, database.ListSessionQueueParams{
+		SessionID: session.ID,
+		Now:       sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		return SessionNext{}, fmt.Errorf("failed to list session cards: %w", err)
+	}
+
+	next := SessionNext{Remaining: len(rows)}
+	var dueWaiting, ordered, aheadWaiting *database.ListSessionQueueRow
+	for i := range rows {
+		row := &rows[i]
+		due := sessionCardDue(session, *row)
+		switch {
+		case !due.Valid:
+			if ordered == nil {
+				ordered = row
+			}
+		case !due.Time.After(now):
+			if dueWaiting == nil || due.Time.Before(sessionCardDue(session, *dueWaiting).Time) {
+				dueWaiting = row
+			}
+		case !due.Time.After(now.Add(learnAheadLimit)):
+			if aheadWaiting == nil || due.Time.Before(sessionCardDue(session, *aheadWaiting).Time) {
+				aheadWaiting = row
+			}
+		default:
+			if !next.NextDueAt.Valid || due.Time.Before(next.NextDueAt.Time) {
+				next.NextDueAt = due
+			}
+		}
+	}
+
+	pick := dueWaiting
+	if pick == nil {
+		pick = ordered
+	}
+	if pick == nil {
+		pick = aheadWaiting
+	}
+	if pick == nil {
+		return next, nil
+	}
+
+	_, err = q.UpdateSessionCard(ctx, database.UpdateSessionCardParams{
+		Status:      sql.NullString{String: SessionCardInProgress, Valid: true},
+		NextCramDue: pick.NextCramDue,
+		CramStep:    pick.CramStep,
+		SessionID:   session.ID,
+		CardID:      pick.CardID,
+	})
+	if err != nil {
+		return SessionNext{}, fmt.Errorf("failed to update session card: %w", err)
+	}
+
+	card, err := q.GetCard(ctx, pick.CardID)
+	if err != nil {
+		return SessionNext{}, fmt.Errorf("failed to get card: %w", err)
+	}
+	next.Card = &card
+	next.NextDueAt = sql.NullTime{}
+	return next, nil
+}
+
+// AnswerSessionCard reviews a card of an active session. The review is linked
+// to the session, and the card stays in the session until it leaves
+// (re)learning, or in cram sessions until it passes the cram steps.
+func AnswerSessionCard(ctx context.Context, app *app.App, userID, sessionID, cardID string, input ReviewInput) (ReviewResult, error) {
+	session, err := getActiveSession(ctx, app.Queries, userID, sessionID)
+	if err != nil {
+		return ReviewResult{}, err
+	}
+	sessionCard, err := app.Queries.GetSessionCard(ctx, database.GetSessionCardParams{
+		SessionID: session.ID,
+		CardID:    cardID,
+	})
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && convertNullString(sessionCard.Status) == SessionCardDone) {
+		return ReviewResult{}, ErrCardNotInSession
+	}
+	if err != nil {
+		return ReviewResult{}, fmt.Errorf("failed to get session card: %w", err)
+	}
+
+	input.SessionID = sql.NullString{String: session.ID, Valid: true}
+	input.SessionCard = &sessionCard
+	if convertNullString(session.Mode) == SessionModeCram {
+		return answerCramCard(ctx, app, userID, session, sessionCard, input, time.Now().UTC())
+	}
+	return submitReview(ctx, app, userID, cardID, input, func(qtx *database.Queries, result ReviewResult) error {
+		status := SessionCardPending
+		// a card suspended as a leech has left the session too
+		if cardStatus := convertNullString(result.Card.Status); cardStatus == string(algorithm.StateReview) || cardStatus == CardStatusSuspended {
+			status = SessionCardDone
+		}
+		_, err := qtx.UpdateSessionCard(ctx, database.UpdateSessionCardParams{
+			Status:      sql.NullString{String: status, Valid: true},
+			NextCramDue: sessionCard.NextCramDue,
+			CramStep:    sessionCard.CramStep,
+			SessionID:   session.ID,
+			CardID:      cardID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update session card: %w", err)
+		}
+		return nil
+	})
+}
+
+// PauseStudySession pauses an active session at now.
+func PauseStudySession(ctx context.Context, q *database.Queries, userID, sessionID string, now time.Time) (database.Session, error) {
+	session, err := getActiveSession(ctx, q, userID, sessionID)
+	if err != nil {
+		return database.Session{}, err
+	}
+	session, err = q.PauseSession(ctx, database.PauseSessionParams{
+		PausedAt: sql.NullTime{Time: now, Valid: true},
+		ID:       session.ID,
+	})
+	if err != nil {
+		return database.Session{}, fmt.Errorf("failed to pause session: %w", err)
+	}
+	return session, nil
+}
+
+// ResumeStudySession resumes a paused session, adding the pause to its paused time.
+func ResumeStudySession(ctx context.Context, q *database.Queries, userID, sessionID string, now time.Time) (database.Session, error) {
+	session, err := getOwnedSession(ctx, q, userID, sessionID)
+	if err != nil {
+		return database.Session{}, err
+	}
+	if !session.IsActive {
+		return database.Session{}, ErrSessionEnded
+	}
+	if !session.PausedAt.Valid {
+		return database.Session{}, ErrSessionNotPaused
+	}
+	session, err = q.ResumeSession(ctx, database.ResumeSessionParams{
+		PausedSeconds: pausedSeconds(session, now),
+		ID:            session.ID,
+	})
+	if err != nil {
+		return database.Session{}, fmt.Errorf("failed to resume session: %w", err)
+	}
+	return session, nil
+}
+
+// EndStudySession ends a session at now, closing any open pause.
+func EndStudySession(ctx context.Context, q *database.Queries, userID, sessionID string, now time.Time) (database.Session, error) {
+	session, err := getOwnedSession(ctx, q, userID, sessionID)
+	if err != nil {
+		return database.Session{}, err
+	}
+	if !session.IsActive {
+		return database.Session{}, ErrSessionEnded
+	}
+	session, err = q.EndSession(ctx, database.EndSessionParams{
+		EndTime:       sql.NullTime{Time: now, Valid: true},
+		PausedSeconds: pausedSeconds(session, now),
+		ID:            session.ID,
+	})
+	if err != nil {
+		return database.Session{}, fmt.Errorf("failed to end session: %w", err)
+	}
+	return session, nil
+}
+
+// SummarizeSession totals the session's reviews by rating and works out the
+// time spent in it, excluding pauses.
+func SummarizeSession(ctx context.Context, q *database.Queries, session database.Session, now time.Time) (SessionSummary, error) {
+	rows, err := q.SummarizeSessionReviews(ctx, sql.NullString{String: session.ID, Valid: true})
+	if err != nil {
+		return SessionSummary{}, fmt.Errorf("failed to summarize reviews: %w", err)
+	}
+	summary := SessionSummary{Counts: make(map[algorithm.Rating]int64)}
+	for _, row := range rows {
+		if rating, ok := ratingFromID(row.RatingID); ok {
+			summary.Counts[rating] += row.ReviewCount
+		}
+		summary.Reviews += row.ReviewCount
+		summary.ReviewSeconds += row.ReviewSeconds
+	}
+
+	end := now
+	if session.EndTime.Valid {
+		end = session.EndTime.Time
+	}
+	active := int64(end.Sub(session.StartTime)/time.Second) - session.PausedSeconds - pausedSeconds(session, now)
+	summary.ActiveSeconds = max(active, 0)
+
+	if session.IsActive {
+		pending, err := q.ListSessionQueue(ctx, database.ListSessionQueueParams{
+			SessionID: session.ID,
+			Now:       sql.NullTime{Time: now, Valid: true},
+		})
+		if err != nil {
+			return SessionSummary{}, fmt.Errorf("failed to list session cards: %w", err)
+		}
+		summary.Remaining = len(pending)
+	}
+	return summary, nil
+}
+
+// getOwnedSession loads a session, returning ErrSessionNotFound unless it belongs to userID.
+func getOwnedSession(ctx context.Context, q *database.Queries, userID, sessionID string) (database.Session, error) {
+	session, err := q.GetSession(ctx, sessionID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && session.UserID != userID) {
+		return database.Session{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return database.Session{}, fmt.Errorf("failed to get session: %w", err)
+	}
+	return session, nil
+}
+
+// getActiveSession is getOwnedSession for sessions that can be studied: not ended and not paused.
+func getActiveSession(ctx context.Context, q *database.Queries, userID, sessionID string) (database.Session, error) {
+	session, err := getOwnedSession(ctx, q, userID, sessionID)
+	if err != nil {
+		return database.Session{}, err
+	}
+	if !session.IsActive {
+		return database.Session{}, ErrSessionEnded
+	}
+	if session.PausedAt.Valid {
+		return database.Session{}, ErrSessionPaused
+	}
+	return session, nil
+}
+
+// sessionCardDue returns when a waiting session card is due; it is null for
+// cards that are shown in session order.
+func sessionCardDue(session database.Session, row database.ListSessionQueueRow) sql.NullTime {
+	if convertNullString(session.Mode) == SessionModeCram {
+		return row.NextCramDue
+	}
+	state := algorithm.State(convertNullString(row.CardStatus))
+	if state == algorithm.StateLearning || state == algorithm.StateRelearning {
+		return row.DueDate
+	}
+	return sql.NullTime{}
+}
+
+// pausedSeconds is the length of the session's current pause, 0 if it is not paused.
+func pausedSeconds(session database.Session, now time.Time) int64 {
+	if !session.PausedAt.Valid {
+		return 0
+	}
+	return max(int64(now.Sub(session.PausedAt.Time)/time.Second), 0)
+}
+
+// respondSessionError maps session errors to responses, logging anything unexpected.
+func respondSessionError(c echo.Context, err error, action string) error {
+	switch {
+	case errors.Is(err, ErrSessionNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Session not found"})
+	case errors.Is(err, ErrCardNotInSession), errors.Is(err, ErrCardNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Card not found in session"})
+	case errors.Is(err, ErrCardSuspended):
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "Card is suspended"})
+	case errors.Is(err, ErrNoTypedAnswer):
+		return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{Error: "Card has no answer to type"})
+	case errors.Is(err, ErrNothingToUndo):
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "Nothing to undo"})
+	case errors.Is(err, ErrCannotUndo):
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "Review cannot be undone"})
+	case errors.Is(err, ErrSessionEnded):
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "Session has ended"})
+	case errors.Is(err, ErrSessionPaused):
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "Session is paused"})
+	case errors.Is(err, ErrSessionNotPaused):
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "Session is not paused"})
+	}
+	logging.SlogLogger.Error("Error handling session", "action", action, "error", err)
+	return c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error: "Failed to " + action,
+	})
+}
+
+// respondWithSession writes the session with its decks and summary.
+func respondWithSession(c echo.Context, q *database.Queries, status int, session database.Session, now time.Time) error {
+	response, err := buildSessionResponse(c.Request().Context(), q, session, now)
+	if err != nil {
+		logging.SlogLogger.Error("Error building session response", "session", session.ID, "error", err)
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to retrieve session",
+		})
+	}
+	return c.JSON(status, response)
+}
+
+// buildSessionResponse loads the session's decks and summary.
+func buildSessionResponse(ctx context.Context, q *database.Queries, session database.Session, now time.Time) (SessionResponse, error) {
+	decks, err := q.ListSessionDecks(ctx, session.ID)
+	if err != nil {
+		return SessionResponse{}, fmt.Errorf("failed to list session decks: %w", err)
+	}
+	summary, err := SummarizeSession(ctx, q, session, now)
+	if err != nil {
+		return SessionResponse{}, err
+	}
+	return convertSessionToResponse(session, decks, summary), nil
+}
+
+func convertSessionToResponse(session database.Session, decks []database.SessionDeck, summary SessionSummary) SessionResponse {
+	deckIDs := make([]string, 0, len(decks))
+	for _, deck := range decks {
+		deckIDs = append(deckIDs, deck.DeckID)
+	}
+
+	var accuracy float64
+	if summary.Reviews > 0 {
+		accuracy = float64(summary.Reviews-summary.Counts[algorithm.Again]) / float64(summary.Reviews)
+	}
+	return SessionResponse{
+		ID:         session.ID,
+		Name:       convertNullString(session.Name),
+		Mode:       convertNullString(session.Mode),
+		Reschedule: session.Reschedule,
+		DeckIDs:    deckIDs,
+		StartTime:  session.StartTime,
+		EndTime:    convertNullTime(session.EndTime),
+		IsActive:   session.IsActive,
+		Paused:     session.PausedAt.Valid,
+		Summary: SessionSummaryResponse{
+			Reviews:       summary.Reviews,
+			Again:         summary.Counts[algorithm.Again],
+			Hard:          summary.Counts[algorithm.Hard],
+			Good:          summary.Counts[algorithm.Good],
+			Easy:          summary.Counts[algorithm.Easy],
+			ReviewSeconds: summary.ReviewSeconds,
+			ActiveSeconds: summary.ActiveSeconds,
+			Accuracy:      accuracy,
+			Remaining:     summary.Remaining,
+		},
+	}
+}
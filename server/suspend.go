@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	algorithm "github.com/threeroundsoftware/voidabyss/algo"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+)
+
+// CardActionRequest names the card an action applies to.
+type CardActionRequest struct {
+	CardID string `param:"cardID" validate:"required,alphanum,len=10"`
+}
+
+// FuncSuspendCardHandler takes a card out of study until it is unsuspended.
+func FuncSuspendCardHandler(app *app.App) echo.HandlerFunc {
+	return cardActionHandler(app, "suspend card", SuspendCard)
+}
+
+// FuncUnsuspendCardHandler returns a suspended card to the state it was
+// suspended in.
+func FuncUnsuspendCardHandler(app *app.App) echo.HandlerFunc {
+	return cardActionHandler(app, "unsuspend card", UnsuspendCard)
+}
+
+// FuncBuryCardHandler holds a card out of study until the next day.
+func FuncBuryCardHandler(app *app.App) echo.HandlerFunc {
+	return cardActionHandler(app, "bury card", BuryCard)
+}
+
+// FuncUnburyCardHandler returns a buried card to study today.
+func FuncUnburyCardHandler(app *app.App) echo.HandlerFunc {
+	return cardActionHandler(app, "unbury card", UnburyCard)
+}
+
+// cardActionHandler applies action to one of the user's cards and responds
+// with the card's new state.
+func cardActionHandler(
+	app *app.App,
+	name string,
+	action func(ctx context.Context, q *database.Queries, card database.Card, day algorithm.StudyDay, now time.Time) (database.Card, error),
+) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req CardActionRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating card request", "action", name, "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		ctx := c.Request().Context()
+		day, err := userStudyDay(ctx, app.Queries, user.ID)
+		if err != nil {
+			logging.SlogLogger.Error("Error getting study day", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to " + name,
+			})
+		}
+		card, _, err := getOwnedCard(ctx, app.Queries, user.ID, req.CardID)
+		if err == nil {
+			card, err = action(ctx, app.Queries, card, day, time.Now().UTC())
+		}
+		if errors.Is(err, ErrCardNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Card not found",
+			})
+		}
+		if err != nil {
+			logging.SlogLogger.Error("Error updating card", "action", name, "user", user.ID, "card", req.CardID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to " + name,
+			})
+		}
+
+		return c.JSON(http.StatusOK, convertCardToStateResponse(card))
+	}
+}
+
+// SuspendCard suspends card, keeping its scheduling for when it is
+// unsuspended. Suspending a suspended card changes nothing.
+func SuspendCard(ctx context.Context, q *database.Queries, card database.Card, day algorithm.StudyDay, now time.Time) (database.Card, error) {
+	if convertNullString(card.Status) == CardStatusSuspended {
+		return card, nil
+	}
+	card, err := q.SuspendCard(ctx, card.ID)
+	if err != nil {
+		return database.Card{}, fmt.Errorf("failed to suspend card: %w", err)
+	}
+	return card, nil
+}
+
+// UnsuspendCard restores a suspended card to the state it was suspended in.
+func UnsuspendCard(ctx context.Context, q *database.Queries, card database.Card, day algorithm.StudyDay, now time.Time) (database.Card, error) {
+	if convertNullString(card.Status) != CardStatusSuspended {
+		return card, nil
+	}
+	card, err := q.UnsuspendCard(ctx, card.ID)
+	if err != nil {
+		return database.Card{}, fmt.Errorf("failed to unsuspend card: %w", err)
+	}
+	return card, nil
+}
+
+// BuryCard buries card until the start of the next study day.
+func BuryCard(ctx context.Context, q *database.Queries, card database.Card, day algorithm.StudyDay, now time.Time) (database.Card, error) {
+	card, err := q.BuryCard(ctx, database.BuryCardParams{
+		BuriedUntil: sql.NullTime{Time: buriedUntil(day, now), Valid: true},
+		ID:          card.ID,
+	})
+	if err != nil {
+		return database.Card{}, fmt.Errorf("failed to bury card: %w", err)
+	}
+	return card, nil
+}
+
+// UnburyCard lifts a card's burial, whether manual or by a sibling.
+func UnburyCard(ctx context.Context, q *database.Queries, card database.Card, day algorithm.StudyDay, now time.Time) (database.Card, error) {
+	card, err := q.UnburyCard(ctx, card.ID)
+	if err != nil {
+		return database.Card{}, fmt.Errorf("failed to unbury card: %w", err)
+	}
+	return card, nil
+}
+
+// burySiblings buries the other new and review cards of card's note once card
+// has been studied at now. Siblings already buried keep their burial.
+func burySiblings(ctx context.Context, q *database.Queries, card database.Card, day algorithm.StudyDay, now time.Time) error {
+	err := q.BurySiblingCards(ctx, database.BurySiblingCardsParams{
+		BuriedUntil: sql.NullTime{Time: buriedUntil(day, now), Valid: true},
+		NoteID:      card.NoteID,
+		ID:          card.ID,
+		Now:         sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to bury siblings: %w", err)
+	}
+	return nil
+}
+
+// buriedUntil is when a card buried at now returns to study: the start of
+// the next study day.
+func buriedUntil(day algorithm.StudyDay, now time.Time) time.Time {
+	return day.AddDays(day.Start(now), 1)
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+)
+
+var ErrNoteNotFound = errors.New("note not found")
+
+// SetNoteTagsRequest replaces the tags of a note.
+type SetNoteTagsRequest struct {
+	NoteID string   `param:"noteID" validate:"required,alphanum,len=10"`
+	Tags   []string `json:"tags" validate:"max=64,dive,required,max=64"`
+}
+
+// TagsListResponse lists tag names.
+type TagsListResponse struct {
+	Tags []string `json:"tags"`
+}
+
+// FuncGetTagsHandler lists the tags the user has used.
+func FuncGetTagsHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		tags, err := app.Queries.ListTagsByOwner(c.Request().Context(), user.ID)
+		if err != nil {
+			logging.SlogLogger.Error("Error listing tags", "user", user.ID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to retrieve tags",
+			})
+		}
+		return c.JSON(http.StatusOK, convertTagsToResponse(tags))
+	}
+}
+
+// FuncSetNoteTagsHandler replaces the tags of one of the user's notes.
+func FuncSetNoteTagsHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req SetNoteTagsRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating note tags request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		tags, err := SetNoteTags(c.Request().Context(), app, user.ID, req.NoteID, req.Tags)
+		if errors.Is(err, ErrNoteNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: "Note not found",
+			})
+		}
+		if err != nil {
+			logging.SlogLogger.Error("Error setting note tags", "user", user.ID, "note", req.NoteID, "error", err)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: "Failed to set tags",
+			})
+		}
+		return c.JSON(http.StatusOK, convertTagsToResponse(tags))
+	}
+}
+
+// SetNoteTags replaces the tags of a note, creating tags that do not exist yet.
+func SetNoteTags(ctx context.Context, app *app.App, userID, noteID string, names []string) ([]database.Tag, error) {
+	note, err := getOwnedNote(ctx, app.Queries, userID, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := app.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := app.Queries.WithTx(tx)
+
+	if err := qtx.ClearNoteTags(ctx, note.ID); err != nil {
+		return nil, fmt.Errorf("failed to clear note tags: %w", err)
+	}
+	for _, name := range normalizeTags(names) {
+		tag, err := qtx.UpsertTag(ctx, database.UpsertTagParams{OwnerID: userID, Name: name})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tag: %w", err)
+		}
+		err = qtx.AddTagToNote(ctx, database.AddTagToNoteParams{NoteID: note.ID, TagID: tag.ID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to tag note: %w", err)
+		}
+	}
+
+	tags, err := qtx.ListTagsByNote(ctx, note.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list note tags: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tags: %w", err)
+	}
+	return tags, nil
+}
+
+// normalizeTags trims tag names and drops blanks and duplicates.
+func normalizeTags(names []string) []string {
+	trimmed := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			trimmed = append(trimmed, name)
+		}
+	}
+	return uniqueStringSlice(trimmed)
+}
+
+func convertTagsToResponse(tags []database.Tag) TagsListResponse {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return TagsListResponse{Tags: names}
+}
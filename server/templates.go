@@ -1,28 +1,36 @@
 package server
 
 import (
+	"cmp"
+	"context"
 	"database/sql"
-	"html/template"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/threeroundsoftware/voidabyss/app"
 	"github.com/threeroundsoftware/voidabyss/database"
 	"github.com/threeroundsoftware/voidabyss/internal/logging"
+	"github.com/threeroundsoftware/voidabyss/render"
 )
 
 // TemplateResponse represents the structure of a single template in the API response.
 type TemplateResponse struct {
-	ID           string    `json:"id"`
-	NoteTypeID   string    `json:"note_type_id"`
-	TemplateName string    `json:"template_name"`
-	FrontHtml    string    `json:"front_html"`
-	BackHtml     string    `json:"back_html"`
-	Css          string    `json:"css"`
-	OwnerID      string    `json:"owner_id"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           string `json:"id"`
+	NoteTypeID   string `json:"note_type_id"`
+	TemplateName string `json:"template_name"`
+	FrontHtml    string `json:"front_html"`
+	BackHtml     string `json:"back_html"`
+	Css          string `json:"css"`
+	// ConditionField is the field a note must have filled in to get a card
+	// from the template, or empty if every note gets one.
+	ConditionField string    `json:"condition_field"`
+	OwnerID        string    `json:"owner_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 // TemplatesListResponse encapsulates a list of TemplateResponse.
@@ -92,21 +100,10 @@ func FuncGetTemplateHandler(app *app.App) echo.HandlerFunc {
 			})
 		}
 
-		// Fetch template from database
-		template, err := app.Queries.GetCardTemplate(c.Request().Context(), req.ID)
+		// Fetch template from database, verifying ownership
+		template, err := getOwnedTemplate(c.Request().Context(), app.Queries, user.ID, req.ID)
 		if err != nil {
-			logging.SlogLogger.Error("Error retreiving card template", "error", err)
-			return c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error: "Failed to retrieve tamplate",
-			})
-		}
-
-		// verify ownership
-		if template.OwnerID != user.ID {
-			logging.SlogLogger.Error("Unauthorized access to template", "user", user, "template", template)
-			return c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error: "Failed to retrieve tamplate",
-			})
+			return respondTemplateError(c, err, "retrieve template")
 		}
 
 		// convert to response
@@ -119,6 +116,30 @@ func FuncGetTemplateHandler(app *app.App) echo.HandlerFunc {
 	}
 }
 
+var (
+	ErrTemplateNotFound        = errors.New("card template not found")
+	ErrTemplateVersionNotFound = errors.New("card template version not found")
+	// ErrInvalidTemplate is returned when a template does not fit its note type.
+	ErrInvalidTemplate = errors.New("invalid card template")
+	// ErrLastTemplate is returned when deleting the only template of a note
+	// type, which would leave its notes without cards.
+	ErrLastTemplate = errors.New("last card template of note type")
+)
+
+// TemplateError says why a template cannot be saved or previewed. It is an
+// ErrInvalidTemplate.
+type TemplateError struct {
+	Message string
+}
+
+func (e *TemplateError) Error() string {
+	return "invalid card template: " + e.Message
+}
+
+func (e *TemplateError) Is(target error) bool {
+	return target == ErrInvalidTemplate
+}
+
 type Field struct {
 	Name string
 }
@@ -129,24 +150,97 @@ type Field struct {
 // 	Fields      []Field
 // }
 
-// UserFunction represents a function sent from the frontend
-type UserFunction struct {
-	Name       string `json:"name"`
-	OutputType string `json:"outputType"`
-	Body       string `json:"body"`
-}
-
+// NewTemplateRequest adds a card template to one of the user's note types.
+// Without a note type, as the template editor sends it, a note type named
+// after the template is created with Fields, and Content is the front.
+// ConditionField, when given, limits the template's cards to notes with that
+// field filled in; cards of notes whose field is emptied are suspended as
+// orphans until it is filled in again.
 type NewTemplateRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"escription"`
-	Content     string `json:"template"`
-	// CustomFunctions     []UserFunction `json:"custom_functions"`
-	Fields []Field `json:"fields"`
+	NoteTypeID     string  `json:"note_type_id" validate:"omitempty,alphanum,len=10"`
+	Name           string  `json:"name" validate:"required,max=128"`
+	Description    string  `json:"description" validate:"max=1024"`
+	FrontHtml      string  `json:"front_html"`
+	BackHtml       string  `json:"back_html"`
+	Css            string  `json:"css"`
+	ConditionField string  `json:"condition_field" validate:"max=128"`
+	Content        string  `json:"template"`
+	Fields         []Field `json:"fields" validate:"max=64"`
 }
 
 type CreateTemplateResponse struct {
-	Message    string `json:"message"`
+	Message    string           `json:"message"`
+	TemplateID string           `json:"template_id"`
+	Template   TemplateResponse `json:"template"`
+}
+
+// UpdateTemplateRequest replaces a template's name, sides, CSS and condition
+// field, saving them as a new version.
+type UpdateTemplateRequest struct {
+	ID             string `param:"templateID" validate:"required,alphanum,len=10"`
+	Name           string `json:"name" validate:"required,max=128"`
+	FrontHtml      string `json:"front_html" validate:"required"`
+	BackHtml       string `json:"back_html"`
+	Css            string `json:"css"`
+	ConditionField string `json:"condition_field" validate:"max=128"`
+}
+
+// PreviewTemplateRequest renders a template against one of the user's notes
+// or, without one, against sample content for each field. Fields override
+// the note's content, and FrontHtml, BackHtml and Css, when given, stand in
+// for the saved template so that unsaved edits can be previewed. Ordinal
+// picks the cloze to hide, by default the note's first.
+type PreviewTemplateRequest struct {
+	ID        string            `param:"templateID" validate:"required,alphanum,len=10"`
+	NoteID    string            `json:"note_id" validate:"omitempty,alphanum,len=10"`
+	Fields    map[string]string `json:"fields" validate:"max=64"`
+	Ordinal   int               `json:"ordinal" validate:"min=0,max=500"`
+	FrontHtml string            `json:"front_html"`
+	BackHtml  string            `json:"back_html"`
+	Css       string            `json:"css"`
+}
+
+// TemplateVersionRequest names a version of one of the user's templates.
+type TemplateVersionRequest struct {
+	ID      string `param:"templateID" validate:"required,alphanum,len=10"`
+	Version int64  `param:"version" validate:"required,min=1"`
+}
+
+// TemplatePreviewResponse is a template rendered for preview.
+type TemplatePreviewResponse struct {
 	TemplateID string `json:"template_id"`
+	// NoteID is empty when the template was rendered with sample content.
+	NoteID  string `json:"note_id"`
+	Ordinal int    `json:"ordinal"`
+	Front   string `json:"front"`
+	Back    string `json:"back"`
+	Css     string `json:"css"`
+}
+
+// TemplateVersionResponse is one saved state of a template.
+type TemplateVersionResponse struct {
+	Version        int64     `json:"version"`
+	TemplateName   string    `json:"template_name"`
+	FrontHtml      string    `json:"front_html"`
+	BackHtml       string    `json:"back_html"`
+	Css            string    `json:"css"`
+	ConditionField string    `json:"condition_field"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TemplateVersionsListResponse lists a template's versions, latest first.
+type TemplateVersionsListResponse struct {
+	TemplateID string                    `json:"template_id"`
+	Versions   []TemplateVersionResponse `json:"versions"`
+}
+
+// TemplateContent is what each version of a card template holds.
+type TemplateContent struct {
+	Name           string
+	FrontHtml      string
+	BackHtml       string
+	Css            string
+	ConditionField string
 }
 
 // CreateTemplateHandler handles template creation
@@ -163,80 +257,533 @@ func FuncCreateTemplateHandler(app *app.App) echo.HandlerFunc {
 		var req NewTemplateRequest
 		err = validateRequest(c, &req)
 		if err != nil {
-			logging.SlogLogger.Error("Error validating create deck request", "error", err)
+			logging.SlogLogger.Error("Error validating create template request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		cardTemplate, err := CreateTemplate(c.Request().Context(), app, user.ID, req)
+		if err != nil {
+			return respondTemplateError(c, err, "create template")
+		}
+
+		return c.JSON(http.StatusCreated,
+			CreateTemplateResponse{
+				Message:    "create template is successful",
+				TemplateID: cardTemplate.ID,
+				Template:   convertTemplateToResponse(cardTemplate, Detail),
+			})
+	}
+}
+
+// FuncUpdateTemplateHandler saves new content for a template as its next
+// version.
+func FuncUpdateTemplateHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req UpdateTemplateRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating update template request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		cardTemplate, err := UpdateTemplate(c.Request().Context(), app, user.ID, req.ID, TemplateContent{
+			Name:           req.Name,
+			FrontHtml:      req.FrontHtml,
+			BackHtml:       req.BackHtml,
+			Css:            req.Css,
+			ConditionField: req.ConditionField,
+		})
+		if err != nil {
+			return respondTemplateError(c, err, "update template")
+		}
+		return c.JSON(http.StatusOK, TemplateDetailResponse{
+			Template: convertTemplateToResponse(cardTemplate, Detail),
+		})
+	}
+}
+
+// FuncDeleteTemplateHandler deletes a template together with its cards.
+func FuncDeleteTemplateHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req GetTemplateDetailRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating template request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		if err := DeleteTemplate(c.Request().Context(), app, user.ID, req.ID); err != nil {
+			return respondTemplateError(c, err, "delete template")
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+// FuncPreviewTemplateHandler renders a template, or a draft of it, without
+// saving anything.
+func FuncPreviewTemplateHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req PreviewTemplateRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating preview template request", "error", err)
 			return c.JSON(http.StatusBadRequest, ErrorResponse{
 				Error: "Failed to validate request",
 			})
 		}
 
-		// // Parse and register user-defined functions
-		// funcMap, err := parseCustomFunctions(req.CustomFunctions)
-		// if err != nil {
-		// 	return c.JSON(http.StatusInternalServerError, ErrorResponse{
-		// 		Error: "Failed to register functions",
-		// 	})
-		// }
+		response, err := PreviewTemplate(c.Request().Context(), app.Queries, user.ID, req)
+		if err != nil {
+			return respondTemplateError(c, err, "preview template")
+		}
+		return c.JSON(http.StatusOK, response)
+	}
+}
+
+// FuncGetTemplateVersionsHandler lists the saved versions of a template.
+func FuncGetTemplateVersionsHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req GetTemplateDetailRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating template request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
 
-		// Register functions and parse template
-		// tmpl, err := template.New("cardTemplate").Funcs(funcMap).Parse(req.TemplateText)
+		ctx := c.Request().Context()
+		cardTemplate, err := getOwnedTemplate(ctx, app.Queries, user.ID, req.ID)
+		if err != nil {
+			return respondTemplateError(c, err, "retrieve template versions")
+		}
+		versions, err := app.Queries.ListCardTemplateVersions(ctx, cardTemplate.ID)
+		if err != nil {
+			return respondTemplateError(c, fmt.Errorf("failed to list template versions: %w", err), "retrieve template versions")
+		}
+		return c.JSON(http.StatusOK, convertTemplateVersionsToResponse(cardTemplate.ID, versions))
+	}
+}
 
-		logging.SlogLogger.Info("Template content", "template", req.Content)
+// FuncRollbackTemplateHandler restores an earlier version of a template. The
+// restored content is saved as a new version, so the rollback can itself be
+// undone.
+func FuncRollbackTemplateHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
 
-		tmpl, err := template.New("cardTemplate").Parse(req.Content)
+		var req TemplateVersionRequest
+		err = validateRequest(c, &req)
 		if err != nil {
-			logging.SlogLogger.Error("Template parsing error", "error", err)
+			logging.SlogLogger.Error("Error validating template version request", "error", err)
 			return c.JSON(http.StatusBadRequest, ErrorResponse{
-				Error: "Invalid template",
+				Error: "Failed to validate request",
 			})
 		}
 
-		logging.SlogLogger.Info("Templates saved", "templates", tmpl)
+		ctx := c.Request().Context()
+		if _, err := getOwnedTemplate(ctx, app.Queries, user.ID, req.ID); err != nil {
+			return respondTemplateError(c, err, "roll back template")
+		}
+		version, err := app.Queries.GetCardTemplateVersion(ctx, database.GetCardTemplateVersionParams{
+			CardTemplateID: req.ID,
+			Version:        req.Version,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrTemplateVersionNotFound
+		}
+		if err != nil {
+			return respondTemplateError(c, err, "roll back template")
+		}
+
+		cardTemplate, err := UpdateTemplate(ctx, app, user.ID, req.ID, TemplateContent{
+			Name:           version.TemplateName,
+			FrontHtml:      version.FrontHtml,
+			BackHtml:       version.BackHtml,
+			Css:            convertNullString(version.Css),
+			ConditionField: convertNullString(version.ConditionField),
+		})
+		if err != nil {
+			return respondTemplateError(c, err, "roll back template")
+		}
+		return c.JSON(http.StatusOK, TemplateDetailResponse{
+			Template: convertTemplateToResponse(cardTemplate, Detail),
+		})
+	}
+}
+
+// CreateTemplate adds a template to a note type, creating the note type first
+// when the request names none, and generates the template's cards for the
+// note type's notes.
+func CreateTemplate(ctx context.Context, app *app.App, userID string, req NewTemplateRequest) (database.CardTemplate, error) {
+	content := TemplateContent{
+		Name:           req.Name,
+		FrontHtml:      cmp.Or(req.FrontHtml, req.Content),
+		BackHtml:       req.BackHtml,
+		Css:            req.Css,
+		ConditionField: req.ConditionField,
+	}
+	if content.FrontHtml == "" {
+		return database.CardTemplate{}, &TemplateError{Message: "Template front is required"}
+	}
+
+	var fields []NoteTypeFieldRequest
+	if req.NoteTypeID == "" {
+		if len(req.Fields) == 0 {
+			return database.CardTemplate{}, &TemplateError{Message: "A new note type needs at least one field"}
+		}
+		for _, field := range req.Fields {
+			fields = append(fields, NoteTypeFieldRequest{Name: field.Name, Kind: FieldKindText})
+		}
+		if msg := checkNoteTypeFields(fields); msg != "" {
+			return database.CardTemplate{}, &TemplateError{Message: msg}
+		}
+	}
+
+	tx, err := app.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return database.CardTemplate{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := app.Queries.WithTx(tx)
 
-		noteType, err := app.Queries.CreateNoteType(c.Request().Context(), database.CreateNoteTypeParams{
+	var noteType database.NoteType
+	if req.NoteTypeID == "" {
+		noteType, err = qtx.CreateNoteType(ctx, database.CreateNoteTypeParams{
 			Name:        req.Name,
 			Description: sql.NullString{String: req.Description, Valid: true},
-			OwnerID:     user.ID,
+			OwnerID:     userID,
 		})
+		if err != nil {
+			return database.CardTemplate{}, fmt.Errorf("failed to create note type: %w", err)
+		}
+		if err := createNoteTypeFields(ctx, qtx, noteType.ID, fields); err != nil {
+			return database.CardTemplate{}, err
+		}
+	} else {
+		noteType, err = getOwnedNoteType(ctx, qtx, userID, req.NoteTypeID)
+		if err != nil {
+			return database.CardTemplate{}, err
+		}
+	}
+	if err := checkTemplateContent(ctx, qtx, userID, noteType.ID, content); err != nil {
+		return database.CardTemplate{}, err
+	}
 
-		cardTemplate, err := app.Queries.CreateCardTemplate(c.Request().Context(), database.CreateCardTemplateParams{
-			NoteTypeID:   noteType.ID,
-			TemplateName: req.Name,
-			FrontHtml:    req.Fields[0].Name,
-			BackHtml:     req.Fields[1].Name,
-			Css:          sql.NullString{},
-			OwnerID:      user.ID,
-		})
+	cardTemplate, err := qtx.CreateCardTemplate(ctx, database.CreateCardTemplateParams{
+		NoteTypeID:     noteType.ID,
+		TemplateName:   content.Name,
+		FrontHtml:      content.FrontHtml,
+		BackHtml:       content.BackHtml,
+		Css:            sql.NullString{String: content.Css, Valid: content.Css != ""},
+		OwnerID:        userID,
+		ConditionField: sql.NullString{String: content.ConditionField, Valid: content.ConditionField != ""},
+	})
+	if err != nil {
+		return database.CardTemplate{}, fmt.Errorf("failed to create card template: %w", err)
+	}
+	if err := saveTemplateVersion(ctx, qtx, cardTemplate); err != nil {
+		return database.CardTemplate{}, err
+	}
 
-		return c.JSON(http.StatusCreated,
-			CreateTemplateResponse{
-				Message:    "create template is successful",
-				TemplateID: cardTemplate.ID,
-			})
+	if err := tx.Commit(); err != nil {
+		return database.CardTemplate{}, fmt.Errorf("failed to commit template: %w", err)
 	}
+	return cardTemplate, nil
 }
 
-// // parseCustomFunctions securely registers functions for Go templates
-// func parseCustomFunctions(userFuncs []UserFunction) (template.FuncMap, error) {
-// 	funcMap := template.FuncMap{}
-//
-// 	for _, fn := range userFuncs {
-// 		switch fn.OutputType {
-// 		case "boolean":
-// 			// Secure evaluation: Only predefined safe logic
-// 			if fn.Body == "return true;" {
-// 				funcMap[fn.Name] = func() bool { return true }
-// 			} else if fn.Body == "return false;" {
-// 				funcMap[fn.Name] = func() bool { return false }
-// 			} else {
-// 				return nil, fmt.Errorf("Invalid function body")
-// 			}
-// 		default:
-// 			return nil, fmt.Errorf("Unsupported function output type")
-// 		}
-// 	}
-//
-// 	return funcMap, nil
-// }
+// UpdateTemplate replaces the content of one of the user's templates, saves it
+// as the template's next version and brings the note type's cards in line
+// with it.
+func UpdateTemplate(ctx context.Context, app *app.App, userID, templateID string, content TemplateContent) (database.CardTemplate, error) {
+	tx, err := app.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return database.CardTemplate{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := app.Queries.WithTx(tx)
+
+	cardTemplate, err := getOwnedTemplate(ctx, qtx, userID, templateID)
+	if err != nil {
+		return database.CardTemplate{}, err
+	}
+	if err := checkTemplateContent(ctx, qtx, userID, cardTemplate.NoteTypeID, content); err != nil {
+		return database.CardTemplate{}, err
+	}
+
+	cardTemplate, err = qtx.UpdateCardTemplate(ctx, database.UpdateCardTemplateParams{
+		TemplateName:   content.Name,
+		FrontHtml:      content.FrontHtml,
+		BackHtml:       content.BackHtml,
+		Css:            sql.NullString{String: content.Css, Valid: content.Css != ""},
+		ConditionField: sql.NullString{String: content.ConditionField, Valid: content.ConditionField != ""},
+		ID:             cardTemplate.ID,
+	})
+	if err != nil {
+		return database.CardTemplate{}, fmt.Errorf("failed to update card template: %w", err)
+	}
+	if err := saveTemplateVersion(ctx, qtx, cardTemplate); err != nil {
+		return database.CardTemplate{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.CardTemplate{}, fmt.Errorf("failed to commit template: %w", err)
+	}
+	return cardTemplate, nil
+}
+
+// DeleteTemplate deletes one of the user's templates with its cards and their
+// reviews. A note type's last template cannot be deleted.
+func DeleteTemplate(ctx context.Context, app *app.App, userID, templateID string) error {
+	tx, err := app.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := app.Queries.WithTx(tx)
+
+	cardTemplate, err := getOwnedTemplate(ctx, qtx, userID, templateID)
+	if err != nil {
+		return err
+	}
+	templates, err := qtx.ListCardTemplatesByNoteType(ctx, database.ListCardTemplatesByNoteTypeParams{
+		OwnerID:    userID,
+		NoteTypeID: cardTemplate.NoteTypeID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list note type templates: %w", err)
+	}
+	if len(templates) <= 1 {
+		return ErrLastTemplate
+	}
+
+	// card_template_id is NOT NULL, so the cards go before their template.
+	if err := qtx.DeleteCardsByTemplate(ctx, cardTemplate.ID); err != nil {
+		return fmt.Errorf("failed to delete template cards: %w", err)
+	}
+	if err := qtx.DeleteCardTemplate(ctx, cardTemplate.ID); err != nil {
+		return fmt.Errorf("failed to delete card template: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit template deletion: %w", err)
+	}
+	return nil
+}
+
+// PreviewTemplate renders a template as req describes. Without a note, each
+// field of the note type shows its name in parentheses; media fields are
+// left empty.
+func PreviewTemplate(ctx context.Context, q *database.Queries, userID string, req PreviewTemplateRequest) (TemplatePreviewResponse, error) {
+	cardTemplate, err := getOwnedTemplate(ctx, q, userID, req.ID)
+	if err != nil {
+		return TemplatePreviewResponse{}, err
+	}
+	schema, err := q.ListNoteTypeFields(ctx, cardTemplate.NoteTypeID)
+	if err != nil {
+		return TemplatePreviewResponse{}, fmt.Errorf("failed to get note type fields: %w", err)
+	}
+
+	values := make(map[string]string, len(schema))
+	ordinal := req.Ordinal
+	if req.NoteID != "" {
+		note, err := getOwnedNote(ctx, q, userID, req.NoteID)
+		if err != nil {
+			return TemplatePreviewResponse{}, err
+		}
+		if note.NoteTypeID != cardTemplate.NoteTypeID {
+			return TemplatePreviewResponse{}, &TemplateError{Message: "Note is not of the template's note type"}
+		}
+		fields, err := q.ListFieldsByNote(ctx, note.ID)
+		if err != nil {
+			return TemplatePreviewResponse{}, fmt.Errorf("failed to get note fields: %w", err)
+		}
+		for _, f := range fields {
+			values[f.FieldName] = f.FieldContent
+		}
+	} else {
+		for _, field := range schema {
+			if field.Kind == FieldKindText || field.Kind == FieldKindHTML {
+				values[field.Name] = "(" + field.Name + ")"
+			}
+		}
+	}
+	for name, value := range req.Fields {
+		values[name] = value
+	}
+	if ordinal == 0 {
+		var numbers []int
+		for _, content := range values {
+			numbers = append(numbers, findAllPlaceholderNumbers(content)...)
+		}
+		if len(numbers) > 0 {
+			ordinal = slices.Min(numbers)
+		}
+	}
+
+	content := TemplateContent{
+		Name:           cardTemplate.TemplateName,
+		FrontHtml:      cmp.Or(req.FrontHtml, cardTemplate.FrontHtml),
+		BackHtml:       cmp.Or(req.BackHtml, cardTemplate.BackHtml),
+		Css:            cmp.Or(req.Css, convertNullString(cardTemplate.Css)),
+		ConditionField: convertNullString(cardTemplate.ConditionField),
+	}
+	names := make([]string, 0, len(schema))
+	for _, field := range schema {
+		names = append(names, field.Name)
+	}
+	funcs, err := userTemplateFuncs(ctx, q, userID)
+	if err != nil {
+		return TemplatePreviewResponse{}, err
+	}
+	if msg := checkTemplateFields(names, funcs, content.FrontHtml, content.BackHtml); msg != "" {
+		return TemplatePreviewResponse{}, &TemplateError{Message: msg}
+	}
+	rendered, err := render.Render(content.FrontHtml, content.BackHtml, renderData(schema, values), ordinal, funcs)
+	if errors.Is(err, render.ErrInvalidTemplate) {
+		return TemplatePreviewResponse{}, &TemplateError{Message: err.Error()}
+	}
+	if err != nil {
+		return TemplatePreviewResponse{}, err
+	}
+	return TemplatePreviewResponse{
+		TemplateID: cardTemplate.ID,
+		NoteID:     req.NoteID,
+		Ordinal:    ordinal,
+		Front:      string(rendered.Front),
+		Back:       string(rendered.Back),
+		Css:        content.Css,
+	}, nil
+}
+
+// getOwnedTemplate returns one of the user's card templates.
+func getOwnedTemplate(ctx context.Context, q *database.Queries, userID, templateID string) (database.CardTemplate, error) {
+	cardTemplate, err := q.GetCardTemplate(ctx, templateID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && cardTemplate.OwnerID != userID) {
+		return database.CardTemplate{}, ErrTemplateNotFound
+	}
+	if err != nil {
+		return database.CardTemplate{}, fmt.Errorf("failed to get card template: %w", err)
+	}
+	return cardTemplate, nil
+}
+
+// checkTemplateContent checks that a template and its condition field only
+// refer to fields of its note type, and that it only calls the built-in
+// functions and the user's own.
+func checkTemplateContent(ctx context.Context, q *database.Queries, userID, noteTypeID string, content TemplateContent) error {
+	if content.Name == "" {
+		return &TemplateError{Message: "Template name is required"}
+	}
+	if content.FrontHtml == "" {
+		return &TemplateError{Message: "Template front is required"}
+	}
+	schema, err := q.ListNoteTypeFields(ctx, noteTypeID)
+	if err != nil {
+		return fmt.Errorf("failed to get note type fields: %w", err)
+	}
+	names := make([]string, 0, len(schema))
+	for _, field := range schema {
+		names = append(names, field.Name)
+	}
+	funcs, err := userTemplateFuncs(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+	if msg := checkTemplateFields(names, funcs, content.FrontHtml, content.BackHtml); msg != "" {
+		return &TemplateError{Message: msg}
+	}
+	if msg := checkConditionField(names, content.ConditionField); msg != "" {
+		return &TemplateError{Message: msg}
+	}
+	return nil
+}
+
+// saveTemplateVersion records the template as it now is as its next version
+// and generates any cards the note type's notes are missing. Cards the
+// template no longer makes are orphaned rather than deleted, so that neither
+// an edit nor a rollback loses review history.
+func saveTemplateVersion(ctx context.Context, qtx *database.Queries, cardTemplate database.CardTemplate) error {
+	if _, err := qtx.CreateCardTemplateVersion(ctx, cardTemplate.ID); err != nil {
+		return fmt.Errorf("failed to save template version: %w", err)
+	}
+	notes, err := qtx.ListNotesByNoteType(ctx, cardTemplate.NoteTypeID)
+	if err != nil {
+		return fmt.Errorf("failed to list note type notes: %w", err)
+	}
+	for _, note := range notes {
+		if err := GenerateCardsForNote(ctx, qtx, note.ID, note.NoteTypeID, note.OwnerID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func respondTemplateError(c echo.Context, err error, action string) error {
+	var templateErr *TemplateError
+	switch {
+	case errors.As(err, &templateErr):
+		return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{Error: templateErr.Message})
+	case errors.Is(err, ErrTemplateNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Template not found"})
+	case errors.Is(err, ErrTemplateVersionNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Template version not found"})
+	case errors.Is(err, ErrNoteTypeNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Note type not found"})
+	case errors.Is(err, ErrNoteNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Note not found"})
+	case errors.Is(err, ErrLastTemplate):
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "A note type needs at least one template"})
+	}
+	logging.SlogLogger.Error("Error handling template", "action", action, "error", err)
+	return c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error: "Failed to " + action,
+	})
+}
 
 // // Execute the provided Go template and extract generated flashcards
 // func executeTemplate(tmpl *template.Template, templateName string, data TemplateData) ([]string, error) {
@@ -286,14 +833,34 @@ func convertTemplateToResponse(template database.CardTemplate, route Route) Temp
 	}
 
 	return TemplateResponse{
-		ID:           template.ID,
-		NoteTypeID:   template.NoteTypeID,
-		TemplateName: template.TemplateName,
-		FrontHtml:    template.FrontHtml,
-		BackHtml:     template.BackHtml,
-		Css:          css,
-		OwnerID:      template.OwnerID,
-		CreatedAt:    template.CreatedAt,
-		UpdatedAt:    template.UpdatedAt,
+		ID:             template.ID,
+		NoteTypeID:     template.NoteTypeID,
+		TemplateName:   template.TemplateName,
+		FrontHtml:      template.FrontHtml,
+		BackHtml:       template.BackHtml,
+		Css:            css,
+		ConditionField: convertNullString(template.ConditionField),
+		OwnerID:        template.OwnerID,
+		CreatedAt:      template.CreatedAt,
+		UpdatedAt:      template.UpdatedAt,
+	}
+}
+
+func convertTemplateVersionsToResponse(templateID string, versions []database.CardTemplateVersion) TemplateVersionsListResponse {
+	response := TemplateVersionsListResponse{
+		TemplateID: templateID,
+		Versions:   make([]TemplateVersionResponse, 0, len(versions)),
+	}
+	for _, version := range versions {
+		response.Versions = append(response.Versions, TemplateVersionResponse{
+			Version:        version.Version,
+			TemplateName:   version.TemplateName,
+			FrontHtml:      version.FrontHtml,
+			BackHtml:       version.BackHtml,
+			Css:            convertNullString(version.Css),
+			ConditionField: convertNullString(version.ConditionField),
+			CreatedAt:      version.CreatedAt,
+		})
 	}
+	return response
 }
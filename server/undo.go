@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/threeroundsoftware/voidabyss/app"
+	"github.com/threeroundsoftware/voidabyss/database"
+	"github.com/threeroundsoftware/voidabyss/internal/logging"
+)
+
+// UndoResponse reports the review that was undone and the card as restored.
+type UndoResponse struct {
+	UndoneReviewID string            `json:"undone_review_id"`
+	Rating         int64             `json:"rating"`
+	Card           CardStateResponse `json:"card"`
+}
+
+// UndoResult is the deleted review together with the restored card.
+type UndoResult struct {
+	Review database.Review
+	Card   database.Card
+}
+
+// FuncUndoSessionReviewHandler undoes the latest review of a session. Calling
+// it again undoes the review before, back to the start of the session.
+func FuncUndoSessionReviewHandler(app *app.App) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUserFromContext(c)
+		if err != nil {
+			logging.SlogLogger.Error("Unauthorized access attempt", "error", err)
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Unauthorized",
+			})
+		}
+
+		var req SessionDetailRequest
+		err = validateRequest(c, &req)
+		if err != nil {
+			logging.SlogLogger.Error("Error validating session request", "error", err)
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: "Failed to validate request",
+			})
+		}
+
+		result, err := UndoSessionReview(c.Request().Context(), app, user.ID, req.ID, time.Now().UTC())
+		if err != nil {
+			return respondSessionError(c, err, "undo review")
+		}
+		return c.JSON(http.StatusOK, convertUndoResultToResponse(result))
+	}
+}
+
+// UndoSessionReview deletes the latest review of an active session and puts
+// back what it replaced: the card's scheduling, the session card's progress
+// and, when no other card of the note has been reviewed today, the siblings it
+// buried. A leech's tag or move to the Leeches deck is kept. A review cannot be
+// undone once its card has been reviewed again outside the session, or changed
+// since by anything else, such as a suspension or an event, that restoring its
+// prior state would overwrite.
+func UndoSessionReview(ctx context.Context, app *app.App, userID, sessionID string, now time.Time) (UndoResult, error) {
+	session, err := getActiveSession(ctx, app.Queries, userID, sessionID)
+	if err != nil {
+		return UndoResult{}, err
+	}
+
+	tx, err := app.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return UndoResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := app.Queries.WithTx(tx)
+
+	review, err := qtx.GetLatestReviewBySession(ctx, sql.NullString{String: session.ID, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		return UndoResult{}, ErrNothingToUndo
+	}
+	if err != nil {
+		return UndoResult{}, fmt.Errorf("failed to get latest review: %w", err)
+	}
+	if !review.PriorSessionCardStatus.Valid || (review.Scheduled && !review.PriorStatus.Valid) {
+		return UndoResult{}, ErrCannotUndo
+	}
+
+	card, err := qtx.GetCard(ctx, review.CardID)
+	if err != nil {
+		return UndoResult{}, fmt.Errorf("failed to get card: %w", err)
+	}
+	if review.Scheduled {
+		latest, err := qtx.GetLatestReviewByCard(ctx, card.ID)
+		if err != nil {
+			return UndoResult{}, fmt.Errorf("failed to get last review: %w", err)
+		}
+		if latest.ID != review.ID {
+			return UndoResult{}, ErrCannotUndo
+		}
+		changed, err := cardChangedSince(ctx, qtx, card, review.ReviewTime)
+		if err != nil {
+			return UndoResult{}, err
+		}
+		if changed {
+			return UndoResult{}, ErrCannotUndo
+		}
+		card, err = qtx.RestoreCardState(ctx, database.RestoreCardStateParams{
+			DueDate:    review.PriorDueDate,
+			Stability:  review.PriorStability,
+			Difficulty: review.PriorDifficulty,
+			Interval:   review.PriorInterval,
+			Status:     review.PriorStatus,
+			Reps:       review.PriorReps,
+			Lapses:     review.PriorLapses,
+			Step:       review.PriorStep.Int64,
+			Ease:       review.PriorEase,
+			LeechedAt:  review.PriorLeechedAt,
+			ID:         card.ID,
+		})
+		if err != nil {
+			return UndoResult{}, fmt.Errorf("failed to restore card: %w", err)
+		}
+	}
+
+	if err := qtx.DeleteReview(ctx, review.ID); err != nil {
+		return UndoResult{}, fmt.Errorf("failed to delete review: %w", err)
+	}
+	if review.Scheduled {
+		day, err := userStudyDay(ctx, qtx, userID)
+		if err != nil {
+			return UndoResult{}, err
+		}
+		err = qtx.UnburySiblingCards(ctx, database.UnburySiblingCardsParams{
+			NoteID: card.NoteID,
+			Since:  day.Start(now),
+		})
+		if err != nil {
+			return UndoResult{}, fmt.Errorf("failed to unbury siblings: %w", err)
+		}
+	}
+
+	_, err = qtx.UpdateSessionCard(ctx, database.UpdateSessionCardParams{
+		Status:      review.PriorSessionCardStatus,
+		NextCramDue: review.PriorNextCramDue,
+		CramStep:    review.PriorCramStep.Int64,
+		SessionID:   session.ID,
+		CardID:      card.ID,
+	})
+	if err != nil {
+		return UndoResult{}, fmt.Errorf("failed to restore session card: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return UndoResult{}, fmt.Errorf("failed to commit undo: %w", err)
+	}
+	return UndoResult{Review: review, Card: card}, nil
+}
+
+func convertUndoResultToResponse(result UndoResult) UndoResponse {
+	rating, _ := ratingFromID(result.Review.RatingID)
+	return UndoResponse{
+		UndoneReviewID: result.Review.ID,
+		Rating:         int64(rating),
+		Card:           convertCardToStateResponse(result.Card),
+	}
+}
+
+// cardChangedSince reports whether card was written after the review at
+// reviewTime, or an applied event changed it since. Card writes are stamped to
+// the second, and the review's own land in its second or the next.
+func cardChangedSince(ctx context.Context, q *database.Queries, card database.Card, reviewTime time.Time) (bool, error) {
+	if card.UpdatedAt.After(reviewTime.Truncate(time.Second).Add(time.Second)) {
+		return true, nil
+	}
+	events, err := q.ListAppliedUserEventChangesByCard(ctx, card.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to list event changes: %w", err)
+	}
+	return len(events) > 0 && events[len(events)-1].CreatedAt.After(reviewTime), nil
+}
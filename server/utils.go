@@ -116,6 +116,18 @@ func uniqueIntSlice(in []int) []int {
 	return out
 }
 
+func uniqueStringSlice(in []string) []string {
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, s := range in {
+		if !seen[s] {
+			out = append(out, s)
+			seen[s] = true
+		}
+	}
+	return out
+}
+
 func getUserFromContext(c echo.Context) (database.User, error) {
 	user, ok := c.Get("user").(database.User)
 	if !ok {
@@ -128,16 +140,12 @@ func getUserFromContext(c echo.Context) (database.User, error) {
 func validateRequest(c echo.Context, req interface{}) error {
 	if err := c.Bind(req); err != nil {
 		logging.SlogLogger.Error("Invalid Request Received", "error", err)
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: "Invalid request",
-		})
+		return err
 	}
 
 	if err := c.Validate(req); err != nil {
 		logging.SlogLogger.Error("Invalid Bind Request Validation", "error", err)
-		return c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: "Failed validation",
-		})
+		return err
 	}
 
 	return nil